@@ -0,0 +1,232 @@
+package apierrors
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"simple-connect/api/httputils"
+	"simple-connect/api/internal"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Domain is the ErrorInfo domain of every reason sent by this server
+const Domain = "simple-connect"
+
+// Reasons are stable, clients may switch on them where codes are too coarse. Errors without one of
+// these get their code as reason, e.g. NOT_FOUND.
+const (
+	ReasonInternal                = "INTERNAL"
+	ReasonEmailTaken              = "EMAIL_TAKEN"
+	ReasonEmailNotVerified        = "EMAIL_NOT_VERIFIED"
+	ReasonInvalidCredentials      = "INVALID_CREDENTIALS"
+	ReasonInvalidEmail            = "INVALID_EMAIL"
+	ReasonInvalidToken            = "INVALID_TOKEN"
+	ReasonInvalidMFACode          = "INVALID_MFA_CODE"
+	ReasonAccountLocked           = "ACCOUNT_LOCKED"
+	ReasonTooManyAttempts         = "TOO_MANY_ATTEMPTS"
+	ReasonRateLimited             = "RATE_LIMITED"
+	ReasonPasswordMismatch        = "PASSWORD_MISMATCH"
+	ReasonPasswordRejected        = "PASSWORD_REJECTED"
+	ReasonSessionRequired         = "SESSION_REQUIRED"
+	ReasonPermissionDenied        = "PERMISSION_DENIED"
+	ReasonAccountAlreadyLinked    = "ACCOUNT_ALREADY_LINKED"
+	ReasonLastLoginMethod         = "LAST_LOGIN_METHOD"
+	ReasonUnknownRole             = "UNKNOWN_ROLE"
+	ReasonUnknownUser             = "UNKNOWN_USER"
+	ReasonLastOwner               = "LAST_OWNER"
+	ReasonAlreadyMember           = "ALREADY_MEMBER"
+	ReasonInvitationEmailMismatch = "INVITATION_EMAIL_MISMATCH"
+)
+
+// Error is an error clients are meant to see, with the code and reason it is sent with. Packages
+// declare their sentinel errors with New, handlers may return them as is or wrapped.
+type Error struct {
+	Code    connect.Code
+	Reason  string
+	Message string
+}
+
+func New(code connect.Code, reason string, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// redacted codes are for failures on our side, their messages aren't meant for clients
+func redacted(code connect.Code) bool {
+	return code == connect.CodeInternal || code == connect.CodeUnknown || code == connect.CodeDataLoss
+}
+
+// FromError is err as it is sent to clients, with an ErrorInfo detail. Errors that don't wrap an *Error
+// and have an internal code, or none, are logged with the request id and replaced by a generic message.
+func FromError(ctx context.Context, err error) *connect.Error {
+	var connectErr *connect.Error
+	var apiErr *Error
+
+	isConnect := errors.As(err, &connectErr)
+	reason := ""
+
+	if errors.As(err, &apiErr) {
+		reason = apiErr.Reason
+
+		if !isConnect {
+			connectErr = connect.NewError(apiErr.Code, err)
+		}
+	}
+
+	code := connect.CodeOf(err)
+
+	if reason == "" && redacted(code) {
+		internal.RpcLogger(ctx).Error("internal error", slog.String("Error", err.Error()), slog.String("code", code.String()))
+
+		connectErr = connect.NewError(connect.CodeInternal, errors.New("internal error"))
+		reason = ReasonInternal
+	} else if connectErr == nil {
+		connectErr = connect.NewError(code, err)
+	}
+
+	if reason == "" {
+		reason = strings.ToUpper(connectErr.Code().String())
+	}
+
+	for _, detail := range connectErr.Details() {
+		if detail.Type() == "google.rpc.ErrorInfo" {
+			return connectErr
+		}
+	}
+
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: Domain}
+
+	if requestId := internal.RequestIDFromContext(ctx); requestId != "" {
+		info.Metadata = map[string]string{"request_id": requestId}
+	}
+
+	if detail, detailErr := connect.NewErrorDetail(info); detailErr == nil {
+		connectErr.AddDetail(detail)
+	}
+
+	return connectErr
+}
+
+// ReasonOf returns the reason FromError attached to err, "" if there is none
+func ReasonOf(err error) string {
+	var connectErr *connect.Error
+
+	if !errors.As(err, &connectErr) {
+		return ""
+	}
+
+	for _, detail := range connectErr.Details() {
+		value, valueErr := detail.Value()
+
+		if info, ok := value.(*errdetails.ErrorInfo); valueErr == nil && ok {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
+
+// HTTPStatus is the status the Connect protocol answers code with
+func HTTPStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499
+	case connect.CodeInvalidArgument, connect.CodeFailedPrecondition, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorResponse is the JSON body of failed REST requests, shaped like Connect's JSON errors with the
+// ErrorInfo flattened into it
+type ErrorResponse struct {
+	Code     string            `json:"code"`
+	Message  string            `json:"message"`
+	Reason   string            `json:"reason"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewErrorResponse returns the status and body err is answered with, see FromError
+func NewErrorResponse(ctx context.Context, err error) (int, ErrorResponse) {
+	connectErr := FromError(ctx, err)
+	res := ErrorResponse{Code: connectErr.Code().String(), Message: connectErr.Message()}
+
+	for _, detail := range connectErr.Details() {
+		value, valueErr := detail.Value()
+
+		if info, ok := value.(*errdetails.ErrorInfo); valueErr == nil && ok {
+			res.Reason = info.Reason
+			res.Metadata = info.Metadata
+			break
+		}
+	}
+
+	return HTTPStatus(connectErr.Code()), res
+}
+
+// WriteJSON answers a REST request with err
+func WriteJSON(w http.ResponseWriter, r *http.Request, err error) {
+	status, res := NewErrorResponse(r.Context(), err)
+
+	httputils.WriteJSONStatus(w, status, res)
+}
+
+// Interceptor passes every error a handler or a later interceptor returns through FromError. Place
+// it first so it sees all of them.
+type Interceptor struct{}
+
+func NewInterceptor() *Interceptor {
+	return &Interceptor{}
+}
+
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		res, err := next(ctx, req)
+
+		if err != nil && !req.Spec().IsClient {
+			return nil, FromError(ctx, err)
+		}
+
+		return res, err
+	}
+}
+
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		err := next(ctx, conn)
+
+		if err != nil {
+			return FromError(ctx, err)
+		}
+
+		return nil
+	}
+}
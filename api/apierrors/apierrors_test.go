@@ -0,0 +1,106 @@
+package apierrors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"simple-connect/api/internal"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedBuffer collects logs the server writes while tests read them
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+var errTaken = New(connect.CodeAlreadyExists, ReasonEmailTaken, "email is already taken")
+
+// failingAuthService fails Login with an internal error and Signup with errTaken
+type failingAuthService struct {
+	apiv1connect.UnimplementedAuthServiceHandler
+}
+
+func (s *failingAuthService) Login(ctx context.Context, req *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error) {
+	return nil, connect.NewError(connect.CodeInternal, errors.New("connection refused by db:5432"))
+}
+
+func (s *failingAuthService) Signup(ctx context.Context, req *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error) {
+	return nil, connect.NewError(connect.CodeAlreadyExists, errTaken)
+}
+
+func TestErrors(t *testing.T) {
+
+	t.Parallel()
+
+	logs := &lockedBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewAuthServiceHandler(&failingAuthService{}, connect.WithInterceptors(NewInterceptor())))
+	mux.HandleFunc("POST /rest/{$}", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, r, errTaken)
+	})
+
+	server := httptest.NewServer(alice.New(internal.RequestIdMiddleware(), internal.LoggingMiddleware(*logger)).Then(mux))
+	defer server.Close()
+
+	client := apiv1connect.NewAuthServiceClient(http.DefaultClient, server.URL)
+
+	t.Run("internal errors are redacted", func(t *testing.T) {
+		_, err := client.Login(context.Background(), connect.NewRequest(&v1.LoginRequest{}))
+
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, connect.CodeInternal, connectErr.Code())
+		assert.Equal(t, "internal error", connectErr.Message())
+		assert.Equal(t, ReasonInternal, ReasonOf(err))
+		assert.Contains(t, logs.String(), "connection refused by db:5432")
+	})
+
+	t.Run("sentinels keep their reason", func(t *testing.T) {
+		_, err := client.Signup(context.Background(), connect.NewRequest(&v1.SignupRequest{}))
+		assert.Equal(t, connect.CodeAlreadyExists, connect.CodeOf(err))
+		assert.Equal(t, ReasonEmailTaken, ReasonOf(err))
+	})
+
+	t.Run("rest errors match connect errors", func(t *testing.T) {
+		res, err := http.Post(server.URL+"/rest/", "application/json", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, "already_exists", body.Code)
+		assert.Equal(t, ReasonEmailTaken, body.Reason)
+		assert.NotEmpty(t, body.Metadata["request_id"])
+	})
+}
@@ -0,0 +1,82 @@
+// Package audit persists security relevant events, such as sign ins and credential changes,
+// and lets users and administrators page through them.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"simple-connect/api/auth"
+	"simple-connect/api/internal"
+	"time"
+)
+
+// PermissionRead lets a user read every user's events, not just their own
+const PermissionRead = "audit.read"
+
+type Event struct {
+	ID        int64          `db:"id"`
+	ActorID   *string        `db:"actor_id"`
+	Action    string         `db:"action"`
+	TargetID  *string        `db:"target_id"`
+	IPAddress string         `db:"ip_address"`
+	UserAgent string         `db:"user_agent"`
+	RequestID string         `db:"request_id"`
+	Metadata  map[string]any `db:"metadata"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// Filter selects events, newest first. Zero fields match everything.
+type Filter struct {
+	// UserID matches events the user acted in or was the target of
+	UserID string
+	// Action matches exactly, or as a prefix when it ends in "."
+	Action string
+	Since  time.Time
+	Until  time.Time
+	// BeforeID continues a previous page
+	BeforeID int64
+	Limit    int
+}
+
+type Store interface {
+	InsertEvent(ctx context.Context, event Event) error
+	ListEvents(ctx context.Context, filter Filter) ([]Event, error)
+}
+
+// Recorder implements auth.AuditLogger, filling in the client and request id from the context
+type Recorder struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewRecorder(store Store, logger *slog.Logger) *Recorder {
+	return &Recorder{store: store, logger: logger.WithGroup("audit")}
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func (r *Recorder) Record(ctx context.Context, event auth.AuditEvent) {
+	client := internal.ClientInfoFromContext(ctx)
+	requestId := internal.RequestIDFromContext(ctx)
+
+	// The request may be cancelled once the response is written, the event should still be stored
+	err := r.store.InsertEvent(context.WithoutCancel(ctx), Event{
+		ActorID:   optional(event.ActorID),
+		Action:    event.Action,
+		TargetID:  optional(event.TargetID),
+		IPAddress: client.IP,
+		UserAgent: client.UserAgent,
+		RequestID: requestId,
+		Metadata:  event.Metadata,
+	})
+
+	if err != nil {
+		r.logger.Error("Error recording audit event", slog.String("action", event.Action), slog.String("request_id", requestId), slog.String("err", err.Error()))
+	}
+}
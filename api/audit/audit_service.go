@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditService struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditService(pool *pgxpool.Pool) *AuditService {
+	return &AuditService{pool: pool}
+}
+
+func (as *AuditService) InsertEvent(ctx context.Context, event Event) error {
+
+	metadata := event.Metadata
+
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	_, err := as.pool.Exec(ctx,
+		"INSERT INTO audit_events (actor_id, action, target_id, ip_address, user_agent, request_id, metadata) VALUES (@actor_id, @action, @target_id, @ip_address, @user_agent, @request_id, @metadata)",
+		pgx.NamedArgs{
+			"actor_id":   event.ActorID,
+			"action":     event.Action,
+			"target_id":  event.TargetID,
+			"ip_address": event.IPAddress,
+			"user_agent": event.UserAgent,
+			"request_id": event.RequestID,
+			"metadata":   metadata,
+		})
+
+	return err
+}
+
+func (as *AuditService) ListEvents(ctx context.Context, filter Filter) ([]Event, error) {
+
+	var conditions []string
+	args := pgx.NamedArgs{}
+
+	if filter.UserID != "" {
+		conditions = append(conditions, "(actor_id = @user_id OR target_id = @user_id)")
+		args["user_id"] = filter.UserID
+	}
+
+	if prefix, ok := strings.CutSuffix(filter.Action, "."); ok {
+		conditions = append(conditions, "action LIKE @action_prefix")
+		args["action_prefix"] = escapeLike(prefix) + ".%"
+	} else if filter.Action != "" {
+		conditions = append(conditions, "action = @action")
+		args["action"] = filter.Action
+	}
+
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= @since")
+		args["since"] = filter.Since
+	}
+
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < @until")
+		args["until"] = filter.Until
+	}
+
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "id < @before_id")
+		args["before_id"] = filter.BeforeID
+	}
+
+	query := "SELECT id, actor_id, action, target_id, ip_address, user_agent, request_id, metadata, created_at FROM audit_events"
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY id DESC LIMIT " + strconv.Itoa(filter.Limit)
+
+	rows, err := as.pool.Query(ctx, query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Event])
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"simple-connect/api/auth"
+	"simple-connect/api/authz"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strconv"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const DefaultPageSize = 50
+const MaxPageSize = 200
+
+// Handler serves AuditService
+type Handler struct {
+	store      Store
+	authorizer *authz.Authorizer
+}
+
+func NewHandler(store Store, authorizer *authz.Authorizer) *Handler {
+	return &Handler{store: store, authorizer: authorizer}
+}
+
+func encodePageToken(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(raw), 10, 64)
+}
+
+func (e *Event) toProto() (*v1.AuditEvent, error) {
+	metadata, err := structpb.NewStruct(e.Metadata)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event := &v1.AuditEvent{
+		Id:        strconv.FormatInt(e.ID, 10),
+		Action:    e.Action,
+		IpAddress: e.IPAddress,
+		UserAgent: e.UserAgent,
+		RequestId: e.RequestID,
+		Metadata:  metadata,
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	}
+
+	if e.ActorID != nil {
+		event.ActorId = *e.ActorID
+	}
+
+	if e.TargetID != nil {
+		event.TargetId = *e.TargetID
+	}
+
+	return event, nil
+}
+
+func (h *Handler) ListAuditEvents(ctx context.Context, req *connect.Request[v1.ListAuditEventsRequest]) (*connect.Response[v1.ListAuditEventsResponse], error) {
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	canReadAll, err := h.authorizer.HasPermission(ctx, userId, PermissionRead)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	filter := Filter{
+		UserID: req.Msg.UserId,
+		Action: req.Msg.Action,
+		Limit:  DefaultPageSize,
+	}
+
+	if !canReadAll {
+		if filter.UserID != "" && filter.UserID != userId {
+			return nil, connect.NewError(connect.CodePermissionDenied, authz.ErrPermissionDenied)
+		}
+
+		filter.UserID = userId
+	}
+
+	if req.Msg.Since != nil {
+		filter.Since = req.Msg.Since.AsTime()
+	}
+
+	if req.Msg.Until != nil {
+		filter.Until = req.Msg.Until.AsTime()
+	}
+
+	if req.Msg.PageSize > 0 {
+		filter.Limit = min(int(req.Msg.PageSize), MaxPageSize)
+	}
+
+	if req.Msg.PageToken != "" {
+		filter.BeforeID, err = decodePageToken(req.Msg.PageToken)
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("invalid page token"))
+		}
+	}
+
+	// Fetch one extra event to know whether there is another page
+	limit := filter.Limit
+	filter.Limit++
+
+	events, err := h.store.ListEvents(ctx, filter)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListAuditEventsResponse{}
+
+	if len(events) > limit {
+		events = events[:limit]
+		res.NextPageToken = encodePageToken(events[limit-1].ID)
+	}
+
+	for _, e := range events {
+		event, err := e.toProto()
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		res.Events = append(res.Events, event)
+	}
+
+	return connect.NewResponse(res), nil
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"simple-connect/api/auth"
+	"simple-connect/api/authz"
+	"simple-connect/api/internal"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (ms *memoryStore) InsertEvent(ctx context.Context, event Event) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	event.ID = int64(len(ms.events) + 1)
+	event.CreatedAt = time.Now()
+	ms.events = append(ms.events, event)
+
+	return nil
+}
+
+func (ms *memoryStore) ListEvents(ctx context.Context, filter Filter) ([]Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var events []Event
+
+	for _, e := range slices.Backward(ms.events) {
+		if filter.UserID != "" && (e.ActorID == nil || *e.ActorID != filter.UserID) && (e.TargetID == nil || *e.TargetID != filter.UserID) {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(filter.Action, "."); ok && !strings.HasPrefix(e.Action, prefix+".") {
+			continue
+		} else if !ok && filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+
+		if filter.BeforeID > 0 && e.ID >= filter.BeforeID {
+			continue
+		}
+
+		events = append(events, e)
+
+		if len(events) == filter.Limit {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+func TestListAuditEvents(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryStore{}
+	recorder := NewRecorder(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	// Events are recorded behind the same middleware as the server's, which supplies the client and request id
+	chain := alice.New(internal.RequestIdMiddleware(), internal.ClientInfoMiddleware(false))
+	record := func(event auth.AuditEvent, requestId string) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("User-Agent", "audit-test")
+		req.Header.Set(internal.RequestIdHeader, requestId)
+
+		chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder.Record(r.Context(), event)
+		}).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for range 3 {
+		record(auth.AuditEvent{ActorID: "user", Action: auth.AuditLoginSucceeded}, "")
+	}
+	record(auth.AuditEvent{TargetID: "user", Action: auth.AuditLoginFailed}, "")
+	record(auth.AuditEvent{ActorID: "other", Action: auth.AuditSignup}, "signup-request")
+
+	sessionManager := auth.NewMemorySessionManager(false, "")
+	permissions := authz.NewMemoryStore([]authz.Role{{Name: "auditor", Permissions: []string{PermissionRead}}}, map[string][]string{
+		"auditor": {"auditor"},
+	})
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return auth.Login(r, sessionManager, auth.SessionData{UserID: userId})
+	})
+	server.Mux.Handle(apiv1connect.NewAuditServiceHandler(NewHandler(store, authz.NewAuthorizer(permissions)), connect.WithInterceptors(
+		auth.NewAuthInterceptor(sessionManager, nil),
+	)))
+
+	clientFor := func(userId string) apiv1connect.AuditServiceClient {
+		return apiv1connect.NewAuditServiceClient(server.ClientFor(userId), server.URL)
+	}
+
+	user := clientFor("user")
+	auditor := clientFor("auditor")
+
+	t.Run("users page through their own events", func(t *testing.T) {
+		first, err := user.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{PageSize: 3}))
+		require.NoError(t, err)
+		require.Len(t, first.Msg.Events, 3)
+		assert.Equal(t, auth.AuditLoginFailed, first.Msg.Events[0].Action)
+		require.NotEmpty(t, first.Msg.NextPageToken)
+
+		second, err := user.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{PageSize: 3, PageToken: first.Msg.NextPageToken}))
+		require.NoError(t, err)
+		assert.Len(t, second.Msg.Events, 1)
+		assert.Empty(t, second.Msg.NextPageToken)
+	})
+
+	t.Run("users can't read other users' events", func(t *testing.T) {
+		_, err := user.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{UserId: "other"}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+
+	t.Run("auditors read everything and filter by action prefix", func(t *testing.T) {
+		res, err := auditor.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{}))
+		require.NoError(t, err)
+		assert.Len(t, res.Msg.Events, 5)
+
+		res, err = auditor.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{Action: "auth.login."}))
+		require.NoError(t, err)
+		assert.Len(t, res.Msg.Events, 4)
+	})
+
+	t.Run("events carry the client and request id", func(t *testing.T) {
+		res, err := auditor.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{}))
+		require.NoError(t, err)
+
+		for _, event := range res.Msg.Events {
+			assert.Equal(t, "192.0.2.1", event.IpAddress)
+			assert.Equal(t, "audit-test", event.UserAgent)
+			assert.NotEmpty(t, event.RequestId)
+		}
+
+		assert.Equal(t, "signup-request", res.Msg.Events[0].RequestId)
+	})
+
+	t.Run("invalid page tokens are rejected", func(t *testing.T) {
+		_, err := user.ListAuditEvents(ctx, connect.NewRequest(&v1.ListAuditEventsRequest{PageToken: "!"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+}
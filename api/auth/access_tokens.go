@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"simple-connect/api/apierrors"
+	v1 "simple-connect/gen/proto/api/v1"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AccessTokenPrefix starts every personal access token so leaked tokens are easy to recognise and scan for
+const AccessTokenPrefix = "sc_pat_"
+
+// accessTokenDisplayLength is how much of the token is kept in clear to tell tokens apart
+const accessTokenDisplayLength = len(AccessTokenPrefix) + 6
+
+const DefaultAccessTokenLifetime = 30 * 24 * time.Hour
+const MaxAccessTokenLifetime = 365 * 24 * time.Hour
+
+// accessTokenTouchInterval is how stale last_used_at gets before a request writes it again
+const accessTokenTouchInterval = time.Minute
+
+// ScopeRead allows procedures without side effects, ScopeWrite allows everything a token can do
+const ScopeRead = "read"
+const ScopeWrite = "write"
+
+var accessTokenScopes = []string{ScopeRead, ScopeWrite}
+
+var ErrSessionRequired = apierrors.New(connect.CodePermissionDenied, apierrors.ReasonSessionRequired, "this operation requires a signed in session")
+
+type AccessToken struct {
+	ID          string     `db:"id"`
+	UserID      string     `db:"user_id"`
+	Name        string     `db:"name"`
+	TokenPrefix string     `db:"token_prefix"`
+	Scopes      []string   `db:"scopes"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+func (t *AccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || (scope == ScopeRead && slices.Contains(t.Scopes, ScopeWrite))
+}
+
+func (t *AccessToken) toProto() *v1.AccessToken {
+	token := &v1.AccessToken{
+		Id:        t.ID,
+		Name:      t.Name,
+		Prefix:    t.TokenPrefix,
+		Scopes:    t.Scopes,
+		CreatedAt: timestamppb.New(t.CreatedAt),
+		ExpiresAt: timestamppb.New(t.ExpiresAt),
+	}
+
+	if t.LastUsedAt != nil {
+		token.LastUsedAt = timestamppb.New(*t.LastUsedAt)
+	}
+
+	return token
+}
+
+// AccessTokenStore looks up personal access tokens presented as bearer tokens
+type AccessTokenStore interface {
+	GetAccessTokenByHash(ctx context.Context, tokenHash string) (*AccessToken, error)
+	TouchAccessToken(ctx context.Context, id string) error
+}
+
+func generateAccessToken() (string, error) {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	return AccessTokenPrefix + token, nil
+}
+
+func (as *ProtectedAuthHandler) CreateAccessToken(ctx context.Context, req *connect.Request[v1.CreateAccessTokenRequest]) (*connect.Response[v1.CreateAccessTokenResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	if strings.TrimSpace(req.Msg.Name) == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+
+	scopes := req.Msg.Scopes
+
+	if len(scopes) == 0 {
+		scopes = []string{ScopeRead}
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(accessTokenScopes, scope) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("unknown scope "+scope))
+		}
+	}
+
+	lifetime := DefaultAccessTokenLifetime
+
+	if req.Msg.ExpiresInDays > 0 {
+		lifetime = time.Duration(req.Msg.ExpiresInDays) * 24 * time.Hour
+	}
+
+	if lifetime > MaxAccessTokenLifetime {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("access tokens can live at most 365 days"))
+	}
+
+	raw, err := generateAccessToken()
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	token, err := as.store.CreateAccessToken(ctx, AccessToken{
+		UserID:      userId,
+		Name:        req.Msg.Name,
+		TokenPrefix: raw[:accessTokenDisplayLength],
+		Scopes:      slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt:   time.Now().Add(lifetime),
+	}, HashToken(raw))
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{
+		ActorID:  userId,
+		Action:   AuditAccessTokenCreated,
+		TargetID: token.ID,
+		Metadata: map[string]any{"name": token.Name, "scopes": token.Scopes},
+	})
+
+	return connect.NewResponse(&v1.CreateAccessTokenResponse{
+		AccessToken: token.toProto(),
+		Token:       raw,
+	}), nil
+}
+
+func (as *ProtectedAuthHandler) ListAccessTokens(ctx context.Context, req *connect.Request[v1.ListAccessTokensRequest]) (*connect.Response[v1.ListAccessTokensResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	tokens, err := as.store.ListAccessTokens(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListAccessTokensResponse{}
+
+	for _, t := range tokens {
+		res.AccessTokens = append(res.AccessTokens, t.toProto())
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (as *ProtectedAuthHandler) RevokeAccessToken(ctx context.Context, req *connect.Request[v1.RevokeAccessTokenRequest]) (*connect.Response[v1.RevokeAccessTokenResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	ok, err := as.store.DeleteAccessToken(ctx, userId, req.Msg.Id)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("access token not found"))
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditAccessTokenRevoked, TargetID: req.Msg.Id})
+
+	return connect.NewResponse(&v1.RevokeAccessTokenResponse{}), nil
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAccessTokenStore struct {
+	AuthStore
+
+	mu      sync.Mutex
+	tokens  map[string]*AccessToken
+	touches int
+}
+
+func (ms *memoryAccessTokenStore) CreateAccessToken(ctx context.Context, token AccessToken, tokenHash string) (*AccessToken, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token.ID = strconv.Itoa(len(ms.tokens))
+	token.CreatedAt = time.Now()
+	ms.tokens[tokenHash] = &token
+
+	return &token, nil
+}
+
+func (ms *memoryAccessTokenStore) ListAccessTokens(ctx context.Context, userId string) ([]AccessToken, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var tokens []AccessToken
+
+	for _, t := range ms.tokens {
+		if t.UserID == userId {
+			tokens = append(tokens, *t)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (ms *memoryAccessTokenStore) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*AccessToken, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.tokens[tokenHash]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	copied := *token
+	return &copied, nil
+}
+
+func (ms *memoryAccessTokenStore) TouchAccessToken(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.touches++
+
+	for _, t := range ms.tokens {
+		if t.ID == id {
+			now := time.Now()
+			t.LastUsedAt = &now
+		}
+	}
+
+	return nil
+}
+
+// bearerTransport adds an Authorization header to every request
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (bt *bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+bt.token)
+	return bt.base.RoundTrip(r)
+}
+
+func TestAccessTokens(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryAccessTokenStore{tokens: map[string]*AccessToken{}}
+	sessionManager := NewMemorySessionManager(false, "")
+
+	handler := NewProtectedAuthHandler(store, sessionManager, nil, NewProviderRegistry(), nil)
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	})
+	server.Mux.Handle(apiv1connect.NewProtectedAuthServiceHandler(handler, connect.WithInterceptors(NewAuthInterceptor(sessionManager, store))))
+
+	session := apiv1connect.NewProtectedAuthServiceClient(server.ClientFor("script-user"), server.URL)
+
+	bearer := func(token string) apiv1connect.ProtectedAuthServiceClient {
+		client := *server.Client()
+		client.Transport = &bearerTransport{token: token, base: server.Client().Transport}
+		return apiv1connect.NewProtectedAuthServiceClient(&client, server.URL)
+	}
+
+	created, err := session.CreateAccessToken(context.Background(), connect.NewRequest(&v1.CreateAccessTokenRequest{
+		Name: "ci",
+	}))
+	require.NoError(t, err)
+
+	readToken := created.Msg.Token
+	assert.True(t, strings.HasPrefix(readToken, AccessTokenPrefix))
+	assert.True(t, strings.HasPrefix(readToken, created.Msg.AccessToken.Prefix))
+	assert.Equal(t, []string{ScopeRead}, created.Msg.AccessToken.Scopes)
+
+	t.Run("read tokens call procedures without side effects", func(t *testing.T) {
+		list, err := bearer(readToken).ListAccessTokens(context.Background(), connect.NewRequest(&v1.ListAccessTokensRequest{}))
+		require.NoError(t, err)
+		require.Len(t, list.Msg.AccessTokens, 1)
+		assert.NotNil(t, list.Msg.AccessTokens[0].LastUsedAt)
+	})
+
+	t.Run("read tokens can't call procedures with side effects", func(t *testing.T) {
+		_, err := bearer(readToken).RevokeOtherSessions(context.Background(), connect.NewRequest(&v1.RevokeOtherSessionsRequest{}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+
+	t.Run("tokens can't create tokens", func(t *testing.T) {
+		writeToken, err := session.CreateAccessToken(context.Background(), connect.NewRequest(&v1.CreateAccessTokenRequest{
+			Name:   "deploy",
+			Scopes: []string{ScopeWrite},
+		}))
+		require.NoError(t, err)
+
+		_, err = bearer(writeToken.Msg.Token).CreateAccessToken(context.Background(), connect.NewRequest(&v1.CreateAccessTokenRequest{Name: "escalate"}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+
+	t.Run("anonymous requests are unauthenticated", func(t *testing.T) {
+		anonymous := apiv1connect.NewProtectedAuthServiceClient(server.Client(), server.URL)
+
+		_, err := anonymous.ListAccessTokens(context.Background(), connect.NewRequest(&v1.ListAccessTokensRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("unknown, malformed and expired tokens are rejected", func(t *testing.T) {
+		expired, err := store.CreateAccessToken(context.Background(), AccessToken{
+			UserID:    "script-user",
+			Scopes:    []string{ScopeWrite},
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}, HashToken(AccessTokenPrefix+"expired"))
+		require.NoError(t, err)
+		require.NotNil(t, expired)
+
+		for _, token := range []string{AccessTokenPrefix + "unknown", "not-a-token", AccessTokenPrefix + "expired"} {
+			_, err := bearer(token).ListAccessTokens(context.Background(), connect.NewRequest(&v1.ListAccessTokensRequest{}))
+			assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), token)
+		}
+	})
+
+	t.Run("scopes and lifetime are validated", func(t *testing.T) {
+		_, err := session.CreateAccessToken(context.Background(), connect.NewRequest(&v1.CreateAccessTokenRequest{Name: "admin", Scopes: []string{"admin"}}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+		_, err = session.CreateAccessToken(context.Background(), connect.NewRequest(&v1.CreateAccessTokenRequest{Name: "forever", ExpiresInDays: 1000}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+}
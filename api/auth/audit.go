@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+)
+
+// Audited actions. Failures carry a "reason" in their metadata.
+const (
+	AuditLoginSucceeded           = "auth.login.succeeded"
+	AuditLoginFailed              = "auth.login.failed"
+	AuditAccountLocked            = "auth.account.locked"
+	AuditAccountUnlocked          = "auth.account.unlocked"
+	AuditMFAChallenged            = "auth.mfa.challenged"
+	AuditMFAFailed                = "auth.mfa.failed"
+	AuditSignup                   = "auth.signup"
+	AuditLogout                   = "auth.logout"
+	AuditEmailVerified            = "auth.email.verified"
+	AuditPasswordReset            = "auth.password.reset"
+	AuditMagicLinkRequested       = "auth.magic_link.requested"
+	AuditOAuthLogin               = "auth.oauth.login"
+	AuditOAuthFailed              = "auth.oauth.failed"
+	AuditProviderUnlinked         = "auth.provider.unlinked"
+	AuditTOTPEnabled              = "auth.totp.enabled"
+	AuditTOTPDisabled             = "auth.totp.disabled"
+	AuditRecoveryCodesRegenerated = "auth.recovery_codes.regenerated"
+	AuditSessionRevoked           = "auth.session.revoked"
+	AuditOtherSessionsRevoked     = "auth.session.revoked_others"
+	AuditAccessTokenCreated       = "auth.access_token.created"
+	AuditAccessTokenRevoked       = "auth.access_token.revoked"
+)
+
+// AuditEvent is a security relevant action. The client address, user agent and request id are
+// taken from the context by the AuditLogger.
+type AuditEvent struct {
+	// ActorID is the user who acted, "" when nobody is signed in
+	ActorID string
+	Action  string
+	// TargetID is the user or object acted on, e.g. the account a failed login tried
+	TargetID string
+	Metadata map[string]any
+}
+
+// AuditLogger persists audit events. It lives outside this package so auth doesn't depend on storage
+// for them. Recording must not fail the request, implementations report their own errors.
+type AuditLogger interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+func recordAudit(ctx context.Context, audit AuditLogger, event AuditEvent) {
+	if audit != nil {
+		audit.Record(ctx, event)
+	}
+}
@@ -5,118 +5,424 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"simple-connect/api/apierrors"
 	"simple-connect/api/httputils"
 	"simple-connect/api/internal"
+	"simple-connect/api/validation"
 	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
 
 	"connectrpc.com/connect"
 	"github.com/alexedwards/scs/v2"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/jackc/pgx/v5"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AuthHandler struct {
 	store          AuthStore
 	sessionManager *scs.SessionManager
+	verifier       *EmailVerifier
+	resetter       *PasswordResetter
+	invitations    InvitationAcceptor
+	audit          AuditLogger
+	throttle       *LoginThrottle
+	policy         PasswordPolicy
+	magicLinks     *MagicLinker
+	validator      *validation.Validator
 }
 
 type LoginResponse struct {
-	Id string `json:"id"`
+	Id             string `json:"id"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	OrganizationId string `json:"organization_id,omitempty"`
 }
 
-type SignupRequest struct {
-	Email     string `json:"email"`
-	Password1 string `json:"password1"`
-	Password2 string `json:"password2"`
+// PasswordViolationsResponse is the 400 Bad Request body for passwords that break the policy
+type PasswordViolationsResponse struct {
+	apierrors.ErrorResponse
+	Violations []*v1.PasswordViolation `json:"violations"`
 }
 
-func NewAuthHandler(store AuthStore, sessionManager *scs.SessionManager) *AuthHandler {
-	return &AuthHandler{store: store, sessionManager: sessionManager}
+var ErrInvalidCredentials = apierrors.New(connect.CodeUnauthenticated, apierrors.ReasonInvalidCredentials, "invalid email or password")
+var ErrPasswordMismatch = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonPasswordMismatch, "passwords do not match")
+
+// NewAuthHandler creates the handler. invitations may be nil, then invitation tokens passed to Signup
+// are ignored, and so may audit. A nil throttle allows unlimited login attempts. Signup passwords
+// must satisfy policy. magicLinks sends and redeems passwordless sign in links. validator checks the
+// requests of the REST shims, which the validation interceptor never sees, it may be nil when they
+// aren't mounted.
+func NewAuthHandler(store AuthStore, sessionManager *scs.SessionManager, verifier *EmailVerifier, resetter *PasswordResetter, invitations InvitationAcceptor, audit AuditLogger, throttle *LoginThrottle, policy PasswordPolicy, magicLinks *MagicLinker, validator *validation.Validator) *AuthHandler {
+	return &AuthHandler{store: store, sessionManager: sessionManager, verifier: verifier, resetter: resetter, invitations: invitations, audit: audit, throttle: throttle, policy: policy, magicLinks: magicLinks, validator: validator}
+}
+
+// loginFailed records a failed password attempt, targetId is "" for unknown emails. attempt is nil
+// without a throttle.
+func (as *AuthHandler) loginFailed(ctx context.Context, email string, targetId string, reason string, attempt *LoginAttempt) {
+	metadata := map[string]any{"reason": reason}
+
+	if targetId == "" {
+		metadata["email"] = email
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{Action: AuditLoginFailed, TargetID: targetId, Metadata: metadata})
+
+	if attempt != nil && attempt.Locked {
+		recordAudit(ctx, as.audit, AuditEvent{Action: AuditAccountLocked, TargetID: targetId, Metadata: map[string]any{"email": email}})
+	}
+}
+
+// writeRPCError answers a REST shim with the status closest to the Connect error's code and an
+// apierrors.ErrorResponse body
+func writeRPCError(w http.ResponseWriter, r *http.Request, err error) {
+	var throttleErr *ThrottleError
+
+	if errors.As(err, &throttleErr) {
+		writeThrottled(w, r, throttleErr)
+		return
+	}
+
+	var policyErr *PasswordPolicyError
+
+	if errors.As(err, &policyErr) {
+		status, res := apierrors.NewErrorResponse(r.Context(), err)
+		httputils.WriteJSONStatus(w, status, PasswordViolationsResponse{ErrorResponse: res, Violations: policyErr.Violations})
+		return
+	}
+
+	apierrors.WriteJSON(w, r, err)
+}
+
+func (as *AuthHandler) Login(ctx context.Context, req *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error) {
+	logger := internal.RpcLogger(ctx)
+	email := req.Msg.Email
+
+	var attempt *LoginAttempt
+
+	if as.throttle != nil {
+		var err error
+		attempt, err = as.throttle.Check(ctx, email, internal.ClientInfoFromContext(ctx).IP)
+
+		var throttleErr *ThrottleError
+
+		if errors.As(err, &throttleErr) {
+			recordAudit(ctx, as.audit, AuditEvent{
+				Action:   AuditLoginFailed,
+				Metadata: map[string]any{"reason": "throttled", "email": email, "locked": errors.Is(err, ErrAccountLocked)},
+			})
+			return nil, throttleErr.ConnectError()
+		}
+
+		if err != nil {
+			logger.Error("error checking login throttle", slog.String("Error", err.Error()))
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+	}
+
+	user, err := as.store.GetUserByEmail(ctx, email)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		as.loginFailed(ctx, email, "", "unknown_email", attempt)
+		return nil, connect.NewError(connect.CodeUnauthenticated, ErrInvalidCredentials)
+	}
+
+	if err != nil {
+		logger.Error("error getting user by email", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	match, err := as.store.CheckPassword(ctx, user, req.Msg.Password)
+
+	if err != nil && !match {
+		logger.Error("error checking password", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if err != nil {
+		// The password was right, the user can still sign in with the outdated hash
+		logger.Warn("error upgrading password hash", slog.String("Error", err.Error()))
+	}
+
+	if !match {
+		logger.Debug("passwords do not match")
+		as.loginFailed(ctx, email, user.ID, "invalid_password", attempt)
+		return nil, connect.NewError(connect.CodeUnauthenticated, ErrInvalidCredentials)
+	}
+
+	mfaEnabled, err := hasMFA(ctx, as.store, user.ID)
+
+	if err != nil {
+		logger.Error("error checking mfa", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	// With a second factor the attempt stays a failure of the account until VerifyMFA passes, so knowing
+	// the password doesn't reset the count for guessing codes
+	if as.throttle != nil && mfaEnabled {
+		err = as.throttle.Pending(ctx, attempt)
+
+		if err != nil {
+			logger.Error("error clearing login failures", slog.String("Error", err.Error()))
+		}
+	} else if as.throttle != nil {
+		err = as.throttle.Success(ctx, attempt)
+
+		if err != nil {
+			logger.Error("error clearing login failures", slog.String("Error", err.Error()))
+		}
+	}
+
+	if mfaEnabled {
+		err = BeginMFAContext(ctx, as.sessionManager, user.ID)
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		recordAudit(ctx, as.audit, AuditEvent{Action: AuditMFAChallenged, TargetID: user.ID})
+		return connect.NewResponse(&v1.LoginResponse{MfaRequired: true}), nil
+	}
+
+	err = LoginContext(ctx, as.sessionManager, SessionData{UserID: user.ID})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{
+		ActorID:  user.ID,
+		Action:   AuditLoginSucceeded,
+		Metadata: map[string]any{"method": "password"},
+	})
+
+	return connect.NewResponse(&v1.LoginResponse{
+		Id: user.ID,
+	}), nil
 }
 
-func (as *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	logger := internal.RequestLogger(r)
+// HandleLogin serves Login as JSON at POST /auth/login/ for clients predating the RPC
+func (as *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	loginReq := &v1.LoginRequest{}
 
 	err := httputils.ReadJSON(r, loginReq)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeInvalidArgument, err))
 		return
 	}
 
-	user, err := as.store.GetUserByEmail(r.Context(), loginReq.Email)
+	err = as.validator.Validate(loginReq)
 
 	if err != nil {
-		logger.Error("error getting user by email", slog.String("Error", err.Error()))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeRPCError(w, r, err)
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(loginReq.Password))
+	res, err := as.Login(r.Context(), connect.NewRequest(loginReq))
 
 	if err != nil {
-		logger.Debug("passwords do not match", slog.String("pw", user.PasswordHash.String))
-		w.WriteHeader(http.StatusUnauthorized)
+		writeRPCError(w, r, err)
 		return
 	}
 
-	err = Login(r, as.sessionManager, SessionData{UserID: user.ID})
+	httputils.WriteJSON(w, r, LoginResponse{Id: res.Msg.Id, MFARequired: res.Msg.MfaRequired})
+}
+
+// normalizeEmail is the form emails are stored in by Signup, provider accounts and magic links. Lookups by
+// email ignore case, so accounts stored before keep working, and users_lower_email_idx keeps them unique.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (as *AuthHandler) Signup(ctx context.Context, req *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error) {
+	logger := internal.RpcLogger(ctx)
+	msg := req.Msg
+	email := normalizeEmail(msg.Email)
+
+	if msg.Password1 != msg.Password2 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, ErrPasswordMismatch)
+	}
+
+	err := as.policy.Check(ctx, msg.Password1, email)
+
+	var policyErr *PasswordPolicyError
+
+	if errors.As(err, &policyErr) {
+		return nil, policyErr.ConnectError()
+	}
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		logger.Error("error checking password policy", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	id, err := as.store.CreateUser(ctx, email, msg.Password1)
+
+	if errors.Is(err, ErrEmailTaken) {
+		return nil, connect.NewError(connect.CodeAlreadyExists, err)
+	}
+
+	if err != nil {
+		logger.Error("error creating user", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = as.verifier.SendVerification(ctx, id, email)
+
+	if err != nil {
+		// The account exists at this point, the user can request another email later
+		logger.Error("error sending verification email", slog.String("Error", err.Error()))
+	}
+
+	err = LoginContext(ctx, as.sessionManager, SessionData{UserID: id})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: id, Action: AuditSignup})
+
+	orgId, err := acceptInvitation(ctx, as.invitations, as.sessionManager, id, msg.InvitationToken)
+
+	if err != nil {
+		// Signing up still succeeded, the user can ask for a new invitation
+		logger.Warn("error accepting invitation", slog.String("Error", err.Error()))
 	}
 
-	httputils.WriteJSON(w, r, LoginResponse{Id: user.ID})
+	return connect.NewResponse(&v1.SignupResponse{
+		Id:             id,
+		OrganizationId: orgId,
+	}), nil
 }
 
-func (as *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
-	logger := internal.RequestLogger(r)
-	signupReq := &SignupRequest{}
+// HandleSignup serves Signup as JSON at POST /auth/signup/ for clients predating the RPC
+func (as *AuthHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
+	signupReq := &v1.SignupRequest{}
 
 	err := httputils.ReadJSON(r, signupReq)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeInvalidArgument, err))
 		return
 	}
 
-	if signupReq.Password1 != signupReq.Password2 {
-		w.WriteHeader(http.StatusBadRequest)
+	err = as.validator.Validate(signupReq)
+
+	if err != nil {
+		writeRPCError(w, r, err)
 		return
 	}
 
-	id, err := as.store.CreateUser(r.Context(), signupReq.Email, signupReq.Password1)
+	res, err := as.Signup(r.Context(), connect.NewRequest(signupReq))
 
 	if err != nil {
-		logger.Error("error creating user", slog.String("Error", err.Error()))
-		w.WriteHeader(http.StatusInternalServerError)
+		writeRPCError(w, r, err)
 		return
 	}
 
-	err = Login(r, as.sessionManager, SessionData{UserID: id})
+	httputils.WriteJSON(w, r, LoginResponse{Id: res.Msg.Id, OrganizationId: res.Msg.OrganizationId})
+}
+
+func (as *AuthHandler) Logout(ctx context.Context, req *connect.Request[v1.LogoutRequest]) (*connect.Response[v1.LogoutResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	err := as.sessionManager.Destroy(ctx)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditLogout})
+
+	return connect.NewResponse(&v1.LogoutResponse{}), nil
+}
+
+// HandleLogout serves Logout at POST /auth/logout/ for clients predating the RPC, behind RequireAuthMiddleWare
+func (as *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	_, err := as.Logout(r.Context(), connect.NewRequest(&v1.LogoutRequest{}))
+
+	if err != nil {
+		writeRPCError(w, r, err)
 		return
 	}
 
-	httputils.WriteJSON(w, r, LoginResponse{Id: id})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (as *AuthHandler) VerifyEmail(ctx context.Context, req *connect.Request[v1.VerifyEmailRequest]) (*connect.Response[v1.VerifyEmailResponse], error) {
+
+	userId, err := as.verifier.Verify(ctx, req.Msg.Token)
+
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{Action: AuditEmailVerified, TargetID: userId})
+
+	return connect.NewResponse(&v1.VerifyEmailResponse{
+		Id: userId,
+	}), nil
+}
+
+func (as *AuthHandler) RequestPasswordReset(ctx context.Context, req *connect.Request[v1.RequestPasswordResetRequest]) (*connect.Response[v1.RequestPasswordResetResponse], error) {
+	logger := internal.RpcLogger(ctx)
+	email := req.Msg.Email
+
+	// Respond the same way whether or not the email is registered, and don't let
+	// the time spent creating and mailing the token give it away either.
+	go func() {
+		err := as.resetter.RequestReset(context.WithoutCancel(ctx), email)
+
+		if err != nil {
+			logger.Error("error requesting password reset", slog.String("Error", err.Error()))
+		}
+	}()
+
+	return connect.NewResponse(&v1.RequestPasswordResetResponse{}), nil
+}
+
+func (as *AuthHandler) ResetPassword(ctx context.Context, req *connect.Request[v1.ResetPasswordRequest]) (*connect.Response[v1.ResetPasswordResponse], error) {
+
+	userId, err := as.resetter.Reset(ctx, req.Msg.Token, req.Msg.Password)
+
+	var policyErr *PasswordPolicyError
+
+	if errors.As(err, &policyErr) {
+		return nil, policyErr.ConnectError()
+	}
+
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrEmptyPassword) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{Action: AuditPasswordReset, TargetID: userId})
+
+	return connect.NewResponse(&v1.ResetPasswordResponse{}), nil
 }
 
 type ProtectedAuthHandler struct {
 	store          AuthStore
 	sessionManager *scs.SessionManager
+	verifier       *EmailVerifier
+	providers      *ProviderRegistry
+	audit          AuditLogger
 }
 
-func NewProtectedAuthHandler(store AuthStore, sessionManager *scs.SessionManager) *ProtectedAuthHandler {
-	return &ProtectedAuthHandler{store: store, sessionManager: sessionManager}
+func NewProtectedAuthHandler(store AuthStore, sessionManager *scs.SessionManager, verifier *EmailVerifier, providers *ProviderRegistry, audit AuditLogger) *ProtectedAuthHandler {
+	return &ProtectedAuthHandler{store: store, sessionManager: sessionManager, verifier: verifier, providers: providers, audit: audit}
 }
 
 func (as *ProtectedAuthHandler) Me(ctx context.Context, req *connect.Request[v1.MeRequest]) (*connect.Response[v1.ReadUser], error) {
 
-	userId := as.sessionManager.GetString(ctx, SessionUserKey)
+	userId := UserIDFromContext(ctx)
 
 	if userId == "" {
 		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
@@ -128,16 +434,46 @@ func (as *ProtectedAuthHandler) Me(ctx context.Context, req *connect.Request[v1.
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	return connect.NewResponse(&v1.ReadUser{
+	readUser := &v1.ReadUser{
 		Id:        user.ID,
 		Email:     *user.Email,
 		CreatedAt: timestamppb.New(user.CreatedAt),
-	}), nil
+	}
+
+	if user.EmailVerified != nil {
+		readUser.EmailVerified = timestamppb.New(*user.EmailVerified)
+	}
+
+	return connect.NewResponse(readUser), nil
 }
 
-func (as *ProtectedAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+func (as *ProtectedAuthHandler) ResendVerificationEmail(ctx context.Context, req *connect.Request[v1.ResendVerificationEmailRequest]) (*connect.Response[v1.ResendVerificationEmailResponse], error) {
 
-	Logout(r, as.sessionManager)
+	userId := UserIDFromContext(ctx)
 
-	w.WriteHeader(http.StatusOK)
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	user, err := as.store.GetUserByID(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if user.EmailVerified != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("email already verified"))
+	}
+
+	if user.Email == nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("user has no email"))
+	}
+
+	err = as.verifier.SendVerification(ctx, user.ID, *user.Email)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.ResendVerificationEmailResponse{}), nil
 }
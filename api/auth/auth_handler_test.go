@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"simple-connect/api/internal"
+	"simple-connect/api/validation"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUserStore keeps passwords in plain text, CheckPassword compares them directly
+type memoryUserStore struct {
+	AuthStore
+	mu    sync.Mutex
+	users map[string]*DBUser
+}
+
+func (ms *memoryUserStore) CreateUser(ctx context.Context, email, password string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.users[email]; ok {
+		return "", ErrEmailTaken
+	}
+
+	user := &DBUser{ID: "user-" + email, Email: &email, PasswordHash: sql.NullString{String: password, Valid: true}}
+	ms.users[email] = user
+
+	return user.ID, nil
+}
+
+func (ms *memoryUserStore) GetUserByEmail(ctx context.Context, email string) (*DBUser, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[email]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (ms *memoryUserStore) CheckPassword(ctx context.Context, user *DBUser, password string) (bool, error) {
+	return user.PasswordHash.String == password, nil
+}
+
+func (ms *memoryUserStore) CreateEmailVerificationToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error {
+	return nil
+}
+
+func (ms *memoryUserStore) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func TestAuthHandler(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryUserStore{users: map[string]*DBUser{}}
+	sessionManager := NewMemorySessionManager(false, "")
+	verifier := NewEmailVerifier(store, &outbox{}, "https://app.test/verify-email")
+	validator, err := validation.NewValidator()
+	require.NoError(t, err)
+	handler := NewAuthHandler(store, sessionManager, verifier, nil, nil, nil, nil, DefaultPasswordPolicy, nil, validator)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewAuthServiceHandler(handler, connect.WithInterceptors(NewAuthInterceptor(sessionManager, nil))))
+	mux.HandleFunc("POST /auth/login/{$}", handler.HandleLogin)
+	mux.HandleFunc("POST /auth/signup/{$}", handler.HandleSignup)
+	mux.Handle("POST /auth/logout/{$}", RequireAuthMiddleWare(sessionManager)(http.HandlerFunc(handler.HandleLogout)))
+
+	server := httptest.NewUnstartedServer(sessionManager.LoadAndSave(internal.ClientInfoMiddleware(false)(internal.LoggingMiddleware(*logger)(mux))))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	httpClient := *server.Client()
+	jar, _ := cookiejar.New(nil)
+	httpClient.Jar = jar
+	client := apiv1connect.NewAuthServiceClient(&httpClient, server.URL)
+	ctx := context.Background()
+
+	const password = "correct horse battery 9"
+
+	t.Run("signup", func(t *testing.T) {
+		res, err := client.Signup(ctx, connect.NewRequest(&v1.SignupRequest{Email: "jane@example.com", Password1: password, Password2: password}))
+		require.NoError(t, err)
+		assert.Equal(t, "user-jane@example.com", res.Msg.Id)
+
+		_, err = client.Signup(ctx, connect.NewRequest(&v1.SignupRequest{Email: "jane@example.com", Password1: password, Password2: password}))
+		assert.Equal(t, connect.CodeAlreadyExists, connect.CodeOf(err))
+
+		_, err = client.Signup(ctx, connect.NewRequest(&v1.SignupRequest{Email: "john@example.com", Password1: password, Password2: "other"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+
+	t.Run("login and logout", func(t *testing.T) {
+		_, err := client.Logout(ctx, connect.NewRequest(&v1.LogoutRequest{}))
+		require.NoError(t, err, "signing up signed in")
+
+		_, err = client.Logout(ctx, connect.NewRequest(&v1.LogoutRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		_, err = client.Login(ctx, connect.NewRequest(&v1.LoginRequest{Email: "jane@example.com", Password: "wrong"}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		res, err := client.Login(ctx, connect.NewRequest(&v1.LoginRequest{Email: "jane@example.com", Password: password}))
+		require.NoError(t, err)
+		assert.Equal(t, "user-jane@example.com", res.Msg.Id)
+		assert.False(t, res.Msg.MfaRequired)
+
+		_, err = client.Logout(ctx, connect.NewRequest(&v1.LogoutRequest{}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("rest shims keep their status codes", func(t *testing.T) {
+		post := func(path string, body string) *http.Response {
+			res, err := httpClient.Post(server.URL+path, "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			t.Cleanup(func() { res.Body.Close() })
+
+			return res
+		}
+
+		res := post("/auth/login/", `{"email":"not an email","password":"wrong"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		res = post("/auth/login/", `{"email":"jane@example.com","password":"wrong"}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = post("/auth/login/", `{"email":"jane@example.com","password":"`+password+`"}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var login LoginResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&login))
+		assert.Equal(t, "user-jane@example.com", login.Id)
+
+		res = post("/auth/logout/", "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = post("/auth/logout/", "")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = post("/auth/signup/", `{"email":"weak@example.com","password1":"short","password2":"short"}`)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var violations PasswordViolationsResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&violations))
+		assert.NotEmpty(t, violations.Violations)
+
+		res = post("/auth/signup/", `{"email":"jane@example.com","password1":"`+password+`","password2":"`+password+`"}`)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+}
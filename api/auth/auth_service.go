@@ -4,34 +4,76 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"simple-connect/api/apierrors"
 	"simple-connect/api/data/gen/gopg/public/model"
 	. "simple-connect/api/data/gen/gopg/public/table"
+	"simple-connect/api/secrets"
 	"time"
 
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
 	. "github.com/go-jet/jet/v2/postgres"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// uniqueViolation is the postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+var ErrAccountAlreadyLinked = apierrors.New(connect.CodeAlreadyExists, apierrors.ReasonAccountAlreadyLinked, "provider account is already linked")
+var ErrLastLoginMethod = apierrors.New(connect.CodeFailedPrecondition, apierrors.ReasonLastLoginMethod, "cannot remove the only way to sign in")
+var ErrEmailTaken = apierrors.New(connect.CodeAlreadyExists, apierrors.ReasonEmailTaken, "email is already registered")
+
 type AuthStore interface {
 	GetUserByEmail(ctx context.Context, email string) (*DBUser, error)
 	GetUserByID(ctx context.Context, id string) (*model.Users, error)
 	CreateUser(ctx context.Context, email, password string) (string, error)
-	GetUserAccount(ctx context.Context, email string, provider string) (UserAccount, error)
+	CheckPassword(ctx context.Context, user *DBUser, password string) (bool, error)
+	GetProviderAccount(ctx context.Context, provider string, providerId string) (UserAccount, error)
 	UpdateAccountTokens(ctx context.Context, userId string, provider string, providerId string, data UpdateAccountTokensData) error
 	CreateAccount(ctx context.Context, data CreateAccountData) (string, error)
+	LinkAccount(ctx context.Context, userId string, data CreateAccountData) error
+	GetLinkedAccounts(ctx context.Context, userId string) ([]LinkedAccount, error)
+	UnlinkAccount(ctx context.Context, userId string, provider string) error
+	CreateEmailVerificationToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (string, error)
+	CreatePasswordResetToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error
+	GetPasswordResetEmail(ctx context.Context, tokenHash string) (string, error)
+	CreateMagicLinkToken(ctx context.Context, email string, tokenHash string, expiresAt time.Time, maxOutstanding int) error
+	UseMagicLink(ctx context.Context, tokenHash string) (MagicLinkUser, error)
+	ResetPassword(ctx context.Context, tokenHash string, password string) (string, error)
+	GetTOTP(ctx context.Context, userId string) (*UserTOTP, error)
+	SaveTOTPSecret(ctx context.Context, userId string, secret string) error
+	ConfirmTOTP(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userId string) error
+	ReplaceRecoveryCodes(ctx context.Context, userId string, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error)
+	ListSessions(ctx context.Context, userId string) ([]DBSession, error)
+	DeleteSession(ctx context.Context, userId string, sessionId string) (bool, error)
+	DeleteOtherSessions(ctx context.Context, userId string, keepToken string) (int64, error)
+	CreateAccessToken(ctx context.Context, token AccessToken, tokenHash string) (*AccessToken, error)
+	ListAccessTokens(ctx context.Context, userId string) ([]AccessToken, error)
+	DeleteAccessToken(ctx context.Context, userId string, id string) (bool, error)
+	GetAccessTokenByHash(ctx context.Context, tokenHash string) (*AccessToken, error)
+	TouchAccessToken(ctx context.Context, id string) error
 }
 
 type AuthService struct {
-	pool *pgxpool.Pool
-	db   *sql.DB
+	pool    *pgxpool.Pool
+	db      *sql.DB
+	keyRing *secrets.KeyRing
+	hasher  PasswordHasher
 }
 
-func NewAuthService(pool *pgxpool.Pool) *AuthService {
+// NewAuthService creates the store. keyRing encrypts provider tokens before they are written to accounts,
+// hasher hashes passwords. Tools that neither read tokens nor set passwords may pass nil for either.
+func NewAuthService(pool *pgxpool.Pool, keyRing *secrets.KeyRing, hasher PasswordHasher) *AuthService {
 	db := stdlib.OpenDBFromPool(pool)
-	return &AuthService{pool: pool, db: db}
+	return &AuthService{pool: pool, db: db, keyRing: keyRing, hasher: hasher}
 }
 
 type DBUser struct {
@@ -44,13 +86,16 @@ type DBUser struct {
 
 type DBSession struct {
 	Token     string    `db:"token" json:"token"`
+	ID        string    `db:"id" json:"id"`
 	UserID    string    `db:"user_id" json:"user_id"`
-	Expiry    time.Time `json:"expiry"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	IPAddress string    `db:"ip_address" json:"ip_address"`
+	Expiry    time.Time `db:"expiry" json:"expiry"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 const getUserByEmailQuery = `
-SELECT * FROM users WHERE email = $1 LIMIT 1
+SELECT * FROM users WHERE lower(email) = lower($1) LIMIT 1
 `
 
 func (as *AuthService) GetUserByEmail(ctx context.Context, email string) (*DBUser, error) {
@@ -78,21 +123,54 @@ func (as *AuthService) GetUserByID(ctx context.Context, id string) (*model.Users
 }
 
 func (as *AuthService) CreateUser(ctx context.Context, email, password string) (string, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := as.hasher.Hash(password)
 
 	if err != nil {
 		return "", err
 	}
 
-	row := as.pool.QueryRow(ctx, "INSERT INTO users (email, password_hash) VALUES ($1, $2) RETURNING id", email, string(hashed))
+	row := as.pool.QueryRow(ctx, "INSERT INTO users (email, password_hash) VALUES ($1, $2) RETURNING id", email, hashed)
 
 	var id string
 
 	err = row.Scan(&id)
 
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return "", ErrEmailTaken
+	}
+
 	return id, err
 }
 
+// CheckPassword verifies the user's password and upgrades the stored hash when it was made with another
+// algorithm or parameters than the hasher's. An error alongside a match means only the upgrade failed.
+func (as *AuthService) CheckPassword(ctx context.Context, user *DBUser, password string) (bool, error) {
+	if !user.PasswordHash.Valid || user.PasswordHash.String == "" {
+		return false, nil
+	}
+
+	match, needsRehash, err := as.hasher.Verify(password, user.PasswordHash.String)
+
+	if !match || err != nil || !needsRehash {
+		return match, err
+	}
+
+	hashed, err := as.hasher.Hash(password)
+
+	if err != nil {
+		return true, err
+	}
+
+	// The hash is only replaced if the password didn't change in the meantime
+	_, err = as.pool.Exec(ctx,
+		"UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3",
+		hashed, user.ID, user.PasswordHash.String)
+
+	return true, err
+}
+
 type UserAccount struct {
 	Email      string `db:"email"`
 	Provider   string `db:"provider"`
@@ -100,11 +178,13 @@ type UserAccount struct {
 	UserId     string `db:"user_id"`
 }
 
-func (as *AuthService) GetUserAccount(ctx context.Context, email string, provider string) (UserAccount, error) {
+// GetProviderAccount finds the account for a provider identity. Accounts are keyed by the
+// provider's subject rather than email since a linked provider's email can differ from the user's.
+func (as *AuthService) GetProviderAccount(ctx context.Context, provider string, providerId string) (UserAccount, error) {
 
 	rows, err := as.pool.Query(ctx,
-		"SELECT u.email, a.provider, a.provider_id, a.user_id FROM users u LEFT JOIN accounts a ON u.id = a.user_id WHERE u.email = $1 AND a.provider = $2 LIMIT 1",
-		email, provider)
+		"SELECT COALESCE(u.email, '') AS email, a.provider, a.provider_id, a.user_id FROM accounts a JOIN users u ON u.id = a.user_id WHERE a.provider = $1 AND a.provider_id = $2",
+		provider, providerId)
 
 	if err != nil {
 		return UserAccount{}, err
@@ -121,6 +201,25 @@ type UpdateAccountTokensData struct {
 	AccessTokenExpiry sql.NullTime `db:"access_token_expires_at"`
 }
 
+// accountTokenArgs encrypts provider tokens under a fresh data key, returning the column values to write
+func (as *AuthService) accountTokenArgs(accessToken string, refreshToken string, expiry sql.NullTime) (pgx.NamedArgs, error) {
+	env, ciphertexts, err := as.keyRing.Encrypt(accessToken, refreshToken)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.NamedArgs{
+		"access_token":            ciphertexts[0],
+		"refresh_token":           ciphertexts[1],
+		"access_token_expires_at": expiry,
+		"token_key_id":            env.KeyID,
+		"token_data_key":          env.DataKey,
+	}, nil
+}
+
+// UpdateAccountTokens stores new tokens for the account. An empty refresh token keeps the stored one,
+// providers only send one on the first consent or when they rotate it.
 func (as *AuthService) UpdateAccountTokens(ctx context.Context, userId string, provider string, providerId string, data UpdateAccountTokensData) error {
 	tx, err := as.pool.Begin(ctx)
 
@@ -130,20 +229,48 @@ func (as *AuthService) UpdateAccountTokens(ctx context.Context, userId string, p
 
 	defer tx.Rollback(ctx)
 
+	if data.RefreshToken == "" {
+		// Tokens share a data key, so the old refresh token is decrypted and written again with the new one
+		current, err := as.scanAccountTokens(tx.QueryRow(ctx,
+			"SELECT provider_id, access_token, refresh_token, access_token_expires_at, token_key_id, token_data_key FROM accounts WHERE user_id = $1 AND provider = $2 AND provider_id = $3 FOR UPDATE",
+			userId, provider, providerId))
+
+		if err != nil {
+			return err
+		}
+
+		data.RefreshToken = current.RefreshToken
+	}
+
+	err = as.updateAccountTokens(ctx, tx, userId, provider, providerId, data)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthService) updateAccountTokens(ctx context.Context, tx pgx.Tx, userId string, provider string, providerId string, data UpdateAccountTokensData) error {
+	args, err := as.accountTokenArgs(data.AccessToken, data.RefreshToken, data.AccessTokenExpiry)
+
+	if err != nil {
+		return err
+	}
+
+	args["user_id"] = userId
+	args["provider"] = provider
+	args["provider_id"] = providerId
+
 	res, err := tx.Exec(ctx,
 		`UPDATE accounts SET
 			access_token = @access_token,
 			refresh_token = @refresh_token,
-			access_token_expires_at = @access_token_expires_at
+			access_token_expires_at = @access_token_expires_at,
+			token_key_id = @token_key_id,
+			token_data_key = @token_data_key
 			WHERE user_id = @user_id AND provider = @provider AND provider_id = @provider_id
-			`, pgx.NamedArgs{
-			"access_token":            data.AccessToken,
-			"refresh_token":           data.RefreshToken,
-			"access_token_expires_at": data.AccessTokenExpiry,
-			"user_id":                 userId,
-			"provider":                provider,
-			"provider_id":             providerId,
-		},
+			`, args,
 	)
 
 	if err != nil {
@@ -154,9 +281,7 @@ func (as *AuthService) UpdateAccountTokens(ctx context.Context, userId string, p
 		return errors.New("more than one row affected")
 	}
 
-	err = tx.Commit(ctx)
-
-	return err
+	return nil
 }
 
 type CreateAccountData struct {
@@ -170,6 +295,12 @@ type CreateAccountData struct {
 
 func (as *AuthService) CreateAccount(ctx context.Context, data CreateAccountData) (string, error) {
 
+	args, err := as.accountTokenArgs(data.AccessToken, data.RefreshToken, data.AccessTokenExpiry)
+
+	if err != nil {
+		return "", err
+	}
+
 	tx, err := as.pool.Begin(ctx)
 
 	if err != nil {
@@ -179,24 +310,28 @@ func (as *AuthService) CreateAccount(ctx context.Context, data CreateAccountData
 	defer tx.Rollback(ctx)
 
 	var userId string
-	userRow := tx.QueryRow(ctx, "INSERT INTO users (email) VALUES ($1) RETURNING id", data.Email)
+	// Provider accounts are only created for emails the provider has verified
+	userRow := tx.QueryRow(ctx, "INSERT INTO users (email, email_verified) VALUES ($1, CURRENT_TIMESTAMP) RETURNING id", normalizeEmail(data.Email))
 
 	err = userRow.Scan(&userId)
 
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return "", ErrEmailTaken
+	}
+
 	if err != nil {
 		return "", err
 	}
 
+	args["provider"] = data.Provider
+	args["provider_id"] = data.ProviderId
+	args["user_id"] = userId
+
 	accountRow := tx.QueryRow(ctx,
-		"INSERT into accounts (provider, provider_id, user_id, access_token, refresh_token, access_token_expires_at) VALUES (@provider, @provider_id, @user_id, @access_token, @refresh_token, @access_token_expires_at) RETURNING provider_id",
-		pgx.NamedArgs{
-			"provider":                data.Provider,
-			"provider_id":             data.ProviderId,
-			"user_id":                 userId,
-			"access_token":            data.AccessToken,
-			"refresh_token":           data.RefreshToken,
-			"access_token_expires_at": data.AccessTokenExpiry,
-		},
+		"INSERT into accounts (provider, provider_id, user_id, access_token, refresh_token, access_token_expires_at, token_key_id, token_data_key) VALUES (@provider, @provider_id, @user_id, @access_token, @refresh_token, @access_token_expires_at, @token_key_id, @token_data_key) RETURNING provider_id",
+		args,
 	)
 
 	var accountId string
@@ -211,3 +346,1046 @@ func (as *AuthService) CreateAccount(ctx context.Context, data CreateAccountData
 
 	return userId, err
 }
+
+// LinkAccount attaches a provider identity to an existing user
+func (as *AuthService) LinkAccount(ctx context.Context, userId string, data CreateAccountData) error {
+
+	args, err := as.accountTokenArgs(data.AccessToken, data.RefreshToken, data.AccessTokenExpiry)
+
+	if err != nil {
+		return err
+	}
+
+	args["provider"] = data.Provider
+	args["provider_id"] = data.ProviderId
+	args["user_id"] = userId
+
+	_, err = as.pool.Exec(ctx,
+		"INSERT into accounts (provider, provider_id, user_id, access_token, refresh_token, access_token_expires_at, token_key_id, token_data_key) VALUES (@provider, @provider_id, @user_id, @access_token, @refresh_token, @access_token_expires_at, @token_key_id, @token_data_key)",
+		args,
+	)
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrAccountAlreadyLinked
+	}
+
+	return err
+}
+
+type AccountTokens struct {
+	ProviderId        string
+	AccessToken       string
+	RefreshToken      string
+	AccessTokenExpiry sql.NullTime
+}
+
+// GetAccountTokens returns the user's decrypted tokens for a provider
+func (as *AuthService) GetAccountTokens(ctx context.Context, userId string, provider string) (*AccountTokens, error) {
+	return as.scanAccountTokens(as.pool.QueryRow(ctx,
+		"SELECT provider_id, access_token, refresh_token, access_token_expires_at, token_key_id, token_data_key FROM accounts WHERE user_id = $1 AND provider = $2",
+		userId, provider))
+}
+
+// WithAccountTokens calls update with the user's decrypted tokens for a provider while holding the
+// account row's lock, and stores what it returns unless that is nil. Refreshes on every instance queue
+// on the row, so only the first calls the provider and the rest see its tokens.
+func (as *AuthService) WithAccountTokens(ctx context.Context, userId string, provider string, update func(tokens *AccountTokens) (*UpdateAccountTokensData, error)) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	tokens, err := as.scanAccountTokens(tx.QueryRow(ctx,
+		"SELECT provider_id, access_token, refresh_token, access_token_expires_at, token_key_id, token_data_key FROM accounts WHERE user_id = $1 AND provider = $2 FOR UPDATE",
+		userId, provider))
+
+	if err != nil {
+		return err
+	}
+
+	data, err := update(tokens)
+
+	if err != nil || data == nil {
+		return err
+	}
+
+	if data.RefreshToken == "" {
+		data.RefreshToken = tokens.RefreshToken
+	}
+
+	err = as.updateAccountTokens(ctx, tx, userId, provider, tokens.ProviderId, *data)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// scanAccountTokens reads and decrypts provider_id, access_token, refresh_token, access_token_expires_at,
+// token_key_id and token_data_key
+func (as *AuthService) scanAccountTokens(row pgx.Row) (*AccountTokens, error) {
+
+	var tokens AccountTokens
+	var accessToken, refreshToken, keyId *string
+	var dataKey []byte
+
+	err := row.Scan(&tokens.ProviderId, &accessToken, &refreshToken, &tokens.AccessTokenExpiry, &keyId, &dataKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens.AccessToken = derefString(accessToken)
+	tokens.RefreshToken = derefString(refreshToken)
+
+	// Rows written before encryption hold plaintext until they are re-encrypted
+	if keyId == nil {
+		return &tokens, nil
+	}
+
+	values, err := as.keyRing.Decrypt(secrets.Envelope{KeyID: *keyId, DataKey: dataKey}, tokens.AccessToken, tokens.RefreshToken)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens.AccessToken, tokens.RefreshToken = values[0], values[1]
+
+	return &tokens, nil
+}
+
+// ReencryptAccountTokens brings every account onto the key ring's primary key. Plaintext rows are
+// encrypted and rows under older keys have their data key rewrapped. Returns the number of rows changed.
+func (as *AuthService) ReencryptAccountTokens(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+
+	for {
+		n, err := as.reencryptAccountTokensBatch(ctx, batchSize)
+		total += n
+
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
+}
+
+func (as *AuthService) reencryptAccountTokensBatch(ctx context.Context, batchSize int) (int, error) {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT provider, provider_id, access_token, refresh_token, token_key_id, token_data_key FROM accounts
+			WHERE token_key_id IS NULL OR token_key_id <> $1
+			LIMIT $2 FOR UPDATE SKIP LOCKED`,
+		as.keyRing.PrimaryKeyID(), batchSize)
+
+	if err != nil {
+		return 0, err
+	}
+
+	type accountRow struct {
+		provider, providerId      string
+		accessToken, refreshToken *string
+		keyId                     *string
+		dataKey                   []byte
+	}
+
+	var batch []accountRow
+
+	for rows.Next() {
+		var row accountRow
+		err = rows.Scan(&row.provider, &row.providerId, &row.accessToken, &row.refreshToken, &row.keyId, &row.dataKey)
+
+		if err != nil {
+			return 0, err
+		}
+
+		batch = append(batch, row)
+	}
+
+	if rows.Err() != nil {
+		return 0, rows.Err()
+	}
+
+	for _, row := range batch {
+		accessToken, refreshToken := derefString(row.accessToken), derefString(row.refreshToken)
+		var env secrets.Envelope
+
+		if row.keyId == nil {
+			var ciphertexts []string
+			env, ciphertexts, err = as.keyRing.Encrypt(accessToken, refreshToken)
+
+			if err == nil {
+				accessToken, refreshToken = ciphertexts[0], ciphertexts[1]
+			}
+		} else {
+			env, err = as.keyRing.Rewrap(secrets.Envelope{KeyID: *row.keyId, DataKey: row.dataKey})
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("account %s/%s: %w", row.provider, row.providerId, err)
+		}
+
+		_, err = tx.Exec(ctx,
+			"UPDATE accounts SET access_token = $1, refresh_token = $2, token_key_id = $3, token_data_key = $4 WHERE provider = $5 AND provider_id = $6",
+			accessToken, refreshToken, env.KeyID, env.DataKey, row.provider, row.providerId)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(batch), tx.Commit(ctx)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+type LinkedAccount struct {
+	Provider   string    `db:"provider"`
+	ProviderId string    `db:"provider_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (as *AuthService) GetLinkedAccounts(ctx context.Context, userId string) ([]LinkedAccount, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT provider, provider_id, created_at FROM accounts WHERE user_id = $1 ORDER BY created_at",
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[LinkedAccount])
+}
+
+// UnlinkAccount removes a provider from the user unless it is their only way to sign in
+func (as *AuthService) UnlinkAccount(ctx context.Context, userId string, provider string) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	// Locking the user runs unlinks one at a time, two could otherwise each count the other's provider
+	_, err = tx.Exec(ctx, "SELECT 1 FROM users WHERE id = $1 FOR UPDATE", userId)
+
+	if err != nil {
+		return err
+	}
+
+	var otherMethods bool
+
+	err = tx.QueryRow(ctx,
+		`SELECT
+			EXISTS (SELECT 1 FROM users WHERE id = $1 AND password_hash IS NOT NULL)
+			OR EXISTS (SELECT 1 FROM accounts WHERE user_id = $1 AND provider <> $2)
+			OR EXISTS (SELECT 1 FROM webauthn_credentials WHERE user_id = $1)`,
+		userId, provider).Scan(&otherMethods)
+
+	if err != nil {
+		return err
+	}
+
+	if !otherMethods {
+		return ErrLastLoginMethod
+	}
+
+	res, err := tx.Exec(ctx, "DELETE FROM accounts WHERE user_id = $1 AND provider = $2", userId, provider)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthService) CreateEmailVerificationToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error {
+
+	_, err := as.pool.Exec(ctx,
+		"INSERT INTO email_verification_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, userId, expiresAt)
+
+	return err
+}
+
+// VerifyEmail consumes an unused, unexpired verification token and stamps the owner's email_verified.
+// Any other outstanding tokens for the user are invalidated. Returns the verified user's id.
+func (as *AuthService) VerifyEmail(ctx context.Context, tokenHash string) (string, error) {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var userId string
+
+	row := tx.QueryRow(ctx,
+		"UPDATE email_verification_tokens SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING user_id",
+		tokenHash)
+
+	err = row.Scan(&userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrInvalidToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE users SET email_verified = COALESCE(email_verified, CURRENT_TIMESTAMP) WHERE id = $1", userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE email_verification_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL", userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+
+	return userId, err
+}
+
+func (as *AuthService) CreatePasswordResetToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error {
+
+	_, err := as.pool.Exec(ctx,
+		"INSERT INTO password_reset_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, userId, expiresAt)
+
+	return err
+}
+
+// CreateMagicLinkToken stores a link for the email, which should be normalised, returning
+// ErrTooManyMagicLinks when the email already has maxOutstanding unused ones. Expired links of every
+// email are pruned first, so they don't count.
+func (as *AuthService) CreateMagicLinkToken(ctx context.Context, email string, tokenHash string, expiresAt time.Time, maxOutstanding int) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM magic_link_tokens WHERE expires_at <= CURRENT_TIMESTAMP")
+
+	if err != nil {
+		return err
+	}
+
+	// Concurrent requests for the email take turns, so they can't all count the same links
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('magic_link_tokens:' || $1, 0))", email)
+
+	if err != nil {
+		return err
+	}
+
+	var outstanding int
+
+	err = tx.QueryRow(ctx,
+		"SELECT count(*) FROM magic_link_tokens WHERE email = $1 AND used_at IS NULL",
+		email).Scan(&outstanding)
+
+	if err != nil {
+		return err
+	}
+
+	if outstanding >= maxOutstanding {
+		return ErrTooManyMagicLinks
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO magic_link_tokens (token_hash, email, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, email, expiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseMagicLink consumes the token along with the email's other links and signs in as the email's user,
+// creating it if needed. The email is verified either way. An account whose email wasn't verified
+// before loses its password, sessions and every other credential, as whoever set them never proved
+// they own the address.
+func (as *AuthService) UseMagicLink(ctx context.Context, tokenHash string) (MagicLinkUser, error) {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return MagicLinkUser{}, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var email string
+
+	err = tx.QueryRow(ctx,
+		"UPDATE magic_link_tokens SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING email",
+		tokenHash).Scan(&email)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return MagicLinkUser{}, ErrInvalidToken
+	}
+
+	if err != nil {
+		return MagicLinkUser{}, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE magic_link_tokens SET used_at = CURRENT_TIMESTAMP WHERE email = $1 AND used_at IS NULL", email)
+
+	if err != nil {
+		return MagicLinkUser{}, err
+	}
+
+	user := MagicLinkUser{Email: email}
+	var verified *time.Time
+
+	err = tx.QueryRow(ctx, "SELECT id, email_verified FROM users WHERE lower(email) = lower($1) FOR UPDATE", email).Scan(&user.ID, &verified)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx, "INSERT INTO users (email, email_verified) VALUES ($1, CURRENT_TIMESTAMP) RETURNING id", email).Scan(&user.ID)
+		user.Created = true
+	} else if err == nil && verified == nil {
+		_, err = tx.Exec(ctx, "UPDATE users SET email_verified = CURRENT_TIMESTAMP, password_hash = NULL WHERE id = $1", user.ID)
+
+		if err == nil {
+			err = deleteUserCredentials(ctx, tx, user.ID)
+		}
+
+		if err == nil {
+			err = deleteUserSessions(ctx, tx, user.ID)
+		}
+	}
+
+	if err != nil {
+		return MagicLinkUser{}, err
+	}
+
+	return user, tx.Commit(ctx)
+}
+
+// GetPasswordResetEmail returns the email of the user a usable reset token belongs to. Tokens of users
+// who have since lost their email are not usable, they are pgx.ErrNoRows too.
+func (as *AuthService) GetPasswordResetEmail(ctx context.Context, tokenHash string) (string, error) {
+
+	var email *string
+
+	err := as.pool.QueryRow(ctx,
+		"SELECT users.email FROM password_reset_tokens JOIN users ON users.id = password_reset_tokens.user_id WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP",
+		tokenHash).Scan(&email)
+
+	if err != nil {
+		return "", err
+	}
+
+	if email == nil {
+		return "", pgx.ErrNoRows
+	}
+
+	return *email, nil
+}
+
+// ResetPassword consumes an unused, unexpired reset token and replaces the owner's password.
+// All of the user's other reset tokens and sessions are invalidated. Returns the user's id.
+func (as *AuthService) ResetPassword(ctx context.Context, tokenHash string, password string) (string, error) {
+	hashed, err := as.hasher.Hash(password)
+
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var userId string
+
+	row := tx.QueryRow(ctx,
+		"UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING user_id",
+		tokenHash)
+
+	err = row.Scan(&userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrInvalidToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2", hashed, userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL", userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = deleteUserSessions(ctx, tx, userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+
+	return userId, err
+}
+
+// deleteUserCredentials removes every way to sign in as userId besides a password: passkeys, the second
+// factor, linked providers and access tokens
+func deleteUserCredentials(ctx context.Context, tx pgx.Tx, userId string) error {
+	for _, table := range []string{"webauthn_credentials", "recovery_codes", "user_totp", "accounts", "access_tokens"} {
+		_, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", userId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUserSessions signs userId out everywhere. Sessions last saved before sessionStore filled the
+// user_id column only have the user in their data, so those are decoded and matched too.
+func deleteUserSessions(ctx context.Context, tx pgx.Tx, userId string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM sessions WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, "SELECT token, data FROM sessions WHERE user_id IS NULL AND expiry > CURRENT_TIMESTAMP")
+
+	if err != nil {
+		return err
+	}
+
+	var tokens []string
+
+	for rows.Next() {
+		var token string
+		var data []byte
+
+		err = rows.Scan(&token, &data)
+
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		_, values, err := scs.GobCodec{}.Decode(data)
+
+		if err == nil && values[SessionUserKey] == userId {
+			tokens = append(tokens, token)
+		}
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM sessions WHERE token = ANY($1)", tokens)
+
+	return err
+}
+
+type UserTOTP struct {
+	UserID       string     `db:"user_id"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep *int64     `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+func (as *AuthService) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+
+	rows, err := as.pool.Query(ctx, "SELECT * FROM user_totp WHERE user_id = $1 LIMIT 1", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[UserTOTP])
+}
+
+// SaveTOTPSecret starts (or restarts) an enrollment. A confirmed secret is never overwritten.
+func (as *AuthService) SaveTOTPSecret(ctx context.Context, userId string, secret string) error {
+
+	_, err := as.pool.Exec(ctx,
+		`INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = CURRENT_TIMESTAMP
+			WHERE user_totp.confirmed_at IS NULL`,
+		userId, secret)
+
+	return err
+}
+
+func (as *AuthService) ConfirmTOTP(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx,
+		"UPDATE user_totp SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2 WHERE user_id = $1 AND confirmed_at IS NULL",
+		userId, step)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() != 1 {
+		return errors.New("no pending totp enrollment")
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userId, recoveryCodeHashes)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep records step as the last accepted one, returning false if it (or a later step) was already used
+func (as *AuthService) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+
+	res, err := as.pool.Exec(ctx,
+		"UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND (last_used_step IS NULL OR last_used_step < $2)",
+		userId, step)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+func (as *AuthService) DeleteTOTP(ctx context.Context, userId string) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM user_totp WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthService) ReplaceRecoveryCodes(ctx context.Context, userId string, recoveryCodeHashes []string) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = replaceRecoveryCodes(ctx, tx, userId, recoveryCodeHashes)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userId string, recoveryCodeHashes []string) error {
+
+	_, err := tx.Exec(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec(ctx, "INSERT INTO recovery_codes (code_hash, user_id) VALUES ($1, $2)", codeHash, userId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, returning false if there was none to use
+func (as *AuthService) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+
+	res, err := as.pool.Exec(ctx,
+		"UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE code_hash = $1 AND user_id = $2 AND used_at IS NULL",
+		codeHash, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+func (as *AuthService) GetPasskeys(ctx context.Context, userId string) ([]Passkey, error) {
+
+	rows, err := as.pool.Query(ctx, "SELECT * FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Passkey])
+}
+
+func (as *AuthService) CreatePasskey(ctx context.Context, passkey Passkey) error {
+
+	_, err := as.pool.Exec(ctx,
+		`INSERT INTO webauthn_credentials (id, user_id, name, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible, backup_state)
+			VALUES (@id, @user_id, @name, @public_key, @attestation_type, @transports, @aaguid, @sign_count, @backup_eligible, @backup_state)`,
+		pgx.NamedArgs{
+			"id":               passkey.ID,
+			"user_id":          passkey.UserID,
+			"name":             passkey.Name,
+			"public_key":       passkey.PublicKey,
+			"attestation_type": passkey.AttestationType,
+			"transports":       passkey.Transports,
+			"aaguid":           passkey.AAGUID,
+			"sign_count":       passkey.SignCount,
+			"backup_eligible":  passkey.BackupEligible,
+			"backup_state":     passkey.BackupState,
+		},
+	)
+
+	return err
+}
+
+func (as *AuthService) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, cloneWarning bool, backupState bool) error {
+
+	_, err := as.pool.Exec(ctx,
+		`UPDATE webauthn_credentials SET
+			sign_count = @sign_count,
+			clone_warning = clone_warning OR @clone_warning,
+			backup_state = @backup_state,
+			last_used_at = CURRENT_TIMESTAMP
+			WHERE id = @id`,
+		pgx.NamedArgs{
+			"id":            id,
+			"sign_count":    int64(signCount),
+			"clone_warning": cloneWarning,
+			"backup_state":  backupState,
+		},
+	)
+
+	return err
+}
+
+func (as *AuthService) DeletePasskey(ctx context.Context, userId string, id []byte) (bool, error) {
+
+	res, err := as.pool.Exec(ctx, "DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2", id, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+func (as *AuthService) ListSessions(ctx context.Context, userId string) ([]DBSession, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT token, id, user_id, user_agent, ip_address, expiry, created_at FROM sessions WHERE user_id = $1 AND current_timestamp < expiry ORDER BY created_at DESC",
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[DBSession])
+}
+
+// DeleteSession removes one of the user's sessions, signing that device out
+func (as *AuthService) DeleteSession(ctx context.Context, userId string, sessionId string) (bool, error) {
+
+	res, err := as.pool.Exec(ctx, "DELETE FROM sessions WHERE id = $1 AND user_id = $2", sessionId, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// DeleteOtherSessions signs the user out everywhere except the session holding keepToken
+func (as *AuthService) DeleteOtherSessions(ctx context.Context, userId string, keepToken string) (int64, error) {
+
+	res, err := as.pool.Exec(ctx, "DELETE FROM sessions WHERE user_id = $1 AND token <> $2", userId, keepToken)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+const accessTokenColumns = "id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at"
+
+func (as *AuthService) CreateAccessToken(ctx context.Context, token AccessToken, tokenHash string) (*AccessToken, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"INSERT INTO access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at) VALUES (@user_id, @name, @token_hash, @token_prefix, @scopes, @expires_at) RETURNING "+accessTokenColumns,
+		pgx.NamedArgs{
+			"user_id":      token.UserID,
+			"name":         token.Name,
+			"token_hash":   tokenHash,
+			"token_prefix": token.TokenPrefix,
+			"scopes":       token.Scopes,
+			"expires_at":   token.ExpiresAt,
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[AccessToken])
+}
+
+func (as *AuthService) ListAccessTokens(ctx context.Context, userId string) ([]AccessToken, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT "+accessTokenColumns+" FROM access_tokens WHERE user_id = $1 ORDER BY created_at DESC",
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[AccessToken])
+}
+
+func (as *AuthService) DeleteAccessToken(ctx context.Context, userId string, id string) (bool, error) {
+
+	res, err := as.pool.Exec(ctx, "DELETE FROM access_tokens WHERE id = $1 AND user_id = $2", id, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+func (as *AuthService) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*AccessToken, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT "+accessTokenColumns+" FROM access_tokens WHERE token_hash = $1",
+		tokenHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[AccessToken])
+}
+
+// TouchAccessToken records that the token was used. Writes are limited to once a minute per token.
+func (as *AuthService) TouchAccessToken(ctx context.Context, id string) error {
+
+	_, err := as.pool.Exec(ctx,
+		"UPDATE access_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')",
+		id)
+
+	return err
+}
+
+func (as *AuthService) GetLoginThrottle(ctx context.Context, key string) (*LoginThrottleState, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT failures, last_failed_at, locked_until FROM login_throttles WHERE key = $1",
+		key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[LoginThrottleState])
+}
+
+func (as *AuthService) ClaimLoginAttempt(ctx context.Context, key string, ip string, at time.Time, resetBefore time.Time, claim func(state *LoginThrottleState, ipFailures int) error) error {
+	tx, err := as.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	// Keys without a row have nothing to lock, so attempts serialise on transaction level advisory locks
+	// instead, always taken key first
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('login_throttles:' || $1, 0))", key)
+
+	if err != nil {
+		return err
+	}
+
+	state := &LoginThrottleState{}
+
+	rows, err := tx.Query(ctx,
+		"SELECT failures, last_failed_at, locked_until FROM login_throttles WHERE key = $1",
+		key)
+
+	if err != nil {
+		return err
+	}
+
+	stored, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[LoginThrottleState])
+
+	if err == nil {
+		state = stored
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	ipFailures := 0
+
+	if ip != "" {
+		_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('login_ip_failures:' || $1, 0))", ip)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx,
+			"SELECT count(*) FROM login_ip_failures WHERE ip_address = $1 AND failed_at >= $2",
+			ip, resetBefore).Scan(&ipFailures)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = claim(state, ipFailures)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO login_throttles (key, failures, last_failed_at, locked_until) VALUES (@key, @failures, @last_failed_at, @locked_until)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = EXCLUDED.failures,
+			last_failed_at = EXCLUDED.last_failed_at,
+			locked_until = EXCLUDED.locked_until`,
+		pgx.NamedArgs{
+			"key":            key,
+			"failures":       state.Failures,
+			"last_failed_at": state.LastFailedAt,
+			"locked_until":   state.LockedUntil,
+		})
+
+	if err != nil {
+		return err
+	}
+
+	if ip != "" {
+		_, err = tx.Exec(ctx, "INSERT INTO login_ip_failures (ip_address, failed_at) VALUES ($1, $2)", ip, at)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (as *AuthService) ClearLoginFailures(ctx context.Context, key string) (bool, error) {
+
+	res, err := as.pool.Exec(ctx, "DELETE FROM login_throttles WHERE key = $1", key)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+func (as *AuthService) DeleteLoginIPFailure(ctx context.Context, ip string, at time.Time) error {
+
+	_, err := as.pool.Exec(ctx,
+		"DELETE FROM login_ip_failures WHERE id = (SELECT id FROM login_ip_failures WHERE ip_address = $1 AND failed_at = $2 LIMIT 1)",
+		ip, at)
+
+	return err
+}
+
+func (as *AuthService) PruneLoginThrottles(ctx context.Context, at time.Time, resetBefore time.Time) error {
+
+	_, err := as.pool.Exec(ctx,
+		"DELETE FROM login_throttles WHERE last_failed_at < $2 AND (locked_until IS NULL OR locked_until <= $1)",
+		at, resetBefore)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = as.pool.Exec(ctx, "DELETE FROM login_ip_failures WHERE failed_at < $1", resetBefore)
+
+	return err
+}
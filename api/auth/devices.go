@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	v1 "simple-connect/gen/proto/api/v1"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (as *ProtectedAuthHandler) ListSessions(ctx context.Context, req *connect.Request[v1.ListSessionsRequest]) (*connect.Response[v1.ListSessionsResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	sessions, err := as.store.ListSessions(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	currentToken := as.sessionManager.Token(ctx)
+	res := &v1.ListSessionsResponse{}
+
+	for _, s := range sessions {
+		res.Sessions = append(res.Sessions, &v1.Session{
+			Id:        s.ID,
+			UserAgent: s.UserAgent,
+			IpAddress: s.IPAddress,
+			CreatedAt: timestamppb.New(s.CreatedAt),
+			ExpiresAt: timestamppb.New(s.Expiry),
+			Current:   s.Token == currentToken,
+		})
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (as *ProtectedAuthHandler) RevokeSession(ctx context.Context, req *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	// Revoking the current session is a logout, destroy it so LoadAndSave doesn't write it back
+	if req.Msg.Id == HashToken(as.sessionManager.Token(ctx)) {
+		err := as.sessionManager.Destroy(ctx)
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditLogout})
+
+		return connect.NewResponse(&v1.RevokeSessionResponse{}), nil
+	}
+
+	ok, err := as.store.DeleteSession(ctx, userId, req.Msg.Id)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("session not found"))
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditSessionRevoked, TargetID: req.Msg.Id})
+
+	return connect.NewResponse(&v1.RevokeSessionResponse{}), nil
+}
+
+func (as *ProtectedAuthHandler) RevokeOtherSessions(ctx context.Context, req *connect.Request[v1.RevokeOtherSessionsRequest]) (*connect.Response[v1.RevokeOtherSessionsResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	revoked, err := as.store.DeleteOtherSessions(ctx, userId, as.sessionManager.Token(ctx))
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{
+		ActorID:  userId,
+		Action:   AuditOtherSessionsRevoked,
+		Metadata: map[string]any{"revoked": revoked},
+	})
+
+	return connect.NewResponse(&v1.RevokeOtherSessionsResponse{
+		Revoked: revoked,
+	}), nil
+}
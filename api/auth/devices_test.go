@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"simple-connect/api/internal"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySessionsStore lists sessions straight from the session manager's memstore,
+// reading the same session values sessionStore copies into the sessions table
+type memorySessionsStore struct {
+	AuthStore
+	sessions *memstore.MemStore
+}
+
+func (ms *memorySessionsStore) ListSessions(ctx context.Context, userId string) ([]DBSession, error) {
+	all, err := ms.sessions.All()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []DBSession
+
+	for token, b := range all {
+		expiry, values, err := scs.GobCodec{}.Decode(b)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if values[SessionUserKey] != userId {
+			continue
+		}
+
+		sessions = append(sessions, DBSession{
+			Token:     token,
+			ID:        HashToken(token),
+			UserID:    userId,
+			UserAgent: values[SessionUserAgentKey].(string),
+			IPAddress: values[SessionIPKey].(string),
+			Expiry:    expiry,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return sessions, nil
+}
+
+func (ms *memorySessionsStore) DeleteSession(ctx context.Context, userId string, sessionId string) (bool, error) {
+	sessions, err := ms.ListSessions(ctx, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range sessions {
+		if s.ID == sessionId {
+			return true, ms.sessions.Delete(s.Token)
+		}
+	}
+
+	return false, nil
+}
+
+func (ms *memorySessionsStore) DeleteOtherSessions(ctx context.Context, userId string, keepToken string) (int64, error) {
+	sessions, err := ms.ListSessions(ctx, userId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+
+	for _, s := range sessions {
+		if s.Token != keepToken {
+			n++
+			ms.sessions.Delete(s.Token)
+		}
+	}
+
+	return n, nil
+}
+
+func TestSessionDevices(t *testing.T) {
+
+	t.Parallel()
+
+	sessionManager := NewMemorySessionManager(false, "")
+	sessions := memstore.New()
+	sessionManager.Store = sessions
+
+	handler := NewProtectedAuthHandler(&memorySessionsStore{sessions: sessions}, sessionManager, nil, NewProviderRegistry(), nil)
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	}, internal.ClientInfoMiddleware(false))
+	server.Mux.Handle(apiv1connect.NewProtectedAuthServiceHandler(handler, connect.WithInterceptors(NewAuthInterceptor(sessionManager, nil))))
+
+	login := func(userAgent string) apiv1connect.ProtectedAuthServiceClient {
+		client := server.NewClient()
+		server.SignIn(client, "device-user", http.Header{"User-Agent": {userAgent}})
+
+		return apiv1connect.NewProtectedAuthServiceClient(client, server.URL)
+	}
+
+	laptop := login("laptop")
+	phone := login("phone")
+	tablet := login("tablet")
+
+	list, err := laptop.ListSessions(context.Background(), connect.NewRequest(&v1.ListSessionsRequest{}))
+	require.NoError(t, err)
+	require.Len(t, list.Msg.Sessions, 3)
+
+	var phoneId string
+
+	for _, s := range list.Msg.Sessions {
+		assert.Equal(t, "127.0.0.1", s.IpAddress)
+		assert.Equal(t, s.UserAgent == "laptop", s.Current)
+
+		if s.UserAgent == "phone" {
+			phoneId = s.Id
+		}
+	}
+
+	t.Run("revoking a session signs that device out", func(t *testing.T) {
+		_, err := laptop.RevokeSession(context.Background(), connect.NewRequest(&v1.RevokeSessionRequest{Id: phoneId}))
+		require.NoError(t, err)
+
+		_, err = phone.ListSessions(context.Background(), connect.NewRequest(&v1.ListSessionsRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		_, err = laptop.RevokeSession(context.Background(), connect.NewRequest(&v1.RevokeSessionRequest{Id: phoneId}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+
+	t.Run("revoking other sessions keeps the current one", func(t *testing.T) {
+		res, err := laptop.RevokeOtherSessions(context.Background(), connect.NewRequest(&v1.RevokeOtherSessionsRequest{}))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), res.Msg.Revoked)
+
+		_, err = tablet.ListSessions(context.Background(), connect.NewRequest(&v1.ListSessionsRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		list, err := laptop.ListSessions(context.Background(), connect.NewRequest(&v1.ListSessionsRequest{}))
+		require.NoError(t, err)
+		assert.Len(t, list.Msg.Sessions, 1)
+	})
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// SessionInvitationKey holds the invitation token a user started the OAuth flow with, accepted once
+// the callback signs them in
+const SessionInvitationKey = "invitation_token"
+
+// InvitationAcceptor adds a user to the organization they were invited to, returning its id.
+// It lives outside this package so signing up doesn't depend on organizations.
+type InvitationAcceptor interface {
+	Accept(ctx context.Context, userId string, token string) (string, error)
+}
+
+// acceptInvitation accepts the token for the logged in user, if there is one, and makes the
+// organization the session's active organization
+func acceptInvitation(ctx context.Context, invitations InvitationAcceptor, sessionManager *scs.SessionManager, userId string, token string) (string, error) {
+	if invitations == nil || token == "" {
+		return "", nil
+	}
+
+	orgId, err := invitations.Accept(ctx, userId, token)
+
+	if err != nil {
+		return "", err
+	}
+
+	sessionManager.Put(ctx, SessionOrganizationKey, orgId)
+
+	return orgId, nil
+}
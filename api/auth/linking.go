@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	v1 "simple-connect/gen/proto/api/v1"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SessionLinkProviderKey holds the provider a logged in user asked to link, so the callback
+// attaches the identity to them instead of signing in or creating a user
+const SessionLinkProviderKey = "link_provider"
+
+// pendingLink returns the logged in user when they started linking this provider, consuming the request
+func pendingLink(ctx context.Context, sessionManager *scs.SessionManager, provider string) string {
+	userId := sessionManager.GetString(ctx, SessionUserKey)
+
+	if userId == "" || sessionManager.GetString(ctx, SessionLinkProviderKey) != provider {
+		return ""
+	}
+
+	sessionManager.Remove(ctx, SessionLinkProviderKey)
+
+	return userId
+}
+
+func (as *ProtectedAuthHandler) ListLinkedAccounts(ctx context.Context, req *connect.Request[v1.ListLinkedAccountsRequest]) (*connect.Response[v1.ListLinkedAccountsResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	accounts, err := as.store.GetLinkedAccounts(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListLinkedAccountsResponse{}
+
+	for _, a := range accounts {
+		res.Accounts = append(res.Accounts, &v1.LinkedAccount{
+			Provider:   a.Provider,
+			ProviderId: a.ProviderId,
+			CreatedAt:  timestamppb.New(a.CreatedAt),
+		})
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (as *ProtectedAuthHandler) LinkProvider(ctx context.Context, req *connect.Request[v1.LinkProviderRequest]) (*connect.Response[v1.LinkProviderResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	provider, err := as.providers.Get(req.Msg.Provider)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	as.sessionManager.Put(ctx, SessionLinkProviderKey, provider.Name)
+
+	return connect.NewResponse(&v1.LinkProviderResponse{
+		AuthorizationUrl: "/auth/" + provider.Name + "/",
+	}), nil
+}
+
+func (as *ProtectedAuthHandler) UnlinkProvider(ctx context.Context, req *connect.Request[v1.UnlinkProviderRequest]) (*connect.Response[v1.UnlinkProviderResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	err := as.store.UnlinkAccount(ctx, userId, req.Msg.Provider)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("provider not linked"))
+	}
+
+	if errors.Is(err, ErrLastLoginMethod) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{
+		ActorID:  userId,
+		Action:   AuditProviderUnlinked,
+		Metadata: map[string]any{"provider": req.Msg.Provider},
+	})
+
+	return connect.NewResponse(&v1.UnlinkProviderResponse{}), nil
+}
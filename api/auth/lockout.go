@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	v1 "simple-connect/gen/proto/api/v1"
+
+	"connectrpc.com/connect"
+	"github.com/go-jet/jet/v2/qrm"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AccountLockoutHandler serves AccountLockoutService, its procedures require the accounts.unlock permission
+type AccountLockoutHandler struct {
+	store    AuthStore
+	throttle *LoginThrottle
+	audit    AuditLogger
+}
+
+func NewAccountLockoutHandler(store AuthStore, throttle *LoginThrottle, audit AuditLogger) *AccountLockoutHandler {
+	return &AccountLockoutHandler{store: store, throttle: throttle, audit: audit}
+}
+
+func (ah *AccountLockoutHandler) userEmail(ctx context.Context, userId string) (string, error) {
+	user, err := ah.store.GetUserByID(ctx, userId)
+
+	if errors.Is(err, qrm.ErrNoRows) {
+		return "", connect.NewError(connect.CodeNotFound, errors.New("user not found"))
+	}
+
+	if err != nil {
+		return "", connect.NewError(connect.CodeInternal, err)
+	}
+
+	if user.Email == nil {
+		return "", connect.NewError(connect.CodeFailedPrecondition, errors.New("user has no email"))
+	}
+
+	return *user.Email, nil
+}
+
+func (ah *AccountLockoutHandler) GetAccountLockout(ctx context.Context, req *connect.Request[v1.GetAccountLockoutRequest]) (*connect.Response[v1.GetAccountLockoutResponse], error) {
+
+	email, err := ah.userEmail(ctx, req.Msg.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := ah.throttle.State(ctx, email)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.GetAccountLockoutResponse{}
+
+	if state != nil {
+		res.Failures = int32(state.Failures)
+
+		if state.LockedUntil != nil && ah.throttle.now().Before(*state.LockedUntil) {
+			res.LockedUntil = timestamppb.New(*state.LockedUntil)
+		}
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (ah *AccountLockoutHandler) UnlockAccount(ctx context.Context, req *connect.Request[v1.UnlockAccountRequest]) (*connect.Response[v1.UnlockAccountResponse], error) {
+
+	email, err := ah.userEmail(ctx, req.Msg.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := ah.throttle.Unlock(ctx, email)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if unlocked {
+		recordAudit(ctx, ah.audit, AuditEvent{ActorID: UserIDFromContext(ctx), Action: AuditAccountUnlocked, TargetID: req.Msg.UserId})
+	}
+
+	return connect.NewResponse(&v1.UnlockAccountResponse{}), nil
+}
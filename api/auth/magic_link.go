@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/httputils"
+	"simple-connect/api/internal"
+	"simple-connect/api/mail"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+const MagicLinkLifetime = 15 * time.Minute
+
+// MaxOutstandingMagicLinks is how many unused, unexpired links an email may have
+const MaxOutstandingMagicLinks = 3
+
+var ErrInvalidEmail = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonInvalidEmail, "invalid email address")
+var ErrTooManyMagicLinks = apierrors.New(connect.CodeResourceExhausted, apierrors.ReasonTooManyAttempts, "too many sign in links requested, use one already sent or try again later")
+
+// MagicLinkUser is who a used magic link signs in
+type MagicLinkUser struct {
+	ID    string
+	Email string
+	// Created is set when the link made a new account
+	Created bool
+}
+
+type MagicLinkRequest struct {
+	Token string `json:"token"`
+}
+
+type MagicLinker struct {
+	store   AuthStore
+	mailer  mail.Mailer
+	linkURL string
+}
+
+// NewMagicLinker creates a MagicLinker that mails links of the form linkURL?token=...
+func NewMagicLinker(store AuthStore, mailer mail.Mailer, linkURL string) *MagicLinker {
+	return &MagicLinker{store: store, mailer: mailer, linkURL: linkURL}
+}
+
+// Send mails a single-use sign in link to the email, whether or not it belongs to a user yet. Emails
+// with MaxOutstandingMagicLinks links already are ErrTooManyMagicLinks.
+func (ml *MagicLinker) Send(ctx context.Context, email string) error {
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = ml.store.CreateMagicLinkToken(ctx, email, HashToken(token), time.Now().Add(MagicLinkLifetime), MaxOutstandingMagicLinks)
+
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", ml.linkURL, url.QueryEscape(token))
+
+	return ml.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Your sign in link",
+		Body:    fmt.Sprintf("Open the link below within 15 minutes to sign in. It can only be used once.\n\n%s\n\nIf you didn't ask to sign in, you can ignore this email.\n", link),
+	})
+}
+
+// Use consumes the token and returns the user it signs in
+func (ml *MagicLinker) Use(ctx context.Context, token string) (MagicLinkUser, error) {
+	if token == "" {
+		return MagicLinkUser{}, ErrInvalidToken
+	}
+
+	return ml.store.UseMagicLink(ctx, HashToken(token))
+}
+
+func (as *AuthHandler) RequestMagicLink(ctx context.Context, req *connect.Request[v1.RequestMagicLinkRequest]) (*connect.Response[v1.RequestMagicLinkResponse], error) {
+
+	email := normalizeEmail(req.Msg.Email)
+
+	if _, domain, ok := strings.Cut(email, "@"); !ok || domain == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, ErrInvalidEmail)
+	}
+
+	err := as.magicLinks.Send(ctx, email)
+
+	if errors.Is(err, ErrTooManyMagicLinks) {
+		return nil, connect.NewError(connect.CodeResourceExhausted, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{Action: AuditMagicLinkRequested, Metadata: map[string]any{"email": email}})
+
+	return connect.NewResponse(&v1.RequestMagicLinkResponse{}), nil
+}
+
+// VerifyMagicLink signs in with the token from a magic link, answering like Login
+func (as *AuthHandler) VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	logger := internal.RequestLogger(r)
+	magicReq := &MagicLinkRequest{}
+
+	err := httputils.ReadJSON(r, magicReq)
+
+	if err != nil {
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeInvalidArgument, err))
+		return
+	}
+
+	user, err := as.magicLinks.Use(r.Context(), magicReq.Token)
+
+	if errors.Is(err, ErrInvalidToken) {
+		recordAudit(r.Context(), as.audit, AuditEvent{Action: AuditLoginFailed, Metadata: map[string]any{"reason": "invalid_magic_link"}})
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeUnauthenticated, err))
+		return
+	}
+
+	if err != nil {
+		logger.Error("error using magic link", slog.String("Error", err.Error()))
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
+	if user.Created {
+		recordAudit(r.Context(), as.audit, AuditEvent{ActorID: user.ID, Action: AuditSignup, Metadata: map[string]any{"method": "magic_link"}})
+	}
+
+	mfaEnabled, err := hasMFA(r.Context(), as.store, user.ID)
+
+	if err != nil {
+		logger.Error("error checking mfa", slog.String("Error", err.Error()))
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
+	if mfaEnabled {
+		err = BeginMFA(r, as.sessionManager, user.ID)
+
+		if err != nil {
+			apierrors.WriteJSON(w, r, err)
+			return
+		}
+
+		recordAudit(r.Context(), as.audit, AuditEvent{Action: AuditMFAChallenged, TargetID: user.ID})
+		httputils.WriteJSON(w, r, LoginResponse{MFARequired: true})
+		return
+	}
+
+	err = Login(r, as.sessionManager, SessionData{UserID: user.ID})
+
+	if err != nil {
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
+	recordAudit(r.Context(), as.audit, AuditEvent{
+		ActorID:  user.ID,
+		Action:   AuditLoginSucceeded,
+		Metadata: map[string]any{"method": "magic_link"},
+	})
+
+	httputils.WriteJSON(w, r, LoginResponse{Id: user.ID})
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simple-connect/api/internal"
+	"simple-connect/api/mail"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryMagicLinkStore struct {
+	AuthStore
+	mu     sync.Mutex
+	tokens map[string]string
+	users  map[string]string
+}
+
+func (ms *memoryMagicLinkStore) CreateMagicLinkToken(ctx context.Context, email string, tokenHash string, expiresAt time.Time, maxOutstanding int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	outstanding := 0
+
+	for _, tokenEmail := range ms.tokens {
+		if tokenEmail == email {
+			outstanding++
+		}
+	}
+
+	if outstanding >= maxOutstanding {
+		return ErrTooManyMagicLinks
+	}
+
+	ms.tokens[tokenHash] = email
+	return nil
+}
+
+func (ms *memoryMagicLinkStore) UseMagicLink(ctx context.Context, tokenHash string) (MagicLinkUser, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	email, ok := ms.tokens[tokenHash]
+
+	if !ok {
+		return MagicLinkUser{}, ErrInvalidToken
+	}
+
+	delete(ms.tokens, tokenHash)
+
+	user := MagicLinkUser{ID: ms.users[email], Email: email}
+
+	if user.ID == "" {
+		user.ID = "user-" + email
+		user.Created = true
+		ms.users[email] = user.ID
+	}
+
+	return user, nil
+}
+
+func (ms *memoryMagicLinkStore) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+	return nil, pgx.ErrNoRows
+}
+
+// outbox records mail instead of sending it
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func TestMagicLinks(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryMagicLinkStore{tokens: map[string]string{}, users: map[string]string{}}
+	sent := &outbox{}
+	sessionManager := NewMemorySessionManager(false, "")
+	handler := NewAuthHandler(store, sessionManager, nil, nil, nil, nil, nil, DefaultPasswordPolicy, NewMagicLinker(store, sent, "https://app.test/magic-link"), nil)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewAuthServiceHandler(handler))
+	mux.HandleFunc("POST /auth/magic-link/{$}", handler.VerifyMagicLink)
+
+	server := httptest.NewServer(sessionManager.LoadAndSave(internal.ClientInfoMiddleware(false)(internal.LoggingMiddleware(*logger)(mux))))
+	defer server.Close()
+
+	client := apiv1connect.NewAuthServiceClient(http.DefaultClient, server.URL)
+
+	requestToken := func(email string) string {
+		_, err := client.RequestMagicLink(context.Background(), connect.NewRequest(&v1.RequestMagicLinkRequest{Email: email}))
+		require.NoError(t, err)
+
+		sent.mu.Lock()
+		defer sent.mu.Unlock()
+
+		msg := sent.messages[len(sent.messages)-1]
+		assert.Equal(t, normalizeEmail(email), msg.To)
+
+		start := strings.Index(msg.Body, "https://app.test/magic-link?token=")
+		require.GreaterOrEqual(t, start, 0)
+		link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+		require.NoError(t, err)
+
+		return link.Query().Get("token")
+	}
+
+	verify := func(token string) *http.Response {
+		res, err := http.Post(server.URL+"/auth/magic-link/", "application/json", strings.NewReader(`{"token":"`+token+`"}`))
+		require.NoError(t, err)
+		res.Body.Close()
+
+		return res
+	}
+
+	t.Run("invalid emails are rejected", func(t *testing.T) {
+		_, err := client.RequestMagicLink(context.Background(), connect.NewRequest(&v1.RequestMagicLinkRequest{Email: "nobody"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+
+	t.Run("links sign in once", func(t *testing.T) {
+		token := requestToken("new@example.com")
+
+		res := verify(token)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotEmpty(t, res.Cookies(), "a session is started")
+		assert.Equal(t, "user-new@example.com", store.users["new@example.com"])
+
+		res = verify(token)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("emails are normalised", func(t *testing.T) {
+		store.mu.Lock()
+		store.users["jane@example.com"] = "jane"
+		store.mu.Unlock()
+
+		res := verify(requestToken(" Jane@Example.com"))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotContains(t, store.users, "Jane@Example.com", "no second account is made")
+	})
+
+	t.Run("outstanding links are capped", func(t *testing.T) {
+		for range MaxOutstandingMagicLinks {
+			requestToken("capped@example.com")
+		}
+
+		_, err := client.RequestMagicLink(context.Background(), connect.NewRequest(&v1.RequestMagicLinkRequest{Email: "Capped@example.com"}))
+		assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	})
+
+	t.Run("unknown tokens are refused", func(t *testing.T) {
+		res := verify("not-a-token")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/internal"
+	v1 "simple-connect/gen/proto/api/v1"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+)
+
+const TOTPIssuer = "simple-connect"
+
+var ErrInvalidMFACode = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonInvalidMFACode, "invalid code")
+
+// hasMFA reports whether the user has a confirmed second factor
+func hasMFA(ctx context.Context, store AuthStore, userId string) (bool, error) {
+	totp, err := store.GetTOTP(ctx, userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return totp.ConfirmedAt != nil, nil
+}
+
+// verifyTOTPCode checks code against the user's confirmed secret and consumes its time step
+func verifyTOTPCode(ctx context.Context, store AuthStore, userId string, code string) (bool, error) {
+	totp, err := store.GetTOTP(ctx, userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if totp.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	step, ok := validateTOTP(totp.Secret, code, time.Now())
+
+	if !ok {
+		return false, nil
+	}
+
+	return store.UseTOTPStep(ctx, userId, step)
+}
+
+// verifySecondFactor accepts either a TOTP code or one of the user's unused recovery codes
+func verifySecondFactor(ctx context.Context, store AuthStore, userId string, code string, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return store.UseRecoveryCode(ctx, userId, hashRecoveryCode(recoveryCode))
+	}
+
+	return verifyTOTPCode(ctx, store, userId, code)
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := generateRecoveryCodes()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
+
+func (as *AuthHandler) VerifyMFA(ctx context.Context, req *connect.Request[v1.VerifyMFARequest]) (*connect.Response[v1.VerifyMFAResponse], error) {
+
+	userId := PendingMFAUser(ctx, as.sessionManager)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("no pending login"))
+	}
+
+	attempt, err := as.checkMFAThrottle(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := verifySecondFactor(ctx, as.store, userId, req.Msg.Code, req.Msg.RecoveryCode)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		recordMFAFailure(ctx, as.sessionManager)
+		recordAudit(ctx, as.audit, AuditEvent{
+			Action:   AuditMFAFailed,
+			TargetID: userId,
+			Metadata: map[string]any{"reason": "invalid_code"},
+		})
+
+		if attempt != nil && attempt.Locked {
+			recordAudit(ctx, as.audit, AuditEvent{Action: AuditAccountLocked, TargetID: userId})
+		}
+
+		return nil, connect.NewError(connect.CodeUnauthenticated, ErrInvalidMFACode)
+	}
+
+	if attempt != nil {
+		err = as.throttle.Success(ctx, attempt)
+
+		if err != nil {
+			internal.RpcLogger(ctx).Error("error clearing login failures", slog.String("Error", err.Error()))
+		}
+	}
+
+	err = LoginContext(ctx, as.sessionManager, SessionData{UserID: userId})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	method := "totp"
+
+	if req.Msg.RecoveryCode != "" {
+		method = "recovery_code"
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{
+		ActorID:  userId,
+		Action:   AuditLoginSucceeded,
+		Metadata: map[string]any{"method": "password", "second_factor": method},
+	})
+
+	return connect.NewResponse(&v1.VerifyMFAResponse{
+		Id: userId,
+	}), nil
+}
+
+// checkMFAThrottle claims a second factor attempt against the same account as its password, keyed by
+// email, or by id for accounts without one. It returns nil without a throttle.
+func (as *AuthHandler) checkMFAThrottle(ctx context.Context, userId string) (*LoginAttempt, error) {
+	if as.throttle == nil {
+		return nil, nil
+	}
+
+	user, err := as.store.GetUserByID(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	key := userId
+
+	if user.Email != nil {
+		key = *user.Email
+	}
+
+	attempt, err := as.throttle.Check(ctx, key, internal.ClientInfoFromContext(ctx).IP)
+
+	var throttleErr *ThrottleError
+
+	if errors.As(err, &throttleErr) {
+		recordAudit(ctx, as.audit, AuditEvent{
+			Action:   AuditMFAFailed,
+			TargetID: userId,
+			Metadata: map[string]any{"reason": "throttled", "locked": errors.Is(err, ErrAccountLocked)},
+		})
+		return nil, throttleErr.ConnectError()
+	}
+
+	if err != nil {
+		internal.RpcLogger(ctx).Error("error checking login throttle", slog.String("Error", err.Error()))
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return attempt, nil
+}
+
+func (as *ProtectedAuthHandler) EnrollTOTP(ctx context.Context, req *connect.Request[v1.EnrollTOTPRequest]) (*connect.Response[v1.EnrollTOTPResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	enabled, err := hasMFA(ctx, as.store, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if enabled {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("totp already enabled"))
+	}
+
+	user, err := as.store.GetUserByID(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	secret, err := generateTOTPSecret()
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = as.store.SaveTOTPSecret(ctx, userId, secret)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	account := user.ID
+
+	if user.Email != nil {
+		account = *user.Email
+	}
+
+	return connect.NewResponse(&v1.EnrollTOTPResponse{
+		Secret: secret,
+		Uri:    totpURI(TOTPIssuer, account, secret),
+	}), nil
+}
+
+func (as *ProtectedAuthHandler) ConfirmTOTP(ctx context.Context, req *connect.Request[v1.ConfirmTOTPRequest]) (*connect.Response[v1.ConfirmTOTPResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	totp, err := as.store.GetTOTP(ctx, userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("totp enrollment not started"))
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if totp.ConfirmedAt != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("totp already enabled"))
+	}
+
+	step, ok := validateTOTP(totp.Secret, req.Msg.Code, time.Now())
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeInvalidArgument, ErrInvalidMFACode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = as.store.ConfirmTOTP(ctx, userId, step, hashes)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditTOTPEnabled})
+
+	return connect.NewResponse(&v1.ConfirmTOTPResponse{
+		RecoveryCodes: codes,
+	}), nil
+}
+
+func (as *ProtectedAuthHandler) DisableTOTP(ctx context.Context, req *connect.Request[v1.DisableTOTPRequest]) (*connect.Response[v1.DisableTOTPResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	ok, err := verifyTOTPCode(ctx, as.store, userId, req.Msg.Code)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeInvalidArgument, ErrInvalidMFACode)
+	}
+
+	err = as.store.DeleteTOTP(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditTOTPDisabled})
+
+	return connect.NewResponse(&v1.DisableTOTPResponse{}), nil
+}
+
+func (as *ProtectedAuthHandler) RegenerateRecoveryCodes(ctx context.Context, req *connect.Request[v1.RegenerateRecoveryCodesRequest]) (*connect.Response[v1.RegenerateRecoveryCodesResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	ok, err := verifyTOTPCode(ctx, as.store, userId, req.Msg.Code)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeInvalidArgument, ErrInvalidMFACode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = as.store.ReplaceRecoveryCodes(ctx, userId, hashes)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	recordAudit(ctx, as.audit, AuditEvent{ActorID: userId, Action: AuditRecoveryCodesRegenerated})
+
+	return connect.NewResponse(&v1.RegenerateRecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}), nil
+}
@@ -0,0 +1,414 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"simple-connect/api/data/gen/gopg/public/model"
+	v1 "simple-connect/gen/proto/api/v1"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const SessionPasskeyRegistrationKey = "passkey_registration"
+const SessionPasskeyLoginKey = "passkey_login"
+
+var ErrPasskeyCloned = errors.New("passkey sign counter went backwards")
+
+type PasskeyStore interface {
+	GetUserByID(ctx context.Context, id string) (*model.Users, error)
+	GetPasskeys(ctx context.Context, userId string) ([]Passkey, error)
+	CreatePasskey(ctx context.Context, passkey Passkey) error
+	UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, cloneWarning bool, backupState bool) error
+	DeletePasskey(ctx context.Context, userId string, id []byte) (bool, error)
+}
+
+// Passkey is a stored WebAuthn credential
+type Passkey struct {
+	ID              []byte     `db:"id"`
+	UserID          string     `db:"user_id"`
+	Name            string     `db:"name"`
+	PublicKey       []byte     `db:"public_key"`
+	AttestationType string     `db:"attestation_type"`
+	Transports      []string   `db:"transports"`
+	AAGUID          []byte     `db:"aaguid"`
+	SignCount       int64      `db:"sign_count"`
+	CloneWarning    bool       `db:"clone_warning"`
+	BackupEligible  bool       `db:"backup_eligible"`
+	BackupState     bool       `db:"backup_state"`
+	LastUsedAt      *time.Time `db:"last_used_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+func newPasskey(userId string, name string, cred *webauthn.Credential) Passkey {
+	transports := make([]string, len(cred.Transport))
+
+	for i, t := range cred.Transport {
+		transports[i] = string(t)
+	}
+
+	return Passkey{
+		ID:              cred.ID,
+		UserID:          userId,
+		Name:            name,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Transports:      transports,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       int64(cred.Authenticator.SignCount),
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func (p Passkey) credential() webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, len(p.Transports))
+
+	for i, t := range p.Transports {
+		transports[i] = protocol.AuthenticatorTransport(t)
+	}
+
+	return webauthn.Credential{
+		ID:              p.ID,
+		PublicKey:       p.PublicKey,
+		AttestationType: p.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: p.BackupEligible,
+			BackupState:    p.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       p.AAGUID,
+			SignCount:    uint32(p.SignCount),
+			CloneWarning: p.CloneWarning,
+		},
+	}
+}
+
+func (p Passkey) toProto() *v1.Passkey {
+	passkey := &v1.Passkey{
+		Id:        base64.RawURLEncoding.EncodeToString(p.ID),
+		Name:      p.Name,
+		CreatedAt: timestamppb.New(p.CreatedAt),
+	}
+
+	if p.LastUsedAt != nil {
+		passkey.LastUsedAt = timestamppb.New(*p.LastUsedAt)
+	}
+
+	return passkey
+}
+
+// passkeyUser adapts a user and their passkeys to webauthn.User. The user id is the WebAuthn user handle.
+type passkeyUser struct {
+	user     *model.Users
+	passkeys []Passkey
+}
+
+func (pu *passkeyUser) WebAuthnID() []byte {
+	return []byte(pu.user.ID)
+}
+
+func (pu *passkeyUser) WebAuthnName() string {
+	if pu.user.Email != nil {
+		return *pu.user.Email
+	}
+
+	return pu.user.ID
+}
+
+func (pu *passkeyUser) WebAuthnDisplayName() string {
+	return pu.WebAuthnName()
+}
+
+func (pu *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(pu.passkeys))
+
+	for i, p := range pu.passkeys {
+		credentials[i] = p.credential()
+	}
+
+	return credentials
+}
+
+// NewWebAuthn configures the relying party. rpId is the registrable domain (e.g. "example.com") and
+// origins are the full origins the frontend is served from.
+func NewWebAuthn(rpId string, rpDisplayName string, origins []string) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpId,
+		RPDisplayName: rpDisplayName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+}
+
+// PasskeyHandler implements both the public PasskeyService (login) and the ProtectedPasskeyService (management)
+type PasskeyHandler struct {
+	store          PasskeyStore
+	sessionManager *scs.SessionManager
+	webAuthn       *webauthn.WebAuthn
+}
+
+func NewPasskeyHandler(store PasskeyStore, sessionManager *scs.SessionManager, webAuthn *webauthn.WebAuthn) *PasskeyHandler {
+	return &PasskeyHandler{store: store, sessionManager: sessionManager, webAuthn: webAuthn}
+}
+
+func (ph *PasskeyHandler) loadUser(ctx context.Context, userId string) (*passkeyUser, error) {
+	user, err := ph.store.GetUserByID(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := ph.store.GetPasskeys(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkeyUser{user: user, passkeys: passkeys}, nil
+}
+
+func (ph *PasskeyHandler) putCeremony(ctx context.Context, key string, session *webauthn.SessionData) error {
+	encoded, err := json.Marshal(session)
+
+	if err != nil {
+		return err
+	}
+
+	ph.sessionManager.Put(ctx, key, string(encoded))
+	return nil
+}
+
+// popCeremony returns the in-progress ceremony for key, which can only be finished once
+func (ph *PasskeyHandler) popCeremony(ctx context.Context, key string) (*webauthn.SessionData, error) {
+	encoded := ph.sessionManager.PopString(ctx, key)
+
+	if encoded == "" {
+		return nil, errors.New("no ceremony in progress")
+	}
+
+	var session webauthn.SessionData
+	err := json.Unmarshal([]byte(encoded), &session)
+
+	return &session, err
+}
+
+func (ph *PasskeyHandler) BeginPasskeyLogin(ctx context.Context, req *connect.Request[v1.BeginPasskeyLoginRequest]) (*connect.Response[v1.BeginPasskeyLoginResponse], error) {
+
+	assertion, session, err := ph.webAuthn.BeginDiscoverableLogin()
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = ph.putCeremony(ctx, SessionPasskeyLoginKey, session)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	options, err := json.Marshal(assertion)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.BeginPasskeyLoginResponse{
+		Options: string(options),
+	}), nil
+}
+
+func (ph *PasskeyHandler) FinishPasskeyLogin(ctx context.Context, req *connect.Request[v1.FinishPasskeyLoginRequest]) (*connect.Response[v1.FinishPasskeyLoginResponse], error) {
+
+	session, err := ph.popCeremony(ctx, SessionPasskeyLoginKey)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes([]byte(req.Msg.Credential))
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	var user *passkeyUser
+
+	credential, err := ph.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err = ph.loadUser(ctx, string(userHandle))
+		return user, err
+	}, *session, parsed)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+
+	err = ph.store.UpdatePasskeyUsage(ctx, credential.ID, credential.Authenticator.SignCount, credential.Authenticator.CloneWarning, credential.Flags.BackupState)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, connect.NewError(connect.CodePermissionDenied, ErrPasskeyCloned)
+	}
+
+	err = LoginContext(ctx, ph.sessionManager, SessionData{UserID: user.user.ID})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.FinishPasskeyLoginResponse{
+		Id: user.user.ID,
+	}), nil
+}
+
+func (ph *PasskeyHandler) BeginPasskeyRegistration(ctx context.Context, req *connect.Request[v1.BeginPasskeyRegistrationRequest]) (*connect.Response[v1.BeginPasskeyRegistrationResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	user, err := ph.loadUser(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, len(user.passkeys))
+
+	for i, p := range user.passkeys {
+		exclusions[i] = p.credential().Descriptor()
+	}
+
+	creation, session, err := ph.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	err = ph.putCeremony(ctx, SessionPasskeyRegistrationKey, session)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	options, err := json.Marshal(creation)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.BeginPasskeyRegistrationResponse{
+		Options: string(options),
+	}), nil
+}
+
+func (ph *PasskeyHandler) FinishPasskeyRegistration(ctx context.Context, req *connect.Request[v1.FinishPasskeyRegistrationRequest]) (*connect.Response[v1.FinishPasskeyRegistrationResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	session, err := ph.popCeremony(ctx, SessionPasskeyRegistrationKey)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes([]byte(req.Msg.Credential))
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	user, err := ph.loadUser(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	credential, err := ph.webAuthn.CreateCredential(user, *session, parsed)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	passkey := newPasskey(userId, req.Msg.Name, credential)
+
+	err = ph.store.CreatePasskey(ctx, passkey)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.FinishPasskeyRegistrationResponse{
+		Passkey: passkey.toProto(),
+	}), nil
+}
+
+func (ph *PasskeyHandler) ListPasskeys(ctx context.Context, req *connect.Request[v1.ListPasskeysRequest]) (*connect.Response[v1.ListPasskeysResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	passkeys, err := ph.store.GetPasskeys(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListPasskeysResponse{}
+
+	for _, p := range passkeys {
+		res.Passkeys = append(res.Passkeys, p.toProto())
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (ph *PasskeyHandler) DeletePasskey(ctx context.Context, req *connect.Request[v1.DeletePasskeyRequest]) (*connect.Response[v1.DeletePasskeyResponse], error) {
+
+	userId := UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(req.Msg.Id)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	deleted, err := ph.store.DeletePasskey(ctx, userId, id)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !deleted {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("passkey not found"))
+	}
+
+	return connect.NewResponse(&v1.DeletePasskeyResponse{}), nil
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"simple-connect/api/data/gen/gopg/public/model"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/descope/virtualwebauthn"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryPasskeyStore struct {
+	mu       sync.Mutex
+	users    map[string]*model.Users
+	passkeys []Passkey
+}
+
+func (ms *memoryPasskeyStore) GetUserByID(ctx context.Context, id string) (*model.Users, error) {
+	user, ok := ms.users[id]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (ms *memoryPasskeyStore) GetPasskeys(ctx context.Context, userId string) ([]Passkey, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var passkeys []Passkey
+
+	for _, p := range ms.passkeys {
+		if p.UserID == userId {
+			passkeys = append(passkeys, p)
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (ms *memoryPasskeyStore) CreatePasskey(ctx context.Context, passkey Passkey) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.passkeys = append(ms.passkeys, passkey)
+	return nil
+}
+
+func (ms *memoryPasskeyStore) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, cloneWarning bool, backupState bool) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, p := range ms.passkeys {
+		if bytes.Equal(p.ID, id) {
+			now := time.Now()
+			ms.passkeys[i].SignCount = int64(signCount)
+			ms.passkeys[i].CloneWarning = p.CloneWarning || cloneWarning
+			ms.passkeys[i].BackupState = backupState
+			ms.passkeys[i].LastUsedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (ms *memoryPasskeyStore) DeletePasskey(ctx context.Context, userId string, id []byte) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, p := range ms.passkeys {
+		if p.UserID == userId && bytes.Equal(p.ID, id) {
+			ms.passkeys = append(ms.passkeys[:i], ms.passkeys[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestPasskeys(t *testing.T) {
+
+	t.Parallel()
+
+	const userId = "7d3c1f4e-0000-4000-8000-000000000001"
+	email := "passkey@email.com"
+
+	store := &memoryPasskeyStore{users: map[string]*model.Users{userId: {ID: userId, Email: &email}}}
+	sessionManager := NewMemorySessionManager(false, "")
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	})
+
+	webAuthn, err := NewWebAuthn("127.0.0.1", "simple-connect", []string{server.URL})
+	require.NoError(t, err)
+
+	handler := NewPasskeyHandler(store, sessionManager, webAuthn)
+	interceptors := connect.WithInterceptors(NewAuthInterceptor(sessionManager, nil))
+	server.Mux.Handle(apiv1connect.NewPasskeyServiceHandler(handler, interceptors))
+	server.Mux.Handle(apiv1connect.NewProtectedPasskeyServiceHandler(handler, interceptors))
+
+	rp := virtualwebauthn.RelyingParty{Name: "simple-connect", ID: "127.0.0.1", Origin: server.URL}
+	authenticator := virtualwebauthn.NewAuthenticatorWithOptions(virtualwebauthn.AuthenticatorOptions{UserHandle: []byte(userId)})
+	credential := virtualwebauthn.NewCredential(virtualwebauthn.KeyTypeEC2)
+
+	t.Run("register then login with a software authenticator", func(t *testing.T) {
+		// Stands in for the password login so registration has a session to attach to
+		protected := apiv1connect.NewProtectedPasskeyServiceClient(server.ClientFor(userId), server.URL)
+
+		begin, err := protected.BeginPasskeyRegistration(context.Background(), connect.NewRequest(&v1.BeginPasskeyRegistrationRequest{}))
+		require.NoError(t, err)
+
+		attestationOptions, err := virtualwebauthn.ParseAttestationOptions(begin.Msg.Options)
+		require.NoError(t, err)
+
+		attestation := virtualwebauthn.CreateAttestationResponse(rp, authenticator, credential, *attestationOptions)
+
+		finish, err := protected.FinishPasskeyRegistration(context.Background(), connect.NewRequest(&v1.FinishPasskeyRegistrationRequest{
+			Credential: attestation,
+			Name:       "laptop",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "laptop", finish.Msg.Passkey.Name)
+
+		authenticator.AddCredential(credential)
+
+		// A fresh client has no session, the passkey alone should log it in
+		anonymous := server.NewClient()
+		public := apiv1connect.NewPasskeyServiceClient(anonymous, server.URL)
+
+		beginLogin, err := public.BeginPasskeyLogin(context.Background(), connect.NewRequest(&v1.BeginPasskeyLoginRequest{}))
+		require.NoError(t, err)
+
+		assertionOptions, err := virtualwebauthn.ParseAssertionOptions(beginLogin.Msg.Options)
+		require.NoError(t, err)
+
+		assertion := virtualwebauthn.CreateAssertionResponse(rp, authenticator, credential, *assertionOptions)
+
+		finishLogin, err := public.FinishPasskeyLogin(context.Background(), connect.NewRequest(&v1.FinishPasskeyLoginRequest{
+			Credential: assertion,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, userId, finishLogin.Msg.Id)
+
+		list, err := apiv1connect.NewProtectedPasskeyServiceClient(anonymous, server.URL).ListPasskeys(context.Background(), connect.NewRequest(&v1.ListPasskeysRequest{}))
+		require.NoError(t, err)
+		require.Len(t, list.Msg.Passkeys, 1)
+		assert.NotNil(t, list.Msg.Passkeys[0].LastUsedAt)
+
+		// The login ceremony is single use
+		_, err = public.FinishPasskeyLogin(context.Background(), connect.NewRequest(&v1.FinishPasskeyLoginRequest{
+			Credential: assertion,
+		}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("registration requires a session", func(t *testing.T) {
+		protected := apiv1connect.NewProtectedPasskeyServiceClient(server.NewClient(), server.URL)
+
+		_, err := protected.BeginPasskeyRegistration(context.Background(), connect.NewRequest(&v1.BeginPasskeyRegistrationRequest{}))
+
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords into self describing strings. Every hasher verifies argon2id and
+// bcrypt hashes so the configured one can change without locking anyone out.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded, and whether encoded should be replaced
+	// because it uses another algorithm or other parameters than this hasher
+	Verify(password string, encoded string) (match bool, needsRehash bool, err error)
+}
+
+// Argon2Params are argon2id's cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follows the OWASP recommendation of 19 MiB and 2 iterations
+var DefaultArgon2Params = Argon2Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher encodes hashes in the PHC string format, $argon2id$v=19$m=...,t=...,p=...$salt$hash
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (ah *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, ah.params.SaltLength)
+
+	_, err := rand.Read(salt)
+
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, ah.params.Iterations, ah.params.Memory, ah.params.Parallelism, ah.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, ah.params.Memory, ah.params.Iterations, ah.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (ah *Argon2idHasher) Verify(password string, encoded string) (bool, bool, error) {
+	match, err := verifyPasswordHash(password, encoded)
+
+	if !match || err != nil {
+		return match, false, err
+	}
+
+	params, _, _, err := decodeArgon2id(encoded)
+
+	return true, err != nil || params != ah.params, nil
+}
+
+// BcryptHasher keeps bcrypt's own $2a$<cost>$ format, which predates PHC strings
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (bh *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bh.cost)
+
+	return string(hashed), err
+}
+
+func (bh *BcryptHasher) Verify(password string, encoded string) (bool, bool, error) {
+	match, err := verifyPasswordHash(password, encoded)
+
+	if !match || err != nil {
+		return match, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+
+	return true, err != nil || cost != bh.cost, nil
+}
+
+// PasswordHasherFromEnv reads PASSWORD_HASHER, argon2id (the default) or bcrypt. Argon2id's parameters
+// default to DefaultArgon2Params and are overridden by ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and
+// ARGON2_PARALLELISM, bcrypt's cost by BCRYPT_COST. Changing either rehashes passwords as users sign in.
+func PasswordHasherFromEnv() (PasswordHasher, error) {
+	envInt := func(name string, fallback int, bitSize int) (int, error) {
+		value := os.Getenv(name)
+
+		if value == "" {
+			return fallback, nil
+		}
+
+		n, err := strconv.ParseUint(value, 10, bitSize)
+
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if n < 1 {
+			return 0, fmt.Errorf("%s must be at least 1", name)
+		}
+
+		return int(n), nil
+	}
+
+	switch algorithm := os.Getenv("PASSWORD_HASHER"); algorithm {
+	case "", "argon2id":
+		params := DefaultArgon2Params
+
+		memory, err := envInt("ARGON2_MEMORY_KIB", int(params.Memory), 32)
+
+		if err != nil {
+			return nil, err
+		}
+
+		iterations, err := envInt("ARGON2_ITERATIONS", int(params.Iterations), 32)
+
+		if err != nil {
+			return nil, err
+		}
+
+		parallelism, err := envInt("ARGON2_PARALLELISM", int(params.Parallelism), 8)
+
+		if err != nil {
+			return nil, err
+		}
+
+		params.Memory, params.Iterations, params.Parallelism = uint32(memory), uint32(iterations), uint8(parallelism)
+
+		return NewArgon2idHasher(params), nil
+	case "bcrypt":
+		cost, err := envInt("BCRYPT_COST", bcrypt.DefaultCost, 8)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		return NewBcryptHasher(cost), nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASHER %q", algorithm)
+	}
+}
+
+// verifyPasswordHash checks password against an argon2id or bcrypt hash
+func verifyPasswordHash(password string, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+
+		if err != nil {
+			return false, err
+		}
+
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+		return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+	case strings.HasPrefix(encoded, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+
+		return err == nil, err
+	default:
+		return false, ErrUnknownPasswordHash
+	}
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	var version int
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+
+	// argon2 panics on zero iterations or parallelism
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
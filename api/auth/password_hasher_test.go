@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashers(t *testing.T) {
+
+	t.Parallel()
+
+	// Cheap parameters keep the test fast, they are compared rather than measured
+	params := Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	argon := NewArgon2idHasher(params)
+	legacy := NewBcryptHasher(bcrypt.MinCost)
+
+	argonHash, err := argon.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(argonHash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	bcryptHash, err := legacy.Hash("correct horse")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		hasher      PasswordHasher
+		password    string
+		encoded     string
+		match       bool
+		needsRehash bool
+	}{
+		{"argon2id", argon, "correct horse", argonHash, true, false},
+		{"wrong password", argon, "battery staple", argonHash, false, false},
+		{"bcrypt upgrades to argon2id", argon, "correct horse", bcryptHash, true, true},
+		{"argon2id parameters changed", NewArgon2idHasher(DefaultArgon2Params), "correct horse", argonHash, true, true},
+		{"bcrypt", legacy, "correct horse", bcryptHash, true, false},
+		{"bcrypt cost changed", NewBcryptHasher(bcrypt.MinCost + 1), "correct horse", bcryptHash, true, true},
+		{"argon2id downgrades to bcrypt", legacy, "correct horse", argonHash, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash, err := tt.hasher.Verify(tt.password, tt.encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, match)
+			assert.Equal(t, tt.needsRehash, needsRehash)
+		})
+	}
+
+	t.Run("unknown formats are an error", func(t *testing.T) {
+		_, _, err := argon.Verify("correct horse", "plaintext")
+		assert.ErrorIs(t, err, ErrUnknownPasswordHash)
+
+		_, _, err = argon.Verify("correct horse", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5")
+		assert.ErrorIs(t, err, ErrUnknownPasswordHash)
+	})
+}
+
+func TestPasswordHasherFromEnv(t *testing.T) {
+
+	for _, name := range []string{"ARGON2_MEMORY_KIB", "ARGON2_ITERATIONS", "ARGON2_PARALLELISM"} {
+		t.Run(name+" must be positive", func(t *testing.T) {
+			t.Setenv("PASSWORD_HASHER", "argon2id")
+			t.Setenv(name, "0")
+
+			_, err := PasswordHasherFromEnv()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), name)
+		})
+	}
+
+	t.Run("valid parameters are used", func(t *testing.T) {
+		t.Setenv("PASSWORD_HASHER", "argon2id")
+		t.Setenv("ARGON2_MEMORY_KIB", "64")
+		t.Setenv("ARGON2_ITERATIONS", "2")
+		t.Setenv("ARGON2_PARALLELISM", "1")
+
+		hasher, err := PasswordHasherFromEnv()
+		require.NoError(t, err)
+
+		hash, err := hasher.Hash("correct horse")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=2,p=1$"))
+	})
+}
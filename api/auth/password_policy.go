@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"simple-connect/api/apierrors"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"connectrpc.com/connect"
+)
+
+// Password violation codes
+const (
+	PasswordTooShort                = "too_short"
+	PasswordTooLong                 = "too_long"
+	PasswordMissingCharacterClasses = "missing_character_classes"
+	PasswordContainsEmail           = "contains_email"
+	PasswordBreached                = "breached"
+)
+
+// minEmailMatchLength is the shortest email local part a password is checked for
+const minEmailMatchLength = 4
+
+// bcryptMaxLength is the most bytes bcrypt reads, anything after is silently ignored
+const bcryptMaxLength = 72
+
+var ErrPasswordRejected = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonPasswordRejected, "password rejected")
+
+// PasswordPolicyError lists every rule a password breaks, it unwraps to ErrPasswordRejected
+type PasswordPolicyError struct {
+	Violations []*v1.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+
+	return "password rejected: " + strings.Join(messages, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrPasswordRejected
+}
+
+// ConnectError is an InvalidArgument error carrying the violations as a PasswordPolicyViolations detail
+func (e *PasswordPolicyError) ConnectError() *connect.Error {
+	connectErr := connect.NewError(connect.CodeInvalidArgument, e)
+
+	if detail, err := connect.NewErrorDetail(&v1.PasswordPolicyViolations{Violations: e.Violations}); err == nil {
+		connectErr.AddDetail(detail)
+	}
+
+	return connectErr
+}
+
+// BreachedPasswordChecker reports whether a password appears in known breaches
+type BreachedPasswordChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+type PasswordPolicy struct {
+	// MinLength counts characters, not bytes
+	MinLength int
+	// MaxLength counts bytes and may not exceed bcrypt's 72
+	MaxLength int
+	// RequiredClasses is how many of lowercase, uppercase, digits and symbols the password must mix
+	RequiredClasses int
+	// Breached screens passwords when set
+	Breached BreachedPasswordChecker
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:       10,
+	MaxLength:       bcryptMaxLength,
+	RequiredClasses: 2,
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// Check returns a *PasswordPolicyError when the password breaks the policy. email is the account's
+// address, neither it nor its local part may appear in the password.
+func (pp PasswordPolicy) Check(ctx context.Context, password string, email string) error {
+	var violations []*v1.PasswordViolation
+
+	violate := func(code string, message string) {
+		violations = append(violations, &v1.PasswordViolation{Code: code, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < pp.MinLength {
+		violate(PasswordTooShort, fmt.Sprintf("must be at least %d characters", pp.MinLength))
+	}
+
+	maxLength := min(pp.MaxLength, bcryptMaxLength)
+
+	if maxLength <= 0 {
+		maxLength = bcryptMaxLength
+	}
+
+	if len(password) > maxLength {
+		violate(PasswordTooLong, fmt.Sprintf("must be at most %d bytes", maxLength))
+	}
+
+	if characterClasses(password) < pp.RequiredClasses {
+		violate(PasswordMissingCharacterClasses, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", pp.RequiredClasses))
+	}
+
+	lowered := strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	localPart, _, _ := strings.Cut(email, "@")
+
+	// Very short local parts would reject passwords for containing a couple of common letters
+	if email != "" && (strings.Contains(lowered, email) || (len(localPart) >= minEmailMatchLength && strings.Contains(lowered, localPart))) {
+		violate(PasswordContainsEmail, "must not contain your email address")
+	}
+
+	// Screening needs the full password, skip it for passwords that are rejected anyway
+	if pp.Breached != nil && len(violations) == 0 {
+		breached, err := pp.Breached.IsBreached(ctx, password)
+
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			violate(PasswordBreached, "appears in a known data breach, choose another")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// HashRangeSource returns the breached SHA-1 hashes starting with a 5 character hex prefix, as
+// "SUFFIX:COUNT" lines like the Pwned Passwords range API. The password itself never leaves the checker.
+type HashRangeSource interface {
+	Range(ctx context.Context, prefix string) (io.ReadCloser, error)
+}
+
+// KAnonymityChecker screens passwords against a HashRangeSource
+type KAnonymityChecker struct {
+	source HashRangeSource
+}
+
+func NewKAnonymityChecker(source HashRangeSource) *KAnonymityChecker {
+	return &KAnonymityChecker{source: source}
+}
+
+func (kc *KAnonymityChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	hashes, err := kc.source.Range(ctx, prefix)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer hashes.Close()
+
+	scanner := bufio.NewScanner(hashes)
+
+	for scanner.Scan() {
+		candidate, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+
+		// Padded responses list hashes with a count of 0 that were never breached
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// DirRangeSource reads range files named by their prefix, e.g. <dir>/5BAA6, such as those written
+// by the Pwned Passwords downloader. Missing files are empty ranges.
+type DirRangeSource string
+
+func (d DirRangeSource) Range(ctx context.Context, prefix string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(string(d), strings.ToUpper(prefix)))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	return file, err
+}
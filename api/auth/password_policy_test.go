@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	// SHA-1 of "Password123" is B2E98AD6F6EB8508DD6A14CFA704BAD7F05F6FB1, the padding entry is never breached
+	err := os.WriteFile(filepath.Join(dir, "B2E98"), []byte("AD6F6EB8508DD6A14CFA704BAD7F05F6FB1:12\r\n0000000000000000000000000000000000A:0\r\n"), 0o600)
+	require.NoError(t, err)
+
+	policy := DefaultPasswordPolicy
+	policy.Breached = NewKAnonymityChecker(DirRangeSource(dir))
+
+	tests := []struct {
+		name     string
+		password string
+		codes    []string
+	}{
+		{"accepted", "correct horse battery 9", nil},
+		{"empty", "", []string{PasswordTooShort, PasswordMissingCharacterClasses}},
+		{"one class", "abcdefghijkl", []string{PasswordMissingCharacterClasses}},
+		{"past bcrypt's limit", strings.Repeat("aB3", 25), []string{PasswordTooLong}},
+		{"contains the email", "Jane.Doe-2026", []string{PasswordContainsEmail}},
+		{"breached", "Password123", []string{PasswordBreached}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(context.Background(), tt.password, "jane.doe@example.com")
+
+			if tt.codes == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+
+			var codes []string
+
+			for _, v := range policyErr.Violations {
+				codes = append(codes, v.Code)
+			}
+
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+
+	t.Run("violations are connect error details", func(t *testing.T) {
+		err := policy.Check(context.Background(), "short", "")
+
+		var policyErr *PasswordPolicyError
+		require.ErrorAs(t, err, &policyErr)
+
+		connectErr := policyErr.ConnectError()
+		assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+		require.Len(t, connectErr.Details(), 1)
+
+		detail, err := connectErr.Details()[0].Value()
+		require.NoError(t, err)
+		assert.Equal(t, PasswordTooShort, detail.(*v1.PasswordPolicyViolations).Violations[0].Code)
+	})
+}
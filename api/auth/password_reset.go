@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/mail"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+)
+
+const PasswordResetLifetime = time.Hour
+
+var ErrEmptyPassword = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonPasswordRejected, "password must not be empty")
+
+type PasswordResetter struct {
+	store    AuthStore
+	mailer   mail.Mailer
+	resetURL string
+	policy   PasswordPolicy
+}
+
+// NewPasswordResetter creates a PasswordResetter that mails links of the form resetURL?token=...
+// New passwords must satisfy policy.
+func NewPasswordResetter(store AuthStore, mailer mail.Mailer, resetURL string, policy PasswordPolicy) *PasswordResetter {
+	return &PasswordResetter{store: store, mailer: mailer, resetURL: resetURL, policy: policy}
+}
+
+// RequestReset mails a reset link if a password account exists for the email.
+// Unknown emails and users who sign in without a password are not an error so callers
+// can't tell which addresses are registered.
+func (pr *PasswordResetter) RequestReset(ctx context.Context, email string) error {
+	user, err := pr.store.GetUserByEmail(ctx, email)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !user.PasswordHash.Valid {
+		return nil
+	}
+
+	token, err := GenerateToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = pr.store.CreatePasswordResetToken(ctx, user.ID, HashToken(token), time.Now().Add(PasswordResetLifetime))
+
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", pr.resetURL, url.QueryEscape(token))
+
+	return pr.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Someone asked to reset the password for this account. If it was you, open the link below within an hour.\n\n%s\n\nIf it wasn't, you can ignore this email.\n", link),
+	})
+}
+
+// Reset consumes the token, sets the new password and signs the user out everywhere. Passwords breaking
+// the policy are a *PasswordPolicyError and leave the token usable.
+func (pr *PasswordResetter) Reset(ctx context.Context, token string, password string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+
+	tokenHash := HashToken(token)
+
+	email, err := pr.store.GetPasswordResetEmail(ctx, tokenHash)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrInvalidToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	err = pr.policy.Check(ctx, password, email)
+
+	if err != nil {
+		return "", err
+	}
+
+	return pr.store.ResetPassword(ctx, tokenHash, password)
+}
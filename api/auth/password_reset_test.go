@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resetToken struct {
+	userId string
+	used   bool
+}
+
+// memoryResetStore revokes sessions straight from the session manager's memstore, matching them
+// on the session's user like deleteUserSessions does for rows without a user_id
+type memoryResetStore struct {
+	AuthStore
+	mu       sync.Mutex
+	sessions *memstore.MemStore
+	users    map[string]*DBUser
+	tokens   map[string]*resetToken
+}
+
+func (ms *memoryResetStore) GetUserByEmail(ctx context.Context, email string) (*DBUser, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[email]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (ms *memoryResetStore) CreatePasswordResetToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.tokens[tokenHash] = &resetToken{userId: userId}
+	return nil
+}
+
+func (ms *memoryResetStore) GetPasswordResetEmail(ctx context.Context, tokenHash string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.tokens[tokenHash]
+
+	if !ok || token.used {
+		return "", pgx.ErrNoRows
+	}
+
+	for email, user := range ms.users {
+		if user.ID == token.userId {
+			return email, nil
+		}
+	}
+
+	return "", pgx.ErrNoRows
+}
+
+func (ms *memoryResetStore) ResetPassword(ctx context.Context, tokenHash string, password string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.tokens[tokenHash]
+
+	if !ok || token.used {
+		return "", ErrInvalidToken
+	}
+
+	token.used = true
+
+	for _, user := range ms.users {
+		if user.ID == token.userId {
+			user.PasswordHash = sql.NullString{String: password, Valid: true}
+		}
+	}
+
+	all, err := ms.sessions.All()
+
+	if err != nil {
+		return "", err
+	}
+
+	for sessionToken, b := range all {
+		_, values, err := scs.GobCodec{}.Decode(b)
+
+		if err != nil {
+			return "", err
+		}
+
+		if values[SessionUserKey] == token.userId {
+			ms.sessions.Delete(sessionToken)
+		}
+	}
+
+	return token.userId, nil
+}
+
+func (ms *memoryResetStore) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func TestPasswordReset(t *testing.T) {
+
+	t.Parallel()
+
+	passwordless := "passwordless@example.com"
+	sessions := memstore.New()
+	store := &memoryResetStore{
+		sessions: sessions,
+		users: map[string]*DBUser{
+			"jane@example.com": {ID: "jane", PasswordHash: sql.NullString{String: "old password 1", Valid: true}},
+			passwordless:       {ID: "passwordless", Email: &passwordless},
+		},
+		tokens: map[string]*resetToken{},
+	}
+	sessionManager := NewMemorySessionManager(false, "")
+	sessionManager.Store = sessions
+	mailer := &outbox{}
+	resetter := NewPasswordResetter(store, mailer, "https://app.test/reset-password", DefaultPasswordPolicy)
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	})
+	interceptors := connect.WithInterceptors(NewAuthInterceptor(sessionManager, nil))
+	server.Mux.Handle(apiv1connect.NewAuthServiceHandler(NewAuthHandler(store, sessionManager, nil, resetter, nil, nil, nil, DefaultPasswordPolicy, nil, nil), interceptors))
+	server.Mux.Handle(apiv1connect.NewProtectedAuthServiceHandler(NewProtectedAuthHandler(store, sessionManager, nil, NewProviderRegistry(), nil), interceptors))
+
+	public := apiv1connect.NewAuthServiceClient(server.NewClient(), server.URL)
+	ctx := context.Background()
+
+	sent := func() int {
+		mailer.mu.Lock()
+		defer mailer.mu.Unlock()
+
+		return len(mailer.messages)
+	}
+
+	t.Run("only password accounts are mailed", func(t *testing.T) {
+		require.NoError(t, resetter.RequestReset(ctx, "nobody@example.com"))
+		require.NoError(t, resetter.RequestReset(ctx, passwordless))
+		assert.Equal(t, 0, sent())
+	})
+
+	t.Run("reset replaces the password and signs the user out", func(t *testing.T) {
+		signedIn := apiv1connect.NewProtectedAuthServiceClient(server.ClientFor("jane"), server.URL)
+
+		require.NoError(t, resetter.RequestReset(ctx, "jane@example.com"))
+		require.Equal(t, 1, sent())
+
+		msg := mailer.messages[0]
+		start := strings.Index(msg.Body, "https://app.test/reset-password?token=")
+		require.NotEqual(t, -1, start)
+		link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+
+		_, err = public.ResetPassword(ctx, connect.NewRequest(&v1.ResetPasswordRequest{Token: token, Password: "short"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+		// A rejected password leaves the token usable
+		_, err = public.ResetPassword(ctx, connect.NewRequest(&v1.ResetPasswordRequest{Token: token, Password: "new password 2"}))
+		require.NoError(t, err)
+		assert.Equal(t, "new password 2", store.users["jane@example.com"].PasswordHash.String)
+
+		_, err = signedIn.ListSessions(ctx, connect.NewRequest(&v1.ListSessionsRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		_, err = public.ResetPassword(ctx, connect.NewRequest(&v1.ResetPasswordRequest{Token: token, Password: "new password 3"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+}
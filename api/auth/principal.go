@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type PrincipalKind int
+
+const (
+	PrincipalSession PrincipalKind = iota + 1
+	PrincipalAccessToken
+)
+
+// Principal is who a request is made by
+type Principal struct {
+	UserID string
+	Kind   PrincipalKind
+	// AccessToken is the token the request authenticated with when Kind is PrincipalAccessToken
+	AccessToken *AccessToken
+}
+
+type principalContextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal AuthInterceptor resolved for the request
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok
+}
+
+// UserIDFromContext returns the id of the user the request is made by, or "" for anonymous requests
+func UserIDFromContext(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.UserID
+	}
+
+	return ""
+}
+
+var errUnauthenticated = errors.New("unauthorized")
+
+// AuthInterceptor resolves the caller from an Authorization: Bearer personal access token or the
+// session and enforces the procedure's (auth) option. It needs the session manager's LoadAndSave
+// middleware in front of the handler.
+type AuthInterceptor struct {
+	sessionManager *scs.SessionManager
+	tokens         AccessTokenStore
+}
+
+// NewAuthInterceptor returns an interceptor for Connect handlers. Bearer tokens are refused when tokens is nil.
+func NewAuthInterceptor(sessionManager *scs.SessionManager, tokens AccessTokenStore) *AuthInterceptor {
+	return &AuthInterceptor{sessionManager: sessionManager, tokens: tokens}
+}
+
+func (ai *AuthInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		ctx, err := ai.authorize(ctx, req.Spec(), req.Header())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (ai *AuthInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (ai *AuthInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := ai.authorize(ctx, conn.Spec(), conn.RequestHeader())
+
+		if err != nil {
+			return err
+		}
+
+		return next(ctx, conn)
+	}
+}
+
+func (ai *AuthInterceptor) authorize(ctx context.Context, spec connect.Spec, header http.Header) (context.Context, error) {
+	requirement := authRequirement(spec)
+
+	principal, err := ai.resolve(ctx, header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		if requirement == v1.AuthRequirement_AUTH_REQUIREMENT_PUBLIC {
+			return ctx, nil
+		}
+
+		return nil, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated)
+	}
+
+	if principal.Kind != PrincipalSession && requirement == v1.AuthRequirement_AUTH_REQUIREMENT_SESSION {
+		return nil, connect.NewError(connect.CodePermissionDenied, ErrSessionRequired)
+	}
+
+	// Tokens without the write scope may only call procedures marked idempotency_level = NO_SIDE_EFFECTS
+	if principal.AccessToken != nil && spec.IdempotencyLevel != connect.IdempotencyNoSideEffects && !principal.AccessToken.HasScope(ScopeWrite) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("access token lacks the write scope"))
+	}
+
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// resolve returns the request's principal, or nil for anonymous requests. Invalid bearer tokens are
+// an error rather than anonymous so clients notice them.
+func (ai *AuthInterceptor) resolve(ctx context.Context, header http.Header) (*Principal, error) {
+	authorization := header.Get("Authorization")
+
+	if authorization == "" {
+		userId := ai.sessionManager.GetString(ctx, SessionUserKey)
+
+		if userId == "" {
+			return nil, nil
+		}
+
+		return &Principal{UserID: userId, Kind: PrincipalSession}, nil
+	}
+
+	scheme, raw, ok := strings.Cut(authorization, " ")
+
+	if ai.tokens == nil || !ok || !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(raw, AccessTokenPrefix) {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid authorization header"))
+	}
+
+	token, err := ai.tokens.GetAccessTokenByHash(ctx, HashToken(raw))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid access token"))
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("access token expired"))
+	}
+
+	// Scripts call in bursts, last_used_at only needs to be about right
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > accessTokenTouchInterval {
+		err = ai.tokens.TouchAccessToken(ctx, token.ID)
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+	}
+
+	return &Principal{UserID: token.UserID, Kind: PrincipalAccessToken, AccessToken: token}, nil
+}
+
+// authRequirement reads the method's (auth) option, falling back to its service's (default_auth)
+// and then to AUTH_REQUIREMENT_AUTHENTICATED
+func authRequirement(spec connect.Spec) v1.AuthRequirement {
+	method, ok := spec.Schema.(protoreflect.MethodDescriptor)
+
+	if !ok {
+		return v1.AuthRequirement_AUTH_REQUIREMENT_AUTHENTICATED
+	}
+
+	requirement := proto.GetExtension(method.Options(), v1.E_Auth).(v1.AuthRequirement)
+
+	if requirement != v1.AuthRequirement_AUTH_REQUIREMENT_UNSPECIFIED {
+		return requirement
+	}
+
+	if service, ok := method.Parent().(protoreflect.ServiceDescriptor); ok {
+		requirement = proto.GetExtension(service.Options(), v1.E_DefaultAuth).(v1.AuthRequirement)
+
+		if requirement != v1.AuthRequirement_AUTH_REQUIREMENT_UNSPECIFIED {
+			return requirement
+		}
+	}
+
+	return v1.AuthRequirement_AUTH_REQUIREMENT_AUTHENTICATED
+}
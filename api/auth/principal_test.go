@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestAuthRequirement(t *testing.T) {
+
+	t.Parallel()
+
+	services := v1.File_proto_api_v1_auth_proto.Services()
+
+	tests := []struct {
+		service  protoreflect.Name
+		method   protoreflect.Name
+		expected v1.AuthRequirement
+	}{
+		{"AuthService", "VerifyEmail", v1.AuthRequirement_AUTH_REQUIREMENT_PUBLIC},
+		{"ProtectedAuthService", "Me", v1.AuthRequirement_AUTH_REQUIREMENT_AUTHENTICATED},
+		{"ProtectedAuthService", "CreateAccessToken", v1.AuthRequirement_AUTH_REQUIREMENT_SESSION},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			method := services.ByName(tt.service).Methods().ByName(tt.method)
+			assert.Equal(t, tt.expected, authRequirement(connect.Spec{Schema: method}))
+		})
+	}
+
+	t.Run("procedures without a schema require authentication", func(t *testing.T) {
+		assert.Equal(t, v1.AuthRequirement_AUTH_REQUIREMENT_AUTHENTICATED, authRequirement(connect.Spec{}))
+	})
+}
+
+func TestAuthInterceptor(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryAccessTokenStore{tokens: map[string]*AccessToken{}}
+	sessionManager := NewMemorySessionManager(false, "")
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	})
+	// Calls the interceptor lets through reach the unimplemented handler
+	server.Mux.Handle(apiv1connect.NewProtectedAuthServiceHandler(apiv1connect.UnimplementedProtectedAuthServiceHandler{}, connect.WithInterceptors(
+		NewAuthInterceptor(sessionManager, store),
+	)))
+
+	newToken := func(scopes ...string) string {
+		raw, err := GenerateToken()
+		require.NoError(t, err)
+
+		_, err = store.CreateAccessToken(context.Background(), AccessToken{UserID: "script-user", Scopes: scopes, ExpiresAt: time.Now().Add(time.Hour)}, HashToken(AccessTokenPrefix+raw))
+		require.NoError(t, err)
+
+		return AccessTokenPrefix + raw
+	}
+
+	bearer := func(token string) apiv1connect.ProtectedAuthServiceClient {
+		client := *server.Client()
+		client.Transport = &bearerTransport{token: token, base: server.Client().Transport}
+		return apiv1connect.NewProtectedAuthServiceClient(&client, server.URL)
+	}
+
+	readToken, writeToken := newToken(ScopeRead), newToken(ScopeWrite)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		client apiv1connect.ProtectedAuthServiceClient
+		call   func(client apiv1connect.ProtectedAuthServiceClient) error
+		code   connect.Code
+	}{
+		{
+			name:   "calls without a session are unauthenticated",
+			client: apiv1connect.NewProtectedAuthServiceClient(server.NewClient(), server.URL),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.Me(ctx, connect.NewRequest(&v1.MeRequest{}))
+				return err
+			},
+			code: connect.CodeUnauthenticated,
+		},
+		{
+			name:   "sessions call session procedures",
+			client: apiv1connect.NewProtectedAuthServiceClient(server.ClientFor("session-user"), server.URL),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.CreateAccessToken(ctx, connect.NewRequest(&v1.CreateAccessTokenRequest{}))
+				return err
+			},
+			code: connect.CodeUnimplemented,
+		},
+		{
+			name:   "access tokens can't call session procedures",
+			client: bearer(writeToken),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.CreateAccessToken(ctx, connect.NewRequest(&v1.CreateAccessTokenRequest{}))
+				return err
+			},
+			code: connect.CodePermissionDenied,
+		},
+		{
+			name:   "read tokens can't call procedures with side effects",
+			client: bearer(readToken),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.ResendVerificationEmail(ctx, connect.NewRequest(&v1.ResendVerificationEmailRequest{}))
+				return err
+			},
+			code: connect.CodePermissionDenied,
+		},
+		{
+			name:   "write tokens can",
+			client: bearer(writeToken),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.ResendVerificationEmail(ctx, connect.NewRequest(&v1.ResendVerificationEmailRequest{}))
+				return err
+			},
+			code: connect.CodeUnimplemented,
+		},
+		{
+			name:   "unknown tokens are unauthenticated",
+			client: bearer(AccessTokenPrefix + "unknown"),
+			call: func(client apiv1connect.ProtectedAuthServiceClient) error {
+				_, err := client.Me(ctx, connect.NewRequest(&v1.MeRequest{}))
+				return err
+			},
+			code: connect.CodeUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, connect.CodeOf(tt.call(tt.client)))
+		})
+	}
+
+	t.Run("last use is written at most once a minute", func(t *testing.T) {
+		token := newToken(ScopeRead)
+
+		store.mu.Lock()
+		before := store.touches
+		store.mu.Unlock()
+
+		for range 3 {
+			_, err := bearer(token).ListAccessTokens(ctx, connect.NewRequest(&v1.ListAccessTokensRequest{}))
+			assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		assert.Equal(t, 1, store.touches-before)
+	})
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/oauth2"
+)
+
+// tokenExpiryDelta refreshes access tokens slightly early so they don't expire in flight
+const tokenExpiryDelta = time.Minute
+
+var ErrProviderNotLinked = errors.New("provider is not linked")
+var ErrNoRefreshToken = errors.New("access token expired and no refresh token is stored")
+
+// AccountTokenStore reads and refreshes the provider tokens of linked accounts
+type AccountTokenStore interface {
+	WithAccountTokens(ctx context.Context, userId string, provider string, update func(tokens *AccountTokens) (*UpdateAccountTokensData, error)) error
+}
+
+func (t *AccountTokens) valid() bool {
+	if t.AccessToken == "" {
+		return false
+	}
+
+	return !t.AccessTokenExpiry.Valid || time.Now().Add(tokenExpiryDelta).Before(t.AccessTokenExpiry.Time)
+}
+
+// providerToken returns a valid access token for the user's account with provider, refreshing and
+// persisting it when it has expired. The store holds the account's lock while the refresh runs, so
+// concurrent callers wait for the first and read its result.
+func providerToken(ctx context.Context, store AccountTokenStore, providers *ProviderRegistry, userId string, providerName string) (*oauth2.Token, error) {
+	var tok *oauth2.Token
+
+	err := store.WithAccountTokens(ctx, userId, providerName, func(tokens *AccountTokens) (*UpdateAccountTokensData, error) {
+		if tokens.valid() {
+			tok = &oauth2.Token{
+				AccessToken:  tokens.AccessToken,
+				RefreshToken: tokens.RefreshToken,
+				TokenType:    "Bearer",
+				Expiry:       tokens.AccessTokenExpiry.Time,
+			}
+
+			return nil, nil
+		}
+
+		if tokens.RefreshToken == "" {
+			return nil, ErrNoRefreshToken
+		}
+
+		provider, err := providers.Get(providerName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := provider.oauthConfig(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// The oauth2 package keeps the old refresh token when the provider doesn't rotate it
+		tok, err = cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: tokens.RefreshToken}).Token()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &UpdateAccountTokensData{
+			AccessToken:  tok.AccessToken,
+			RefreshToken: tok.RefreshToken,
+			AccessTokenExpiry: sql.NullTime{
+				Time:  tok.Expiry,
+				Valid: !tok.Expiry.IsZero(),
+			},
+		}, nil
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProviderNotLinked
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tok, nil
+}
+
+// ProviderToken returns a valid access token for the user's linked provider account, refreshing it
+// through the provider's token endpoint when it has expired
+func (as *AuthService) ProviderToken(ctx context.Context, providers *ProviderRegistry, userId string, provider string) (*oauth2.Token, error) {
+	return providerToken(ctx, as, providers, userId, provider)
+}
+
+type providerTokenSource struct {
+	ctx       context.Context
+	store     *AuthService
+	providers *ProviderRegistry
+	userId    string
+	provider  string
+}
+
+func (ts *providerTokenSource) Token() (*oauth2.Token, error) {
+	return ts.store.ProviderToken(ts.ctx, ts.providers, ts.userId, ts.provider)
+}
+
+// ProviderTokenSource adapts ProviderToken to an oauth2.TokenSource, e.g. for oauth2.NewClient
+func (as *AuthService) ProviderTokenSource(ctx context.Context, providers *ProviderRegistry, userId string, provider string) oauth2.TokenSource {
+	return &providerTokenSource{ctx: ctx, store: as, providers: providers, userId: userId, provider: provider}
+}
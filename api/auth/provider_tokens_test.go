@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]AccountTokens
+}
+
+// WithAccountTokens holds the store's lock for the whole update, like the row lock AuthService takes
+func (ms *memoryTokenStore) WithAccountTokens(ctx context.Context, userId string, provider string, update func(tokens *AccountTokens) (*UpdateAccountTokensData, error)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	tokens, ok := ms.tokens[userId+provider]
+
+	if !ok {
+		return pgx.ErrNoRows
+	}
+
+	data, err := update(&tokens)
+
+	if err != nil || data == nil {
+		return err
+	}
+
+	if data.RefreshToken == "" {
+		data.RefreshToken = tokens.RefreshToken
+	}
+
+	ms.tokens[userId+provider] = AccountTokens{
+		ProviderId:        tokens.ProviderId,
+		AccessToken:       data.AccessToken,
+		RefreshToken:      data.RefreshToken,
+		AccessTokenExpiry: data.AccessTokenExpiry,
+	}
+
+	return nil
+}
+
+func TestProviderToken(t *testing.T) {
+
+	t.Parallel()
+
+	var refreshes atomic.Int32
+
+	mux := http.NewServeMux()
+	issuer := newTestIssuer(t, mux)
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh-token" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		n := refreshes.Add(1)
+		// Slow enough that concurrent callers pile up behind the lock
+		time.Sleep(50 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "refreshed-" + strconv.Itoa(int(n)),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+
+	registry := NewProviderRegistry()
+	require.NoError(t, registry.Register(OIDCProviderConfig{Name: "mock", Issuer: issuer.URL, ClientID: "client-id"}))
+
+	expired := sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	store := &memoryTokenStore{tokens: map[string]AccountTokens{
+		"fresh" + "mock":      {ProviderId: "1", AccessToken: "still-valid", AccessTokenExpiry: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}},
+		"expired" + "mock":    {ProviderId: "2", AccessToken: "stale", RefreshToken: "refresh-token", AccessTokenExpiry: expired},
+		"no-refresh" + "mock": {ProviderId: "3", AccessToken: "stale", AccessTokenExpiry: expired},
+	}}
+
+	t.Run("valid tokens are returned without refreshing", func(t *testing.T) {
+		tok, err := providerToken(context.Background(), store, registry, "fresh", "mock")
+		require.NoError(t, err)
+		assert.Equal(t, "still-valid", tok.AccessToken)
+	})
+
+	t.Run("concurrent callers refresh an expired token once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		tokens := make([]string, 10)
+
+		for i := range tokens {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				tok, err := providerToken(context.Background(), store, registry, "expired", "mock")
+
+				if assert.NoError(t, err) {
+					tokens[i] = tok.AccessToken
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		assert.Equal(t, int32(1), refreshes.Load())
+
+		for _, tok := range tokens {
+			assert.Equal(t, "refreshed-1", tok)
+		}
+
+		stored := store.tokens["expired"+"mock"]
+		assert.Equal(t, "refreshed-1", stored.AccessToken)
+		assert.Equal(t, "refresh-token", stored.RefreshToken, "refresh token is kept when the provider doesn't rotate it")
+		assert.True(t, stored.AccessTokenExpiry.Time.After(time.Now()))
+	})
+
+	t.Run("expired tokens without a refresh token fail", func(t *testing.T) {
+		_, err := providerToken(context.Background(), store, registry, "no-refresh", "mock")
+		assert.ErrorIs(t, err, ErrNoRefreshToken)
+	})
+
+	t.Run("unlinked providers fail", func(t *testing.T) {
+		_, err := providerToken(context.Background(), store, registry, "nobody", "mock")
+		assert.ErrorIs(t, err, ErrProviderNotLinked)
+	})
+}
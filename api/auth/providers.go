@@ -7,46 +7,273 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"math"
 	"net/http"
 	"os"
+	"simple-connect/api/apierrors"
 	"simple-connect/api/internal"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"connectrpc.com/connect"
 	"github.com/alexedwards/scs/v2"
 	"github.com/jackc/pgx/v5"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 const OAUTH_STATE_SESSION_KEY = "oauth_state"
 const OAUTH_VERIFIER_SESSION_KEY = "oauth_verifier"
+const OAUTH_NONCE_SESSION_KEY = "oauth_nonce"
+
+const GoogleIssuer = "https://accounts.google.com"
 
 var ErrStateMismatch = errors.New("state mismatch")
-var googleOAuthConfig *oauth2.Config
+var ErrUnknownProvider = errors.New("unknown provider")
+
+var defaultScopes = []string{"openid", "profile", "email"}
+
+// OIDCProviderConfig configures one OpenID Connect identity provider.
+// Name is used in the /auth/{provider}/ routes and as accounts.provider.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
 
-type ProviderHandler struct {
-	Domain         string
-	Secure         bool
-	RedirectURI    string
-	AuthStore      AuthStore
-	SessionManager *scs.SessionManager
+// discoveryDocument is the subset of /.well-known/openid-configuration we use
+type discoveryDocument struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	ScopesSupported       []string `json:"scopes_supported"`
+}
+
+type OIDCProvider struct {
+	Name   string
+	config OIDCProviderConfig
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	jwks      *jwksCache
+}
+
+// discover fetches the provider's discovery document once. Failures are not cached so a
+// provider that is briefly unreachable at startup recovers on the next request.
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery for %s returned %s", p.Name, res.Status)
+	}
+
+	var doc discoveryDocument
+	err = json.NewDecoder(res.Body).Decode(&doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer %q", doc.Issuer, p.config.Issuer)
+	}
+
+	if doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery for %s has no jwks_uri", p.Name)
+	}
+
+	p.discovery = &doc
+	p.jwks = &jwksCache{uri: doc.JWKSURI}
+
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) oauthConfig(ctx context.Context) (*oauth2.Config, error) {
+	doc, err := p.discover(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+		RedirectURL: p.config.RedirectURL,
+		Scopes:      offlineScopes(p.config.Scopes, doc.ScopesSupported),
+	}, nil
+}
+
+// offlineScopes adds offline_access, which asks for a refresh token, to scopes when the provider
+// supports it. Providers that don't, such as Google, take the access_type=offline parameter instead.
+func offlineScopes(scopes []string, supported []string) []string {
+	if !slices.Contains(supported, "offline_access") || slices.Contains(scopes, "offline_access") {
+		return scopes
+	}
+
+	return append(slices.Clone(scopes), "offline_access")
+}
+
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]*OIDCProvider
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{providers: map[string]*OIDCProvider{}}
+}
+
+// Register adds a provider. Discovery happens lazily on first use.
+func (pr *ProviderRegistry) Register(cfg OIDCProviderConfig) error {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" {
+		return fmt.Errorf("provider %q needs a name, issuer and client id", cfg.Name)
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.providers[cfg.Name] = &OIDCProvider{Name: cfg.Name, config: cfg}
+
+	return nil
+}
+
+func (pr *ProviderRegistry) Get(name string) (*OIDCProvider, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	provider, ok := pr.providers[name]
+
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	return provider, nil
+}
+
+// ProviderConfigsFromEnv reads OIDC_PROVIDERS, a comma separated list of names, and for each name
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URI and optionally _SCOPES.
+// Google falls back to the GOOGLE_* variables and its well known issuer.
+func ProviderConfigsFromEnv() []OIDCProviderConfig {
+	var configs []OIDCProviderConfig
+	names := strings.Split(os.Getenv("OIDC_PROVIDERS"), ",")
+
+	if os.Getenv("GOOGLE_CLIENT_ID") != "" && !containsName(names, "google") {
+		names = append(names, "google")
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		cfg := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URI"),
+		}
+
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if name == "google" {
+			cfg.Issuer = firstNonEmpty(cfg.Issuer, GoogleIssuer)
+			cfg.ClientID = firstNonEmpty(cfg.ClientID, os.Getenv("GOOGLE_CLIENT_ID"))
+			cfg.ClientSecret = firstNonEmpty(cfg.ClientSecret, os.Getenv("GOOGLE_CLIENT_SECRET"))
+			cfg.RedirectURL = firstNonEmpty(cfg.RedirectURL, os.Getenv("GOOGLE_REDIRECT_URI"))
+		}
+
+		configs = append(configs, cfg)
+	}
+
+	return configs
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.TrimSpace(strings.ToLower(n)) == name {
+			return true
+		}
+	}
+
+	return false
 }
 
-func GoogleConfig() *oauth2.Config {
-	if googleOAuthConfig == nil {
-		googleOAuthConfig = &oauth2.Config{
-			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-			Endpoint:     google.Endpoint,
-			RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URI"),
-			Scopes:       []string{"openid", "profile", "email"},
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
 		}
 	}
 
-	return googleOAuthConfig
+	return ""
+}
+
+type ProviderHandler struct {
+	Domain      string
+	Secure      bool
+	RedirectURI string
+	// MFARedirectURI is where users with a second factor are sent to enter it, RedirectURI when empty
+	MFARedirectURI string
+	AuthStore      AuthStore
+	SessionManager *scs.SessionManager
+	Providers      *ProviderRegistry
+	// Invitations accepts the invitation token passed to HandleAuth as ?invitation=, optional
+	Invitations InvitationAcceptor
+	// Audit records sign ins and refused callbacks, optional
+	Audit AuditLogger
+}
+
+// recordFailure audits a refused callback
+func (ph *ProviderHandler) recordFailure(ctx context.Context, provider string, reason string, targetId string) {
+	recordAudit(ctx, ph.Audit, AuditEvent{
+		Action:   AuditOAuthFailed,
+		TargetID: targetId,
+		Metadata: map[string]any{"provider": provider, "reason": reason},
+	})
 }
 
 func generateState() (string, error) {
@@ -80,12 +307,51 @@ func validateState(r *http.Request) error {
 	return nil
 }
 
-func (ph *ProviderHandler) HandleGoogleAuth(w http.ResponseWriter, r *http.Request) {
+// expireFlowCookies deletes the state, verifier and nonce cookies, each is good for one callback only
+func (ph *ProviderHandler) expireFlowCookies(w http.ResponseWriter) {
+	for _, name := range []string{OAUTH_STATE_SESSION_KEY, OAUTH_VERIFIER_SESSION_KEY, OAUTH_NONCE_SESSION_KEY} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			MaxAge:   -1,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Domain:   ph.Domain,
+			Secure:   ph.Secure,
+		})
+	}
+}
+
+// HandleAuth redirects to the authorization endpoint of the provider named in the path
+func (ph *ProviderHandler) HandleAuth(w http.ResponseWriter, r *http.Request) {
+	reqLogger := internal.RequestLogger(r)
+
+	provider, err := ph.Providers.Get(r.PathValue("provider"))
+
+	if err != nil {
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeNotFound, err))
+		return
+	}
+
+	cfg, err := provider.oauthConfig(r.Context())
+
+	if err != nil {
+		reqLogger.Error("Error loading provider configuration", slog.String("provider", provider.Name), slog.String("err", err.Error()))
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
 	state, err := generateState()
 	verifier := oauth2.GenerateVerifier()
 
 	if err != nil {
-		http.Error(w, "Server Error", http.StatusInternalServerError)
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
+	nonce, err := generateState()
+
+	if err != nil {
+		apierrors.WriteJSON(w, r, err)
 		return
 	}
 
@@ -109,14 +375,28 @@ func (ph *ProviderHandler) HandleGoogleAuth(w http.ResponseWriter, r *http.Reque
 		Secure:   ph.Secure,
 	})
 
-	url := GoogleConfig().AuthCodeURL(state, oauth2.AccessTypeOnline, oauth2.S256ChallengeOption(verifier))
+	http.SetCookie(w, &http.Cookie{
+		Name:     OAUTH_NONCE_SESSION_KEY,
+		Value:    nonce,
+		MaxAge:   60 * 5,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Domain:   ph.Domain,
+		Secure:   ph.Secure,
+	})
+
+	if invitation := r.URL.Query().Get("invitation"); invitation != "" {
+		ph.SessionManager.Put(r.Context(), SessionInvitationKey, invitation)
+	}
+
+	url := cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("nonce", nonce))
 
 	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
 }
 
-// GoogleToken is a custom struct to hold the oidc token response
+// OIDCToken is a custom struct to hold the oidc token response
 // ExpiresIn remaining lifetime of the token in seconds
-type GoogleToken struct {
+type OIDCToken struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	Expiry       time.Time `json:"expiry,omitempty"`
@@ -125,85 +405,138 @@ type GoogleToken struct {
 	Scope        string    `json:"scope"`
 }
 
-type googleUserInfo struct {
-	Email         string `json:"email"`
-	EmailVerified bool   `json:"email_verified"`
-	FamilyName    string `json:"family_name"`
-	GivenName     string `json:"given_name"`
-	Picture       string `json:"picture"`
-	Sub           string `json:"sub"`
-	Name          string `json:"name"`
-	Locale        string `json:"locale"`
+// oidcUserInfo holds the standard claims returned by the userinfo endpoint
+type oidcUserInfo struct {
+	Email         string    `json:"email"`
+	EmailVerified claimBool `json:"email_verified"`
+	FamilyName    string    `json:"family_name"`
+	GivenName     string    `json:"given_name"`
+	Picture       string    `json:"picture"`
+	Sub           string    `json:"sub"`
+	Name          string    `json:"name"`
+	Locale        string    `json:"locale"`
 }
 
-func newGoogleToken(tok *oauth2.Token) *GoogleToken {
-
-	tokExpiresIn := tok.Extra("expires_in")
+func newOIDCToken(tok *oauth2.Token) *OIDCToken {
+	token := &OIDCToken{
+		AccessToken:  tok.AccessToken,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	}
 
-	if tokExpiresIn != nil {
-		expiresIn := int(math.Round(tokExpiresIn.(float64)))
+	if idToken, ok := tok.Extra("id_token").(string); ok {
+		token.IDToken = idToken
+	}
 
-		return &GoogleToken{
-			AccessToken:  tok.AccessToken,
-			RefreshToken: tok.RefreshToken,
-			Expiry:       tok.Expiry,
-			IDToken:      tok.Extra("id_token").(string),
-			ExpiresIn:    &expiresIn,
-			Scope:        tok.Extra("scope").(string),
-		}
+	if scope, ok := tok.Extra("scope").(string); ok {
+		token.Scope = scope
 	}
 
-	return &GoogleToken{
-		AccessToken:  tok.AccessToken,
-		RefreshToken: tok.RefreshToken,
-		Expiry:       tok.Expiry,
-		IDToken:      tok.Extra("id_token").(string),
-		ExpiresIn:    nil,
-		Scope:        tok.Extra("scope").(string),
+	if expiresIn, ok := tok.Extra("expires_in").(float64); ok {
+		rounded := int(math.Round(expiresIn))
+		token.ExpiresIn = &rounded
 	}
+
+	return token
 }
 
-// googleExchange exchanges the code for tokens and gets user info from Google
-func googleExchange(ctx context.Context, code string, verifier string) (*GoogleToken, *googleUserInfo, error) {
-	tok, err := GoogleConfig().Exchange(ctx, code, oauth2.VerifierOption(verifier))
+// exchange exchanges the code for tokens, verifies the id token and gets user info from the provider.
+// The email is only trusted when the id token or userinfo marks it verified, it is returned normalised.
+func (p *OIDCProvider) exchange(ctx context.Context, code string, verifier string, nonce string) (*OIDCToken, *oidcUserInfo, error) {
+	cfg, err := p.oauthConfig(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tok, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 
 	if err != nil {
 		return nil, nil, err
 	}
 
-	googleToken := newGoogleToken(tok)
+	token := newOIDCToken(tok)
 
-	client := GoogleConfig().Client(ctx, tok)
+	claims, err := p.verifyIDToken(ctx, token.IDToken, nonce)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := p.discover(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := cfg.Client(ctx, tok)
+
+	userInfo, err := client.Get(doc.UserinfoEndpoint)
+
+	if err != nil {
+		return nil, nil, err
+	}
 
-	userInfo, err := client.Get("https://openidconnect.googleapis.com/v1/userinfo")
 	defer userInfo.Body.Close()
 
+	if userInfo.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("userinfo returned %s", userInfo.Status)
+	}
+
+	var userJson oidcUserInfo
+	err = json.NewDecoder(userInfo.Body).Decode(&userJson)
+
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var userJson googleUserInfo
-	json.NewDecoder(userInfo.Body).Decode(&userJson)
+	// The userinfo response must be about the same user the id token was issued for
+	if userJson.Sub != claims.Subject {
+		return nil, nil, errors.New("userinfo subject does not match id token")
+	}
+
+	if userJson.Email == "" {
+		userJson.Email = claims.Email
+		userJson.EmailVerified = claims.EmailVerified
+	}
+
+	if userJson.Email == "" || !userJson.EmailVerified {
+		return token, &userJson, ErrEmailNotVerified
+	}
+
+	userJson.Email = normalizeEmail(userJson.Email)
 
-	return googleToken, &userJson, nil
+	return token, &userJson, nil
 }
 
-func (ph *ProviderHandler) HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
+// HandleCallback completes the authorization code flow for the provider named in the path
+func (ph *ProviderHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	reqLogger := internal.RequestLogger(r)
 
+	provider, err := ph.Providers.Get(r.PathValue("provider"))
+
+	if err != nil {
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeNotFound, err))
+		return
+	}
+
+	// The request still carries the cookies, the browser drops them whatever the outcome
+	ph.expireFlowCookies(w)
+
 	code := r.URL.Query().Get("code")
 
 	stateErr := validateState(r)
 
 	if stateErr != nil {
-		http.Error(w, stateErr.Error(), http.StatusBadRequest)
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeInvalidArgument, stateErr))
+		return
 	}
 
 	verifierCookie, err := r.Cookie(OAUTH_VERIFIER_SESSION_KEY)
 
 	if err != nil {
 		reqLogger.Error("Error getting verifier cookie", slog.String("err", err.Error()))
-		http.Error(w, "Server Error", http.StatusInternalServerError)
+		apierrors.WriteJSON(w, r, err)
 		return
 	}
 
@@ -211,91 +544,188 @@ func (ph *ProviderHandler) HandleGoogleCallback(w http.ResponseWriter, r *http.R
 
 	if verifier == "" {
 		reqLogger.Error("Verifier cookie is empty")
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated))
 		return
 	}
 
-	token, userJson, err := googleExchange(r.Context(), code, verifier)
+	nonceCookie, err := r.Cookie(OAUTH_NONCE_SESSION_KEY)
 
 	if err != nil {
-		reqLogger.Error("Error exchanging code for token", slog.String("err", err.Error()))
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated))
 		return
 	}
 
-	// Save tokens and user info to db if valid, save session, login
-	// TODO: Check if user and account already exists, if so, update tokens and expiry, login
+	token, userJson, err := provider.exchange(r.Context(), code, verifier, nonceCookie.Value)
 
-	existingUser, err := ph.AuthStore.GetUserAccount(r.Context(), userJson.Email, "google")
-	accessTokenExpiryValid := token.Expiry != time.Time{}
+	if errors.Is(err, ErrEmailNotVerified) {
+		reqLogger.Warn("Refusing login with unverified provider email", slog.String("provider", provider.Name))
+		ph.recordFailure(r.Context(), provider.Name, "email_not_verified", "")
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			reqLogger.Info("User not found, creating new account")
-
-			userId, createAccErr := ph.AuthStore.CreateAccount(r.Context(), CreateAccountData{
-				Email:        userJson.Email,
-				Provider:     "google",
-				ProviderId:   userJson.Sub,
-				AccessToken:  token.AccessToken,
-				RefreshToken: token.RefreshToken,
-				AccessTokenExpiry: sql.NullTime{
-					Time:  token.Expiry,
-					Valid: accessTokenExpiryValid,
-				},
-			})
-
-			if createAccErr != nil {
-				reqLogger.Error("Error creating account", slog.String("err", createAccErr.Error()))
-
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+		reqLogger.Error("Error exchanging code for token", slog.String("provider", provider.Name), slog.String("err", err.Error()))
+		ph.recordFailure(r.Context(), provider.Name, "exchange_failed", "")
+		apierrors.WriteJSON(w, r, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated))
+		return
+	}
+
+	ctx := r.Context()
+	accessTokenExpiry := sql.NullTime{
+		Time:  token.Expiry,
+		Valid: token.Expiry != time.Time{},
+	}
+
+	linkingUserId := pendingLink(ctx, ph.SessionManager, provider.Name)
+
+	existingAccount, err := ph.AuthStore.GetProviderAccount(ctx, provider.Name, userJson.Sub)
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		reqLogger.Error("Failed OAuth callback", slog.String("err", err.Error()))
+		apierrors.WriteJSON(w, r, err)
+		return
+	}
+
+	var userId string
+	outcome := "existing"
+
+	if err == nil {
+		// Known identity, refresh its tokens and sign in as its user
+
+		if linkingUserId != "" && linkingUserId != existingAccount.UserId {
+			ph.recordFailure(ctx, provider.Name, "linked_to_another_user", linkingUserId)
+			apierrors.WriteJSON(w, r, ErrAccountAlreadyLinked)
+			return
+		}
+
+		err = ph.AuthStore.UpdateAccountTokens(ctx, existingAccount.UserId, provider.Name, userJson.Sub, UpdateAccountTokensData{
+			AccessToken:       token.AccessToken,
+			RefreshToken:      token.RefreshToken,
+			AccessTokenExpiry: accessTokenExpiry,
+		})
+
+		if err != nil {
+			reqLogger.Error("Error updating account tokens", slog.String("err", err.Error()))
+			apierrors.WriteJSON(w, r, err)
+			return
+		}
+
+		userId = existingAccount.UserId
+	} else {
+		accountData := CreateAccountData{
+			Email:             userJson.Email,
+			Provider:          provider.Name,
+			ProviderId:        userJson.Sub,
+			AccessToken:       token.AccessToken,
+			RefreshToken:      token.RefreshToken,
+			AccessTokenExpiry: accessTokenExpiry,
+		}
+
+		userId = linkingUserId
+
+		if userId == "" {
+			// Signing in with a new identity whose email already belongs to a user. Only link when
+			// that user proved they own the email, otherwise whoever registered it unverified
+			// would gain access to the provider's account holder.
+			existingUser, err := ph.AuthStore.GetUserByEmail(ctx, userJson.Email)
+
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				reqLogger.Error("Failed OAuth callback", slog.String("err", err.Error()))
+				apierrors.WriteJSON(w, r, err)
 				return
 			}
 
-			createAccErr = Login(r, ph.SessionManager, SessionData{
-				UserID: userId,
-			})
+			if err == nil {
+				if existingUser.EmailVerified == nil {
+					ph.recordFailure(ctx, provider.Name, "email_taken_unverified", existingUser.ID)
+					apierrors.WriteJSON(w, r, ErrEmailTaken)
+					return
+				}
+
+				userId = existingUser.ID
+			}
+		}
+
+		if userId != "" {
+			reqLogger.Info("Linking provider to existing user", slog.String("provider", provider.Name))
+			outcome = "linked"
+
+			err = ph.AuthStore.LinkAccount(ctx, userId, accountData)
 
-			if createAccErr != nil {
-				reqLogger.Error("Error logging in user", slog.String("err", createAccErr.Error()))
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			if errors.Is(err, ErrAccountAlreadyLinked) {
+				ph.recordFailure(ctx, provider.Name, "provider_already_linked", userId)
+				apierrors.WriteJSON(w, r, err)
 				return
 			}
+		} else {
+			reqLogger.Info("User not found, creating new account", slog.String("provider", provider.Name))
+			outcome = "created"
 
-			http.Redirect(w, r, ph.RedirectURI, http.StatusTemporaryRedirect)
+			userId, err = ph.AuthStore.CreateAccount(ctx, accountData)
+		}
+
+		if err != nil {
+			reqLogger.Error("Error creating account", slog.String("err", err.Error()))
+			apierrors.WriteJSON(w, r, err)
 			return
-		} else {
-			// Unexpected error
+		}
+	}
+
+	// Linking happens in a session that already passed the second factor
+	if linkingUserId == "" {
+		mfaEnabled, err := hasMFA(ctx, ph.AuthStore, userId)
+
+		if err != nil {
+			reqLogger.Error("Error checking mfa", slog.String("err", err.Error()))
+			apierrors.WriteJSON(w, r, err)
+			return
+		}
+
+		if mfaEnabled {
+			err = BeginMFA(r, ph.SessionManager, userId)
+
+			if err != nil {
+				reqLogger.Error("Error starting mfa", slog.String("err", err.Error()))
+				apierrors.WriteJSON(w, r, err)
+				return
+			}
+
+			recordAudit(ctx, ph.Audit, AuditEvent{Action: AuditMFAChallenged, TargetID: userId, Metadata: map[string]any{"provider": provider.Name}})
+
+			redirect := ph.MFARedirectURI
 
-			reqLogger.Error("Failed OAuth callback", slog.String("err", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			if redirect == "" {
+				redirect = ph.RedirectURI
+			}
+
+			http.Redirect(w, r, redirect, http.StatusTemporaryRedirect)
 			return
 		}
 	}
 
-	err = ph.AuthStore.UpdateAccountTokens(r.Context(), existingUser.UserId, "google", userJson.Sub, UpdateAccountTokensData{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		AccessTokenExpiry: sql.NullTime{
-			Time:  token.Expiry,
-			Valid: accessTokenExpiryValid,
-		},
+	err = Login(r, ph.SessionManager, SessionData{
+		UserID: userId,
 	})
 
 	if err != nil {
-		reqLogger.Error("Error updating account tokens", slog.String("err", err.Error()))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		reqLogger.Error("Error logging in user", slog.String("err", err.Error()))
+		apierrors.WriteJSON(w, r, err)
 		return
 	}
 
-	err = Login(r, ph.SessionManager, SessionData{
-		UserID: existingUser.UserId,
+	recordAudit(ctx, ph.Audit, AuditEvent{
+		ActorID:  userId,
+		Action:   AuditOAuthLogin,
+		Metadata: map[string]any{"provider": provider.Name, "account": outcome},
 	})
 
+	invitation := ph.SessionManager.PopString(ctx, SessionInvitationKey)
+
+	_, err = acceptInvitation(ctx, ph.Invitations, ph.SessionManager, userId, invitation)
+
 	if err != nil {
-		reqLogger.Error("Error logging in user", slog.String("err", err.Error()))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		reqLogger.Warn("Error accepting invitation", slog.String("err", err.Error()))
 	}
 
 	http.Redirect(w, r, ph.RedirectURI, http.StatusTemporaryRedirect)
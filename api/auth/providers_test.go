@@ -0,0 +1,637 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/internal"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIssuer serves a discovery document for an identity provider rooted at the returned server's URL
+func newTestIssuer(t *testing.T, mux *http.ServeMux) *httptest.Server {
+	issuer := httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                issuer.URL,
+			AuthorizationEndpoint: issuer.URL + "/authorize",
+			TokenEndpoint:         issuer.URL + "/token",
+			UserinfoEndpoint:      issuer.URL + "/userinfo",
+			JWKSURI:               issuer.URL + "/jwks",
+			ScopesSupported:       []string{"openid", "profile", "email", "offline_access"},
+		})
+	})
+
+	return issuer
+}
+
+func newTestProviderMux(ph *ProviderHandler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /auth/{provider}/{$}", ph.HandleAuth)
+	mux.HandleFunc("GET /auth/{provider}/callback/{$}", ph.HandleCallback)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return ph.SessionManager.LoadAndSave(internal.LoggingMiddleware(*logger)(mux))
+}
+
+func TestProviderRegistry(t *testing.T) {
+
+	t.Parallel()
+
+	issuer := newTestIssuer(t, http.NewServeMux())
+
+	registry := NewProviderRegistry()
+	require.NoError(t, registry.Register(OIDCProviderConfig{
+		Name:        "mock",
+		Issuer:      issuer.URL,
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost:8000/auth/mock/callback/",
+	}))
+
+	assert.Error(t, registry.Register(OIDCProviderConfig{Name: "incomplete"}))
+
+	handler := newTestProviderMux(&ProviderHandler{
+		SessionManager: NewMemorySessionManager(false, ""),
+		Providers:      registry,
+	})
+
+	t.Run("redirects to the discovered authorization endpoint", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/mock/", nil))
+
+		require.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		location, err := url.Parse(rec.Header().Get("Location"))
+		require.NoError(t, err)
+
+		assert.Equal(t, issuer.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+		assert.Equal(t, "client-id", location.Query().Get("client_id"))
+		assert.Equal(t, "openid profile email offline_access", location.Query().Get("scope"))
+		assert.Equal(t, "offline", location.Query().Get("access_type"))
+		assert.NotEmpty(t, location.Query().Get("state"))
+		assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	})
+
+	t.Run("unknown providers are not found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/unknown/", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("discovery rejects a mismatched issuer", func(t *testing.T) {
+		require.NoError(t, registry.Register(OIDCProviderConfig{
+			Name:     "impostor",
+			Issuer:   issuer.URL + "/other",
+			ClientID: "client-id",
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/impostor/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestProviderConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "keycloak")
+	t.Setenv("OIDC_KEYCLOAK_ISSUER", "http://localhost:8080/realms/dev")
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_ID", "simple-connect")
+	t.Setenv("OIDC_KEYCLOAK_SCOPES", "openid,email")
+	t.Setenv("GOOGLE_CLIENT_ID", "google-client")
+
+	configs := ProviderConfigsFromEnv()
+
+	require.Len(t, configs, 2)
+	assert.Equal(t, "keycloak", configs[0].Name)
+	assert.Equal(t, []string{"openid", "email"}, configs[0].Scopes)
+	assert.Equal(t, "google", configs[1].Name)
+	assert.Equal(t, GoogleIssuer, configs[1].Issuer)
+	assert.Equal(t, "google-client", configs[1].ClientID)
+}
+
+// memoryAccountStore implements the parts of AuthStore the OAuth callback uses
+type memoryAccountStore struct {
+	AuthStore
+
+	mu       sync.Mutex
+	users    map[string]*DBUser
+	accounts []UserAccount
+	// mfa holds the ids of users with a confirmed second factor
+	mfa map[string]bool
+}
+
+func newMemoryAccountStore(users ...*DBUser) *memoryAccountStore {
+	ms := &memoryAccountStore{users: map[string]*DBUser{}}
+
+	for _, u := range users {
+		ms.users[*u.Email] = u
+	}
+
+	return ms
+}
+
+func (ms *memoryAccountStore) GetUserByEmail(ctx context.Context, email string) (*DBUser, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[email]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (ms *memoryAccountStore) GetProviderAccount(ctx context.Context, provider string, providerId string) (UserAccount, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, a := range ms.accounts {
+		if a.Provider == provider && a.ProviderId == providerId {
+			return a, nil
+		}
+	}
+
+	return UserAccount{}, pgx.ErrNoRows
+}
+
+func (ms *memoryAccountStore) CreateAccount(ctx context.Context, data CreateAccountData) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	user := &DBUser{ID: "user-" + strconv.Itoa(len(ms.users)), Email: &data.Email, EmailVerified: &now}
+	ms.users[data.Email] = user
+	ms.accounts = append(ms.accounts, UserAccount{Email: data.Email, Provider: data.Provider, ProviderId: data.ProviderId, UserId: user.ID})
+
+	return user.ID, nil
+}
+
+func (ms *memoryAccountStore) LinkAccount(ctx context.Context, userId string, data CreateAccountData) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, a := range ms.accounts {
+		if a.Provider == data.Provider && a.UserId == userId {
+			return ErrAccountAlreadyLinked
+		}
+	}
+
+	ms.accounts = append(ms.accounts, UserAccount{Email: data.Email, Provider: data.Provider, ProviderId: data.ProviderId, UserId: userId})
+
+	return nil
+}
+
+func (ms *memoryAccountStore) UpdateAccountTokens(ctx context.Context, userId string, provider string, providerId string, data UpdateAccountTokensData) error {
+	return nil
+}
+
+func (ms *memoryAccountStore) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if !ms.mfa[userId] {
+		return nil, pgx.ErrNoRows
+	}
+
+	now := time.Now()
+
+	return &UserTOTP{UserID: userId, ConfirmedAt: &now}, nil
+}
+
+// testIdP stands in for an identity provider, signing id tokens with a key published on its JWKS endpoint
+type testIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu sync.Mutex
+	// idToken builds the id token returned by the token endpoint
+	idToken func(claims jwt.MapClaims) (string, error)
+	claims  jwt.MapClaims
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &testIdP{key: key}
+	idp.idToken = idp.sign
+
+	mux := http.NewServeMux()
+	idp.server = newTestIssuer(t, mux)
+
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []jsonWebKey{{
+				Kty: "RSA",
+				Kid: "test-key",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+
+		idToken, err := idp.idToken(idp.claims)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	mux.HandleFunc("GET /userinfo", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"sub":            idp.claims["sub"],
+			"email":          idp.claims["email"],
+			"email_verified": idp.claims["email_verified"],
+		})
+	})
+
+	return idp
+}
+
+func (idp *testIdP) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+
+	return token.SignedString(idp.key)
+}
+
+func newNoRedirectClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// signIn runs the authorization code flow against the app for a provider user with the given email.
+// claims and idToken optionally tamper with the id token the provider returns.
+func (idp *testIdP) signIn(t *testing.T, client *http.Client, appURL string, email string, claims func(jwt.MapClaims), idToken func(jwt.MapClaims) (string, error)) *http.Response {
+	res, err := client.Get(appURL + "/auth/mock/")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+
+	nonce := location.Query().Get("nonce")
+	require.NotEmpty(t, nonce)
+
+	idTokenClaims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            "client-id",
+		"sub":            email,
+		"email":          email,
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	if claims != nil {
+		claims(idTokenClaims)
+	}
+
+	idp.mu.Lock()
+	idp.claims = idTokenClaims
+	idp.idToken = idp.sign
+
+	if idToken != nil {
+		idp.idToken = idToken
+	}
+	idp.mu.Unlock()
+
+	res, err = client.Get(appURL + "/auth/mock/callback/?code=code&state=" + url.QueryEscape(location.Query().Get("state")))
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
+
+// errorReason decodes the reason of an apierrors.ErrorResponse body
+func errorReason(t *testing.T, res *http.Response) string {
+	var body apierrors.ErrorResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+	return body.Reason
+}
+
+func TestProviderCallbackVerifiesIDToken(t *testing.T) {
+
+	t.Parallel()
+
+	idp := newTestIdP(t)
+
+	registry := NewProviderRegistry()
+	require.NoError(t, registry.Register(OIDCProviderConfig{
+		Name:     "mock",
+		Issuer:   idp.server.URL,
+		ClientID: "client-id",
+	}))
+
+	store := newMemoryAccountStore()
+
+	app := httptest.NewServer(newTestProviderMux(&ProviderHandler{
+		RedirectURI:    "/home",
+		AuthStore:      store,
+		SessionManager: NewMemorySessionManager(false, ""),
+		Providers:      registry,
+	}))
+	defer app.Close()
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		email   string
+		claims  func(claims jwt.MapClaims)
+		idToken func(claims jwt.MapClaims) (string, error)
+		status  int
+	}{
+		{
+			name:   "valid id token creates the account",
+			email:  "valid@email.com",
+			status: http.StatusTemporaryRedirect,
+		},
+		{
+			name:   "email_verified may be a string",
+			email:  "string@email.com",
+			claims: func(claims jwt.MapClaims) { claims["email_verified"] = "true" },
+			status: http.StatusTemporaryRedirect,
+		},
+		{
+			name:   "unverified email is refused",
+			email:  "unverified@email.com",
+			claims: func(claims jwt.MapClaims) { claims["email_verified"] = false },
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "nonce must match the authorization request",
+			email:  "nonce@email.com",
+			claims: func(claims jwt.MapClaims) { claims["nonce"] = "replayed" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "audience must be our client",
+			email:  "audience@email.com",
+			claims: func(claims jwt.MapClaims) { claims["aud"] = "someone-else" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "issuer must be the provider",
+			email:  "issuer@email.com",
+			claims: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "expired tokens are refused",
+			email:  "expired@email.com",
+			claims: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:  "signature must come from the provider's keys",
+			email: "forged@email.com",
+			idToken: func(claims jwt.MapClaims) (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+				token.Header["kid"] = "test-key"
+				return token.SignedString(otherKey)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:  "unsigned tokens are refused",
+			email: "unsigned@email.com",
+			idToken: func(claims jwt.MapClaims) (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := idp.signIn(t, newNoRedirectClient(), app.URL, tt.email, tt.claims, tt.idToken)
+
+			assert.Equal(t, tt.status, res.StatusCode)
+
+			var expired []string
+
+			for _, cookie := range res.Cookies() {
+				if cookie.MaxAge < 0 {
+					expired = append(expired, cookie.Name)
+				}
+			}
+
+			assert.ElementsMatch(t, []string{OAUTH_STATE_SESSION_KEY, OAUTH_VERIFIER_SESSION_KEY, OAUTH_NONCE_SESSION_KEY}, expired)
+
+			_, err = store.GetProviderAccount(context.Background(), "mock", tt.email)
+
+			if tt.status == http.StatusTemporaryRedirect {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, pgx.ErrNoRows)
+			}
+		})
+	}
+}
+
+func TestProviderCallbackLinksAccounts(t *testing.T) {
+
+	t.Parallel()
+
+	idp := newTestIdP(t)
+
+	registry := NewProviderRegistry()
+	require.NoError(t, registry.Register(OIDCProviderConfig{
+		Name:     "mock",
+		Issuer:   idp.server.URL,
+		ClientID: "client-id",
+	}))
+
+	verified, unverified, linking, cased := "verified@email.com", "unverified@email.com", "linking@email.com", "cased@email.com"
+	now := time.Now()
+
+	store := newMemoryAccountStore(
+		&DBUser{ID: "password-user", Email: &verified, EmailVerified: &now},
+		&DBUser{ID: "cased-user", Email: &cased, EmailVerified: &now},
+		&DBUser{ID: "unverified-user", Email: &unverified},
+		&DBUser{ID: "linking-user", Email: &linking, EmailVerified: &now},
+	)
+
+	sessionManager := NewMemorySessionManager(false, "")
+	handler := &ProviderHandler{
+		RedirectURI:    "/home",
+		AuthStore:      store,
+		SessionManager: sessionManager,
+		Providers:      registry,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", newTestProviderMux(handler))
+	// Stands in for a logged in user calling LinkProvider
+	mux.Handle("/test-link", sessionManager.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Login(r, sessionManager, SessionData{UserID: "linking-user"})
+		sessionManager.Put(r.Context(), SessionLinkProviderKey, "mock")
+	})))
+
+	app := httptest.NewServer(mux)
+	defer app.Close()
+
+	t.Run("links to an existing verified user with the same email", func(t *testing.T) {
+		res := idp.signIn(t, newNoRedirectClient(), app.URL, verified, nil, nil)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+
+		account, err := store.GetProviderAccount(context.Background(), "mock", verified)
+		require.NoError(t, err)
+		assert.Equal(t, "password-user", account.UserId)
+	})
+
+	t.Run("provider emails are normalised", func(t *testing.T) {
+		res := idp.signIn(t, newNoRedirectClient(), app.URL, " Cased@Email.com", nil, nil)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+
+		account, err := store.GetProviderAccount(context.Background(), "mock", " Cased@Email.com")
+		require.NoError(t, err)
+		assert.Equal(t, "cased-user", account.UserId)
+	})
+
+	t.Run("refuses to link to an unverified user", func(t *testing.T) {
+		res := idp.signIn(t, newNoRedirectClient(), app.URL, unverified, nil, nil)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, apierrors.ReasonEmailTaken, errorReason(t, res))
+
+		_, err := store.GetProviderAccount(context.Background(), "mock", unverified)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("logged in users link a provider with a different email", func(t *testing.T) {
+		client := newNoRedirectClient()
+		res, err := client.Get(app.URL + "/test-link")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		res = idp.signIn(t, client, app.URL, "work@email.com", nil, nil)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+
+		account, err := store.GetProviderAccount(context.Background(), "mock", "work@email.com")
+		require.NoError(t, err)
+		assert.Equal(t, "linking-user", account.UserId)
+	})
+
+	t.Run("an identity linked to another user can't be linked again", func(t *testing.T) {
+		client := newNoRedirectClient()
+		res, err := client.Get(app.URL + "/test-link")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		res = idp.signIn(t, client, app.URL, verified, nil, nil)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, apierrors.ReasonAccountAlreadyLinked, errorReason(t, res))
+	})
+}
+
+func TestProviderCallbackRequiresMFA(t *testing.T) {
+
+	t.Parallel()
+
+	idp := newTestIdP(t)
+
+	registry := NewProviderRegistry()
+	require.NoError(t, registry.Register(OIDCProviderConfig{
+		Name:     "mock",
+		Issuer:   idp.server.URL,
+		ClientID: "client-id",
+	}))
+
+	email := "mfa@email.com"
+	now := time.Now()
+
+	store := newMemoryAccountStore(&DBUser{ID: "mfa-user", Email: &email, EmailVerified: &now})
+	store.mfa = map[string]bool{"mfa-user": true}
+
+	sessionManager := NewMemorySessionManager(false, "")
+
+	mux := http.NewServeMux()
+	mux.Handle("/", newTestProviderMux(&ProviderHandler{
+		RedirectURI:    "/home",
+		MFARedirectURI: "/mfa",
+		AuthStore:      store,
+		SessionManager: sessionManager,
+		Providers:      registry,
+	}))
+	mux.Handle("/test-session", sessionManager.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			SessionUserKey:       sessionManager.GetString(r.Context(), SessionUserKey),
+			SessionPendingMFAKey: sessionManager.GetString(r.Context(), SessionPendingMFAKey),
+		})
+	})))
+
+	app := httptest.NewServer(mux)
+	defer app.Close()
+
+	client := newNoRedirectClient()
+	res := idp.signIn(t, client, app.URL, email, nil, nil)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+	assert.Equal(t, "/mfa", res.Header.Get("Location"))
+
+	res, err := client.Get(app.URL + "/test-session")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var session map[string]string
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&session))
+
+	assert.Equal(t, map[string]string{SessionUserKey: "", SessionPendingMFAKey: "mfa-user"}, session)
+}
@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"context"
 	"encoding/gob"
 	"net/http"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/internal"
 	"time"
 
+	"connectrpc.com/connect"
 	"github.com/alexedwards/scs/pgxstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/alexedwards/scs/v2/memstore"
@@ -21,6 +25,16 @@ type SessionData struct {
 }
 
 const SessionUserKey = "user_id"
+const SessionUserAgentKey = "user_agent"
+const SessionIPKey = "ip_address"
+
+// SessionOrganizationKey holds the organization the user is working in. It is cleared on login.
+const SessionOrganizationKey = "organization_id"
+const SessionPendingMFAKey = "pending_mfa_user_id"
+const SessionPendingMFAExpiryKey = "pending_mfa_expiry"
+const SessionPendingMFAAttemptsKey = "pending_mfa_attempts"
+const PendingMFALifetime = 5 * time.Minute
+const MaxMFAAttempts = 5
 const AuthLifetime = 30 * 24 * time.Hour
 const SessionName = "__s_auth_sess"
 
@@ -43,9 +57,39 @@ func NewMemorySessionManager(secure bool, domain string) *scs.SessionManager {
 	return manager
 }
 
+// sessionStore is pgxstore that also fills the sessions table's user and device columns from the
+// session data, so sessions can be listed and revoked per user
+type sessionStore struct {
+	*pgxstore.PostgresStore
+	pool *pgxpool.Pool
+}
+
+func (ss *sessionStore) Commit(token string, b []byte, expiry time.Time) error {
+	return ss.CommitCtx(context.Background(), token, b, expiry)
+}
+
+func (ss *sessionStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	_, values, err := scs.GobCodec{}.Decode(b)
+
+	if err != nil {
+		return err
+	}
+
+	userId, _ := values[SessionUserKey].(string)
+	userAgent, _ := values[SessionUserAgentKey].(string)
+	ip, _ := values[SessionIPKey].(string)
+
+	_, err = ss.pool.Exec(ctx,
+		`INSERT INTO sessions (token, id, data, expiry, user_id, user_agent, ip_address) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+			ON CONFLICT (token) DO UPDATE SET data = EXCLUDED.data, expiry = EXCLUDED.expiry, user_id = EXCLUDED.user_id, user_agent = EXCLUDED.user_agent, ip_address = EXCLUDED.ip_address`,
+		token, HashToken(token), b, expiry, userId, userAgent, ip)
+
+	return err
+}
+
 func NewSessionManager(secure bool, domain string, pool *pgxpool.Pool) *scs.SessionManager {
 	manager := scs.New()
-	manager.Store = pgxstore.New(pool)
+	manager.Store = &sessionStore{PostgresStore: pgxstore.New(pool), pool: pool}
 
 	manager.Lifetime = AuthLifetime
 	manager.Cookie.Name = SessionName
@@ -63,18 +107,79 @@ func NewSessionManager(secure bool, domain string, pool *pgxpool.Pool) *scs.Sess
 }
 
 func Login(r *http.Request, sessionManager *scs.SessionManager, data SessionData) error {
-	err := sessionManager.RenewToken(r.Context())
+	return LoginContext(r.Context(), sessionManager, data)
+}
+
+// LoginContext is Login for callers that only have the request context, such as Connect handlers
+func LoginContext(ctx context.Context, sessionManager *scs.SessionManager, data SessionData) error {
+	err := sessionManager.RenewToken(ctx)
+	if err != nil {
+		return err
+	}
+	clearPendingMFA(ctx, sessionManager)
+	sessionManager.Remove(ctx, SessionOrganizationKey)
+	sessionManager.Put(ctx, SessionUserKey, data.UserID)
+
+	client := internal.ClientInfoFromContext(ctx)
+	sessionManager.Put(ctx, SessionUserAgentKey, client.UserAgent)
+	sessionManager.Put(ctx, SessionIPKey, client.IP)
+	return nil
+}
+
+// BeginMFA marks the session as having passed the first factor for userId without logging them in.
+// The login is completed by LoginContext once the second factor is verified.
+func BeginMFA(r *http.Request, sessionManager *scs.SessionManager, userId string) error {
+	return BeginMFAContext(r.Context(), sessionManager, userId)
+}
+
+// BeginMFAContext is BeginMFA for callers that only have the request context, such as Connect handlers
+func BeginMFAContext(ctx context.Context, sessionManager *scs.SessionManager, userId string) error {
+	err := sessionManager.RenewToken(ctx)
 	if err != nil {
 		return err
 	}
-	sessionManager.Put(r.Context(), SessionUserKey, data.UserID)
+	sessionManager.Put(ctx, SessionPendingMFAKey, userId)
+	sessionManager.Put(ctx, SessionPendingMFAExpiryKey, time.Now().Add(PendingMFALifetime))
+	sessionManager.Put(ctx, SessionPendingMFAAttemptsKey, 0)
 	return nil
 }
 
-func Logout(r *http.Request, sessionManager *scs.SessionManager) {
-	sessionManager.Destroy(r.Context())
+// PendingMFAUser returns the user waiting on a second factor, or "" if there is none or it expired
+func PendingMFAUser(ctx context.Context, sessionManager *scs.SessionManager) string {
+	userId := sessionManager.GetString(ctx, SessionPendingMFAKey)
+
+	if userId == "" {
+		return ""
+	}
+
+	if time.Now().After(sessionManager.GetTime(ctx, SessionPendingMFAExpiryKey)) {
+		clearPendingMFA(ctx, sessionManager)
+		return ""
+	}
+
+	return userId
+}
+
+// recordMFAFailure counts a failed second factor and abandons the pending login after MaxMFAAttempts
+func recordMFAFailure(ctx context.Context, sessionManager *scs.SessionManager) {
+	attempts := sessionManager.GetInt(ctx, SessionPendingMFAAttemptsKey) + 1
+
+	if attempts >= MaxMFAAttempts {
+		clearPendingMFA(ctx, sessionManager)
+		return
+	}
+
+	sessionManager.Put(ctx, SessionPendingMFAAttemptsKey, attempts)
+}
+
+func clearPendingMFA(ctx context.Context, sessionManager *scs.SessionManager) {
+	sessionManager.Remove(ctx, SessionPendingMFAKey)
+	sessionManager.Remove(ctx, SessionPendingMFAExpiryKey)
+	sessionManager.Remove(ctx, SessionPendingMFAAttemptsKey)
 }
 
+// RequireAuthMiddleWare rejects requests without a logged in session and makes the session's user the
+// request's principal. Connect handlers use AuthInterceptor instead.
 func RequireAuthMiddleWare(sessionManager *scs.SessionManager) alice.Constructor {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -82,11 +187,12 @@ func RequireAuthMiddleWare(sessionManager *scs.SessionManager) alice.Constructor
 			userId := sessionManager.GetString(r.Context(), SessionUserKey)
 
 			if userId == "" {
-				w.WriteHeader(http.StatusUnauthorized)
+				apierrors.WriteJSON(w, r, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated))
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx := ContextWithPrincipal(r.Context(), &Principal{UserID: userId, Kind: PrincipalSession})
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/httputils"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAccountLocked = apierrors.New(connect.CodeResourceExhausted, apierrors.ReasonAccountLocked, "account is temporarily locked")
+var ErrTooManyAttempts = apierrors.New(connect.CodeResourceExhausted, apierrors.ReasonTooManyAttempts, "too many sign in attempts, try again later")
+
+// ThrottleError rejects a login attempt made too soon, RetryAfter is when the next one is allowed
+type ThrottleError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return e.Err
+}
+
+type ThrottleConfig struct {
+	// Window is how long failures are remembered, per account and per client address
+	Window time.Duration
+	// FreeAttempts failures are allowed before attempts are delayed
+	FreeAttempts int
+	// BaseDelay is the first delay, doubling with each further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAttempts failures lock the account for LockoutDuration
+	LockoutAttempts int
+	LockoutDuration time.Duration
+	// MaxFailuresPerIP is how many failures a client address may have within Window, across all accounts
+	MaxFailuresPerIP int
+}
+
+var DefaultThrottleConfig = ThrottleConfig{
+	Window:           15 * time.Minute,
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Minute,
+	LockoutAttempts:  10,
+	LockoutDuration:  30 * time.Minute,
+	MaxFailuresPerIP: 50,
+}
+
+type LoginThrottleState struct {
+	Failures     int        `db:"failures"`
+	LastFailedAt time.Time  `db:"last_failed_at"`
+	LockedUntil  *time.Time `db:"locked_until"`
+}
+
+// LoginThrottleStore persists failures so limits hold across replicas. Keys are normalised emails.
+type LoginThrottleStore interface {
+	// GetLoginThrottle returns pgx.ErrNoRows when the key has no failures
+	GetLoginThrottle(ctx context.Context, key string) (*LoginThrottleState, error)
+	// ClaimLoginAttempt holds the key and ip locked while claim decides on the key's state, zero when it
+	// has none, and the ip's failures since resetBefore. Unless claim returns an error the state it left is
+	// saved and a failure of ip at at is recorded, both visible to the next claim once this one returns.
+	ClaimLoginAttempt(ctx context.Context, key string, ip string, at time.Time, resetBefore time.Time, claim func(state *LoginThrottleState, ipFailures int) error) error
+	ClearLoginFailures(ctx context.Context, key string) (bool, error)
+	// DeleteLoginIPFailure drops the failure of ip recorded at at
+	DeleteLoginIPFailure(ctx context.Context, ip string, at time.Time) error
+	// PruneLoginThrottles deletes keys without failures since resetBefore nor a lock past at, and failures
+	// of any ip before resetBefore
+	PruneLoginThrottles(ctx context.Context, at time.Time, resetBefore time.Time) error
+}
+
+// throttlePruneInterval is how often a LoginThrottle deletes failures older than its window
+const throttlePruneInterval = 10 * time.Minute
+
+// LoginThrottle limits password and second factor attempts with delays growing per failure, temporary account lockouts
+// and a sliding window per client address
+type LoginThrottle struct {
+	store  LoginThrottleStore
+	config ThrottleConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// LoginAttempt is an attempt claimed by LoginThrottle.Check. It counts as a failure until Success.
+type LoginAttempt struct {
+	key string
+	ip  string
+	at  time.Time
+	// Locked is whether the attempt locked the account, should it fail
+	Locked bool
+}
+
+func NewLoginThrottle(store LoginThrottleStore, config ThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{store: store, config: config, now: time.Now}
+}
+
+func throttleKey(email string) string {
+	return normalizeEmail(email)
+}
+
+func (lt *LoginThrottle) delay(failures int) time.Duration {
+	if failures < lt.config.FreeAttempts {
+		return 0
+	}
+
+	delay := lt.config.BaseDelay
+
+	for range failures - lt.config.FreeAttempts {
+		if delay >= lt.config.MaxDelay {
+			break
+		}
+
+		delay *= 2
+	}
+
+	return min(delay, lt.config.MaxDelay)
+}
+
+// Check claims an attempt to log in as email from ip, it returns a *ThrottleError when the attempt must
+// not be made yet. The attempt is counted as a failure straight away, so concurrent attempts can't all
+// pass before any has failed.
+func (lt *LoginThrottle) Check(ctx context.Context, email string, ip string) (*LoginAttempt, error) {
+	now := lt.now()
+	resetBefore := now.Add(-lt.config.Window)
+	attempt := &LoginAttempt{key: throttleKey(email), ip: ip, at: now}
+
+	err := lt.prune(ctx, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = lt.store.ClaimLoginAttempt(ctx, attempt.key, ip, now, resetBefore, func(state *LoginThrottleState, ipFailures int) error {
+		if ip != "" && lt.config.MaxFailuresPerIP > 0 && ipFailures >= lt.config.MaxFailuresPerIP {
+			return &ThrottleError{Err: ErrTooManyAttempts, RetryAfter: lt.config.Window}
+		}
+
+		if state.LockedUntil != nil && now.Before(*state.LockedUntil) {
+			return &ThrottleError{Err: ErrAccountLocked, RetryAfter: state.LockedUntil.Sub(now)}
+		}
+
+		// The count restarts once the failures are outside the window or the lock has expired
+		if state.LockedUntil != nil || state.LastFailedAt.Before(resetBefore) {
+			state.Failures = 0
+			state.LockedUntil = nil
+		}
+
+		next := state.LastFailedAt.Add(lt.delay(state.Failures))
+
+		if state.Failures > 0 && now.Before(next) {
+			return &ThrottleError{Err: ErrTooManyAttempts, RetryAfter: next.Sub(now)}
+		}
+
+		state.Failures++
+		state.LastFailedAt = now
+
+		if lt.config.LockoutAttempts > 0 && state.Failures >= lt.config.LockoutAttempts {
+			until := now.Add(lt.config.LockoutDuration)
+			state.LockedUntil = &until
+			attempt.Locked = true
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+// Success forgets the account's failures and takes the attempt off the client address's count
+func (lt *LoginThrottle) Success(ctx context.Context, attempt *LoginAttempt) error {
+	_, err := lt.store.ClearLoginFailures(ctx, attempt.key)
+
+	if err != nil || attempt.ip == "" {
+		return err
+	}
+
+	return lt.store.DeleteLoginIPFailure(ctx, attempt.ip, attempt.at)
+}
+
+// Pending takes an attempt whose password was right off the client address's count. The account keeps
+// it as a failure until Success, once the second factor has passed.
+func (lt *LoginThrottle) Pending(ctx context.Context, attempt *LoginAttempt) error {
+	if attempt.ip == "" {
+		return nil
+	}
+
+	return lt.store.DeleteLoginIPFailure(ctx, attempt.ip, attempt.at)
+}
+
+// prune deletes expired failures and locks at most once per throttlePruneInterval
+func (lt *LoginThrottle) prune(ctx context.Context, now time.Time) error {
+	lt.mu.Lock()
+
+	if now.Sub(lt.lastPrune) < throttlePruneInterval {
+		lt.mu.Unlock()
+		return nil
+	}
+
+	lt.lastPrune = now
+	lt.mu.Unlock()
+
+	return lt.store.PruneLoginThrottles(ctx, now, now.Add(-lt.config.Window))
+}
+
+// Unlock lifts a lockout and forgets the account's failures, it reports whether there was anything to clear
+func (lt *LoginThrottle) Unlock(ctx context.Context, email string) (bool, error) {
+	return lt.store.ClearLoginFailures(ctx, throttleKey(email))
+}
+
+// State returns the account's failures and lock, nil when there are none
+func (lt *LoginThrottle) State(ctx context.Context, email string) (*LoginThrottleState, error) {
+	state, err := lt.store.GetLoginThrottle(ctx, throttleKey(email))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	return state, err
+}
+
+func (e *ThrottleError) retryAfterSeconds() string {
+	return strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// ConnectError is a ResourceExhausted error with Retry-After in its metadata, the error still
+// unwraps to ErrAccountLocked or ErrTooManyAttempts
+func (e *ThrottleError) ConnectError() *connect.Error {
+	connectErr := connect.NewError(connect.CodeResourceExhausted, e)
+	connectErr.Meta().Set("Retry-After", e.retryAfterSeconds())
+
+	return connectErr
+}
+
+// writeThrottled responds 423 Locked to locked accounts and 429 Too Many Requests otherwise
+func writeThrottled(w http.ResponseWriter, r *http.Request, err *ThrottleError) {
+	w.Header().Set("Retry-After", err.retryAfterSeconds())
+
+	status, res := apierrors.NewErrorResponse(r.Context(), err)
+
+	if errors.Is(err, ErrAccountLocked) {
+		status = http.StatusLocked
+	}
+
+	httputils.WriteJSONStatus(w, status, res)
+}
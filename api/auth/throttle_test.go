@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"simple-connect/api/data/gen/gopg/public/model"
+	"simple-connect/api/internal"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryThrottleStore struct {
+	mu         sync.Mutex
+	states     map[string]*LoginThrottleState
+	ipFailures map[string][]time.Time
+}
+
+func newMemoryThrottleStore() *memoryThrottleStore {
+	return &memoryThrottleStore{states: map[string]*LoginThrottleState{}, ipFailures: map[string][]time.Time{}}
+}
+
+func (ms *memoryThrottleStore) GetLoginThrottle(ctx context.Context, key string) (*LoginThrottleState, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	state, ok := ms.states[key]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	copied := *state
+	return &copied, nil
+}
+
+func (ms *memoryThrottleStore) ClaimLoginAttempt(ctx context.Context, key string, ip string, at time.Time, resetBefore time.Time, claim func(state *LoginThrottleState, ipFailures int) error) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	state := &LoginThrottleState{}
+
+	if stored, ok := ms.states[key]; ok {
+		copied := *stored
+		state = &copied
+	}
+
+	ipFailures := 0
+
+	for _, failedAt := range ms.ipFailures[ip] {
+		if !failedAt.Before(resetBefore) {
+			ipFailures++
+		}
+	}
+
+	err := claim(state, ipFailures)
+
+	if err != nil {
+		return err
+	}
+
+	ms.states[key] = state
+
+	if ip != "" {
+		ms.ipFailures[ip] = append(ms.ipFailures[ip], at)
+	}
+
+	return nil
+}
+
+func (ms *memoryThrottleStore) ClearLoginFailures(ctx context.Context, key string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	_, ok := ms.states[key]
+	delete(ms.states, key)
+	return ok, nil
+}
+
+func (ms *memoryThrottleStore) DeleteLoginIPFailure(ctx context.Context, ip string, at time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, failedAt := range ms.ipFailures[ip] {
+		if failedAt.Equal(at) {
+			ms.ipFailures[ip] = slices.Delete(ms.ipFailures[ip], i, i+1)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (ms *memoryThrottleStore) PruneLoginThrottles(ctx context.Context, at time.Time, resetBefore time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for key, state := range ms.states {
+		if state.LastFailedAt.Before(resetBefore) && (state.LockedUntil == nil || !state.LockedUntil.After(at)) {
+			delete(ms.states, key)
+		}
+	}
+
+	for ip, failures := range ms.ipFailures {
+		ms.ipFailures[ip] = slices.DeleteFunc(failures, func(failedAt time.Time) bool { return failedAt.Before(resetBefore) })
+	}
+
+	return nil
+}
+
+func TestLoginThrottle(t *testing.T) {
+
+	t.Parallel()
+
+	ctx := context.Background()
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	newThrottle := func() (*LoginThrottle, *memoryThrottleStore, *time.Time) {
+		store := newMemoryThrottleStore()
+		now := start
+		throttle := NewLoginThrottle(store, DefaultThrottleConfig)
+		throttle.now = func() time.Time { return now }
+		return throttle, store, &now
+	}
+
+	t.Run("delays grow after the free attempts", func(t *testing.T) {
+		throttle, _, now := newThrottle()
+
+		for range DefaultThrottleConfig.FreeAttempts {
+			_, err := throttle.Check(ctx, "user@example.com", "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		_, err := throttle.Check(ctx, "USER@example.com", "10.0.0.2")
+		var throttleErr *ThrottleError
+		require.ErrorAs(t, err, &throttleErr)
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		assert.Equal(t, time.Second, throttleErr.RetryAfter)
+
+		*now = now.Add(time.Second)
+		attempt, err := throttle.Check(ctx, "user@example.com", "10.0.0.2")
+		require.NoError(t, err)
+
+		_, err = throttle.Check(ctx, "user@example.com", "10.0.0.2")
+		require.ErrorAs(t, err, &throttleErr)
+		assert.Equal(t, 2*time.Second, throttleErr.RetryAfter)
+
+		require.NoError(t, throttle.Success(ctx, attempt))
+		_, err = throttle.Check(ctx, "user@example.com", "10.0.0.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("concurrent attempts count before they fail", func(t *testing.T) {
+		throttle, _, _ := newThrottle()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+
+		for range 20 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := throttle.Check(ctx, "user@example.com", "10.0.0.1")
+
+				if err == nil {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+		assert.Equal(t, DefaultThrottleConfig.FreeAttempts, allowed)
+	})
+
+	t.Run("accounts lock until unlocked", func(t *testing.T) {
+		throttle, _, now := newThrottle()
+
+		for range DefaultThrottleConfig.LockoutAttempts - 1 {
+			attempt, err := throttle.Check(ctx, "user@example.com", "10.0.0.1")
+			require.NoError(t, err)
+			assert.False(t, attempt.Locked)
+			*now = now.Add(DefaultThrottleConfig.MaxDelay)
+		}
+
+		attempt, err := throttle.Check(ctx, "user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, attempt.Locked)
+
+		_, err = throttle.Check(ctx, "user@example.com", "10.0.0.2")
+		assert.ErrorIs(t, err, ErrAccountLocked)
+
+		unlocked, err := throttle.Unlock(ctx, "user@example.com")
+		require.NoError(t, err)
+		assert.True(t, unlocked)
+		_, err = throttle.Check(ctx, "user@example.com", "10.0.0.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("addresses are limited across accounts", func(t *testing.T) {
+		throttle, _, _ := newThrottle()
+
+		attempt, err := throttle.Check(ctx, "success@example.com", "10.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, throttle.Success(ctx, attempt))
+
+		for i := range DefaultThrottleConfig.MaxFailuresPerIP {
+			_, err := throttle.Check(ctx, "user"+strconv.Itoa(i)+"@example.com", "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		_, err = throttle.Check(ctx, "fresh@example.com", "10.0.0.1")
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		_, err = throttle.Check(ctx, "fresh@example.com", "10.0.0.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired failures are pruned", func(t *testing.T) {
+		throttle, store, now := newThrottle()
+
+		_, err := throttle.Check(ctx, "old@example.com", "10.0.0.1")
+		require.NoError(t, err)
+
+		*now = now.Add(DefaultThrottleConfig.Window + throttlePruneInterval)
+		_, err = throttle.Check(ctx, "new@example.com", "10.0.0.2")
+		require.NoError(t, err)
+
+		assert.NotContains(t, store.states, "old@example.com")
+		assert.Empty(t, store.ipFailures["10.0.0.1"])
+	})
+
+	t.Run("locked and throttled responses differ", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login/", nil)
+		rec := httptest.NewRecorder()
+		writeThrottled(rec, req, &ThrottleError{Err: ErrAccountLocked, RetryAfter: 1500 * time.Millisecond})
+		assert.Equal(t, http.StatusLocked, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+		rec = httptest.NewRecorder()
+		writeThrottled(rec, req, &ThrottleError{Err: ErrTooManyAttempts, RetryAfter: time.Second})
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	})
+}
+
+// memoryMFAStore gives every user a confirmed second factor, "good" is the only recovery code accepted
+type memoryMFAStore struct {
+	memoryUserStore
+}
+
+func (ms *memoryMFAStore) GetTOTP(ctx context.Context, userId string) (*UserTOTP, error) {
+	confirmedAt := time.Now()
+	return &UserTOTP{UserID: userId, ConfirmedAt: &confirmedAt}, nil
+}
+
+func (ms *memoryMFAStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+	return codeHash == hashRecoveryCode("good"), nil
+}
+
+func (ms *memoryMFAStore) GetUserByID(ctx context.Context, id string) (*model.Users, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, user := range ms.users {
+		if user.ID == id {
+			return &model.Users{ID: user.ID, Email: user.Email}, nil
+		}
+	}
+
+	return nil, pgx.ErrNoRows
+}
+
+func TestLoginThrottleSecondFactor(t *testing.T) {
+
+	t.Parallel()
+
+	store := &memoryMFAStore{memoryUserStore{users: map[string]*DBUser{}}}
+	sessionManager := NewMemorySessionManager(false, "")
+	throttleStore := newMemoryThrottleStore()
+	throttle := NewLoginThrottle(throttleStore, ThrottleConfig{Window: time.Hour, FreeAttempts: 3, LockoutAttempts: 4, LockoutDuration: time.Hour})
+	handler := NewAuthHandler(store, sessionManager, nil, nil, nil, nil, throttle, DefaultPasswordPolicy, nil, nil)
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	}, internal.ClientInfoMiddleware(false))
+	server.Mux.Handle(apiv1connect.NewAuthServiceHandler(handler))
+
+	ctx := context.Background()
+	const password = "correct horse battery 9"
+
+	for _, email := range []string{"jane@example.com", "john@example.com"} {
+		_, err := store.CreateUser(ctx, email, password)
+		require.NoError(t, err)
+	}
+
+	login := func(client apiv1connect.AuthServiceClient, email string, recoveryCode string) error {
+		res, err := client.Login(ctx, connect.NewRequest(&v1.LoginRequest{Email: email, Password: password}))
+
+		if err != nil {
+			return err
+		}
+
+		require.True(t, res.Msg.MfaRequired)
+		_, err = client.VerifyMFA(ctx, connect.NewRequest(&v1.VerifyMFARequest{RecoveryCode: recoveryCode}))
+
+		return err
+	}
+
+	t.Run("failed codes lock the account despite the right password", func(t *testing.T) {
+		client := apiv1connect.NewAuthServiceClient(server.NewClient(), server.URL)
+
+		for range 2 {
+			err := login(client, "jane@example.com", "wrong")
+			assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+		}
+
+		err := login(client, "jane@example.com", "good")
+		assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+		assert.ErrorContains(t, err, ErrAccountLocked.Error())
+	})
+
+	t.Run("a passed second factor clears the failures", func(t *testing.T) {
+		client := apiv1connect.NewAuthServiceClient(server.NewClient(), server.URL)
+
+		require.NoError(t, login(client, "john@example.com", "good"))
+
+		state, err := throttle.State(ctx, "john@example.com")
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"simple-connect/api/apierrors"
+
+	"connectrpc.com/connect"
+)
+
+var ErrInvalidToken = apierrors.New(connect.CodeInvalidArgument, apierrors.ReasonInvalidToken, "invalid or expired token")
+
+// GenerateToken returns a random url-safe token to hand to the user. Only its hash is persisted.
+func GenerateToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, tokenBytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// HashToken is how tokens are looked up once issued
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
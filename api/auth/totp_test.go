@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP(t *testing.T) {
+
+	t.Parallel()
+
+	// Test vectors from RFC 6238 appendix B, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	t.Run("validateTOTP accepts RFC vectors", func(t *testing.T) {
+		for ts, code := range vectors {
+			step, ok := validateTOTP(secret, code, time.Unix(ts, 0))
+
+			assert.True(t, ok, "code for %d", ts)
+			assert.Equal(t, ts/totpPeriod, step)
+		}
+	})
+
+	t.Run("validateTOTP allows one step of clock skew", func(t *testing.T) {
+		_, ok := validateTOTP(secret, "287082", time.Unix(59+totpPeriod, 0))
+		assert.True(t, ok)
+
+		_, ok = validateTOTP(secret, "287082", time.Unix(59+3*totpPeriod, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("validateTOTP rejects malformed input", func(t *testing.T) {
+		_, ok := validateTOTP(secret, "28708", time.Unix(59, 0))
+		assert.False(t, ok)
+
+		_, ok = validateTOTP("not base32!", "287082", time.Unix(59, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("recovery codes hash independently of formatting", func(t *testing.T) {
+		codes, err := generateRecoveryCodes()
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.Len(t, codes[0], 11)
+
+		assert.Equal(t, hashRecoveryCode("abcde-fghij"), hashRecoveryCode(" ABCDEFGHIJ "))
+	})
+}
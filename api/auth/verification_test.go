@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/data/gen/gopg/public/model"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type verificationToken struct {
+	userId    string
+	expiresAt time.Time
+	used      bool
+}
+
+type memoryVerificationStore struct {
+	AuthStore
+	mu     sync.Mutex
+	users  map[string]*model.Users
+	tokens map[string]*verificationToken
+}
+
+func (ms *memoryVerificationStore) GetUserByID(ctx context.Context, id string) (*model.Users, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user := *ms.users[id]
+	return &user, nil
+}
+
+func (ms *memoryVerificationStore) CreateEmailVerificationToken(ctx context.Context, userId string, tokenHash string, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.tokens[tokenHash] = &verificationToken{userId: userId, expiresAt: expiresAt}
+	return nil
+}
+
+func (ms *memoryVerificationStore) VerifyEmail(ctx context.Context, tokenHash string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.tokens[tokenHash]
+
+	if !ok || token.used || token.expiresAt.Before(time.Now()) {
+		return "", ErrInvalidToken
+	}
+
+	token.used = true
+	now := time.Now()
+	ms.users[token.userId].EmailVerified = &now
+
+	return token.userId, nil
+}
+
+func TestEmailVerification(t *testing.T) {
+
+	t.Parallel()
+
+	email := "unverified@example.com"
+	verifiedAt := time.Now()
+	store := &memoryVerificationStore{
+		users: map[string]*model.Users{
+			"unverified": {ID: "unverified", Email: &email},
+			"verified":   {ID: "verified", Email: &email, EmailVerified: &verifiedAt},
+		},
+		tokens: map[string]*verificationToken{},
+	}
+	sessionManager := NewMemorySessionManager(false, "")
+	mailer := &outbox{}
+	verifier := NewEmailVerifier(store, mailer, "https://app.test/verify-email")
+
+	interceptors := connect.WithInterceptors(
+		apierrors.NewInterceptor(),
+		NewAuthInterceptor(sessionManager, nil),
+		NewRequireVerifiedInterceptor(store, apiv1connect.ProtectedAuthServiceMeProcedure),
+	)
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return Login(r, sessionManager, SessionData{UserID: userId})
+	})
+	server.Mux.Handle(apiv1connect.NewAuthServiceHandler(NewAuthHandler(store, sessionManager, verifier, nil, nil, nil, nil, DefaultPasswordPolicy, nil, nil), interceptors))
+	server.Mux.Handle(apiv1connect.NewProtectedAuthServiceHandler(NewProtectedAuthHandler(store, sessionManager, verifier, NewProviderRegistry(), nil), interceptors))
+
+	public := apiv1connect.NewAuthServiceClient(server.NewClient(), server.URL)
+	unverified := apiv1connect.NewProtectedAuthServiceClient(server.ClientFor("unverified"), server.URL)
+	verified := apiv1connect.NewProtectedAuthServiceClient(server.ClientFor("verified"), server.URL)
+	ctx := context.Background()
+
+	verify := func(token string) error {
+		_, err := public.VerifyEmail(ctx, connect.NewRequest(&v1.VerifyEmailRequest{Token: token}))
+		return err
+	}
+
+	t.Run("expired tokens are refused", func(t *testing.T) {
+		token, err := GenerateToken()
+		require.NoError(t, err)
+		require.NoError(t, store.CreateEmailVerificationToken(ctx, "unverified", HashToken(token), time.Now().Add(-time.Minute)))
+
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(verify(token)))
+	})
+
+	t.Run("unverified users can't call guarded procedures", func(t *testing.T) {
+		_, err := unverified.Me(ctx, connect.NewRequest(&v1.MeRequest{}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+		assert.Equal(t, apierrors.ReasonEmailNotVerified, apierrors.ReasonOf(err))
+
+		_, err = verified.Me(ctx, connect.NewRequest(&v1.MeRequest{}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("resent links verify once", func(t *testing.T) {
+		_, err := unverified.ResendVerificationEmail(ctx, connect.NewRequest(&v1.ResendVerificationEmailRequest{}))
+		require.NoError(t, err)
+
+		mailer.mu.Lock()
+		msg := mailer.messages[len(mailer.messages)-1]
+		mailer.mu.Unlock()
+
+		start := strings.Index(msg.Body, "https://app.test/verify-email?token=")
+		require.NotEqual(t, -1, start)
+		link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+
+		require.NoError(t, verify(token))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(verify(token)))
+
+		_, err = unverified.Me(ctx, connect.NewRequest(&v1.MeRequest{}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("verified users can't resend", func(t *testing.T) {
+		_, err := verified.ResendVerificationEmail(ctx, connect.NewRequest(&v1.ResendVerificationEmailRequest{}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+}
@@ -0,0 +1,65 @@
+// Package authz implements role based access control. Permissions are granted to roles, roles
+// are granted to users, and Connect procedures declare the permission they need with the
+// (proto.api.v1.permission) method option, which Interceptor enforces.
+package authz
+
+import (
+	"context"
+	"simple-connect/api/apierrors"
+	"slices"
+
+	"connectrpc.com/connect"
+)
+
+var ErrPermissionDenied = apierrors.New(connect.CodePermissionDenied, apierrors.ReasonPermissionDenied, "permission denied")
+var ErrUnknownRole = apierrors.New(connect.CodeNotFound, apierrors.ReasonUnknownRole, "unknown role")
+var ErrUnknownUser = apierrors.New(connect.CodeNotFound, apierrors.ReasonUnknownUser, "unknown user")
+
+type Role struct {
+	ID          string   `db:"id"`
+	Name        string   `db:"name"`
+	Description string   `db:"description"`
+	Permissions []string `db:"permissions"`
+}
+
+type Store interface {
+	UserPermissions(ctx context.Context, userId string) ([]string, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListUserRoles(ctx context.Context, userId string) ([]string, error)
+	AssignRole(ctx context.Context, userId string, role string) error
+	RevokeRole(ctx context.Context, userId string, role string) (bool, error)
+}
+
+// Authorizer answers permission checks for users
+type Authorizer struct {
+	store Store
+}
+
+func NewAuthorizer(store Store) *Authorizer {
+	return &Authorizer{store: store}
+}
+
+func (a *Authorizer) HasPermission(ctx context.Context, userId string, permission string) (bool, error) {
+	permissions, err := a.store.UserPermissions(ctx, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
+
+// Require returns ErrPermissionDenied unless the user's roles grant permission
+func (a *Authorizer) Require(ctx context.Context, userId string, permission string) error {
+	ok, err := a.HasPermission(ctx, userId, permission)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// foreignKeyViolation is the postgres error code for foreign key constraint violations
+const foreignKeyViolation = "23503"
+
+type AuthorizationService struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuthorizationService(pool *pgxpool.Pool) *AuthorizationService {
+	return &AuthorizationService{pool: pool}
+}
+
+func (as *AuthorizationService) UserPermissions(ctx context.Context, userId string) ([]string, error) {
+
+	rows, err := as.pool.Query(ctx,
+		`SELECT DISTINCT p.name FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name`,
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (as *AuthorizationService) ListRoles(ctx context.Context) ([]Role, error) {
+
+	rows, err := as.pool.Query(ctx,
+		`SELECT r.id, r.name, r.description,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}') AS permissions
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id
+		ORDER BY r.name`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Role])
+}
+
+func (as *AuthorizationService) ListUserRoles(ctx context.Context, userId string) ([]string, error) {
+
+	rows, err := as.pool.Query(ctx,
+		"SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = $1 ORDER BY r.name",
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// AssignRole grants the named role to the user, granting it twice is a no-op. Returns ErrUnknownRole
+// when no role has that name and ErrUnknownUser when the user doesn't exist.
+func (as *AuthorizationService) AssignRole(ctx context.Context, userId string, role string) error {
+
+	// DO UPDATE rather than DO NOTHING so an existing grant still counts as an affected row
+	res, err := as.pool.Exec(ctx,
+		`INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = $2
+		ON CONFLICT (user_id, role_id) DO UPDATE SET user_id = EXCLUDED.user_id`,
+		userId, role)
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrUnknownUser
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrUnknownRole
+	}
+
+	return nil
+}
+
+func (as *AuthorizationService) RevokeRole(ctx context.Context, userId string, role string) (bool, error) {
+
+	res, err := as.pool.Exec(ctx,
+		"DELETE FROM user_roles WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)",
+		userId, role)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
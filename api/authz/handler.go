@@ -0,0 +1,102 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"simple-connect/api/auth"
+	v1 "simple-connect/gen/proto/api/v1"
+
+	"connectrpc.com/connect"
+)
+
+// Handler serves AuthorizationService. Permission checks happen in Interceptor.
+type Handler struct {
+	store Store
+}
+
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) ListMyPermissions(ctx context.Context, req *connect.Request[v1.ListMyPermissionsRequest]) (*connect.Response[v1.ListMyPermissionsResponse], error) {
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	permissions, err := h.store.UserPermissions(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.ListMyPermissionsResponse{
+		Permissions: permissions,
+	}), nil
+}
+
+func (h *Handler) ListRoles(ctx context.Context, req *connect.Request[v1.ListRolesRequest]) (*connect.Response[v1.ListRolesResponse], error) {
+
+	roles, err := h.store.ListRoles(ctx)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListRolesResponse{}
+
+	for _, r := range roles {
+		res.Roles = append(res.Roles, &v1.Role{
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: r.Permissions,
+		})
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (h *Handler) ListUserRoles(ctx context.Context, req *connect.Request[v1.ListUserRolesRequest]) (*connect.Response[v1.ListUserRolesResponse], error) {
+
+	roles, err := h.store.ListUserRoles(ctx, req.Msg.UserId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.ListUserRolesResponse{
+		Roles: roles,
+	}), nil
+}
+
+func (h *Handler) AssignRole(ctx context.Context, req *connect.Request[v1.AssignRoleRequest]) (*connect.Response[v1.AssignRoleResponse], error) {
+
+	err := h.store.AssignRole(ctx, req.Msg.UserId, req.Msg.Role)
+
+	if errors.Is(err, ErrUnknownRole) || errors.Is(err, ErrUnknownUser) {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.AssignRoleResponse{}), nil
+}
+
+func (h *Handler) RevokeRole(ctx context.Context, req *connect.Request[v1.RevokeRoleRequest]) (*connect.Response[v1.RevokeRoleResponse], error) {
+
+	ok, err := h.store.RevokeRole(ctx, req.Msg.UserId, req.Msg.Role)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("user does not have this role"))
+	}
+
+	return connect.NewResponse(&v1.RevokeRoleResponse{}), nil
+}
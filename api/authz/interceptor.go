@@ -0,0 +1,102 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"simple-connect/api/auth"
+	v1 "simple-connect/gen/proto/api/v1"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Interceptor enforces the (permission) option of every procedure it wraps. It must run after
+// auth.AuthInterceptor, which resolves the principal.
+type Interceptor struct {
+	authorizer *Authorizer
+	permission string
+}
+
+func NewInterceptor(authorizer *Authorizer) *Interceptor {
+	return &Interceptor{authorizer: authorizer}
+}
+
+// NewPermissionInterceptor requires permission for every procedure it wraps, for services such as
+// gRPC reflection whose protos can't declare the (permission) option
+func NewPermissionInterceptor(authorizer *Authorizer, permission string) *Interceptor {
+	return &Interceptor{authorizer: authorizer, permission: permission}
+}
+
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		err := i.authorize(ctx, req.Spec())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		err := i.authorize(ctx, conn.Spec())
+
+		if err != nil {
+			return err
+		}
+
+		return next(ctx, conn)
+	}
+}
+
+func (i *Interceptor) authorize(ctx context.Context, spec connect.Spec) error {
+	permission := i.permission
+
+	if permission == "" {
+		permission = requiredPermission(spec)
+	}
+
+	if permission == "" {
+		return nil
+	}
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	err := i.authorizer.Require(ctx, userId, permission)
+
+	if errors.Is(err, ErrPermissionDenied) {
+		return connect.NewError(connect.CodePermissionDenied, err)
+	}
+
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, err)
+	}
+
+	return nil
+}
+
+// requiredPermission reads the procedure's (permission) option, "" when it has none
+func requiredPermission(spec connect.Spec) string {
+	method, ok := spec.Schema.(protoreflect.MethodDescriptor)
+
+	if !ok {
+		return ""
+	}
+
+	return proto.GetExtension(method.Options(), v1.E_Permission).(string)
+}
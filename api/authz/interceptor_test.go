@@ -0,0 +1,76 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"simple-connect/api/auth"
+	"simple-connect/api/internal/testserver"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor(t *testing.T) {
+
+	t.Parallel()
+
+	store := NewMemoryStore([]Role{
+		{Name: "admin", Permissions: []string{"roles.read", "roles.manage"}},
+		{Name: "auditor", Permissions: []string{"roles.read"}},
+	}, map[string][]string{
+		"admin-user":   {"admin"},
+		"auditor-user": {"auditor"},
+	})
+	sessionManager := auth.NewMemorySessionManager(false, "")
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return auth.Login(r, sessionManager, auth.SessionData{UserID: userId})
+	})
+	server.Mux.Handle(apiv1connect.NewAuthorizationServiceHandler(NewHandler(store), connect.WithInterceptors(
+		auth.NewAuthInterceptor(sessionManager, nil),
+		NewInterceptor(NewAuthorizer(store)),
+	)))
+
+	clientFor := func(userId string) apiv1connect.AuthorizationServiceClient {
+		if userId == "" {
+			return apiv1connect.NewAuthorizationServiceClient(server.NewClient(), server.URL)
+		}
+
+		return apiv1connect.NewAuthorizationServiceClient(server.ClientFor(userId), server.URL)
+	}
+
+	admin := clientFor("admin-user")
+	auditor := clientFor("auditor-user")
+
+	t.Run("procedures without a permission only need a principal", func(t *testing.T) {
+		res, err := clientFor("nobody").ListMyPermissions(context.Background(), connect.NewRequest(&v1.ListMyPermissionsRequest{}))
+		require.NoError(t, err)
+		assert.Empty(t, res.Msg.Permissions)
+	})
+
+	t.Run("anonymous requests are unauthenticated", func(t *testing.T) {
+		_, err := clientFor("").ListRoles(context.Background(), connect.NewRequest(&v1.ListRolesRequest{}))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	})
+
+	t.Run("roles grant permissions", func(t *testing.T) {
+		_, err := auditor.ListRoles(context.Background(), connect.NewRequest(&v1.ListRolesRequest{}))
+		assert.NoError(t, err)
+
+		_, err = auditor.AssignRole(context.Background(), connect.NewRequest(&v1.AssignRoleRequest{UserId: "auditor-user", Role: "admin"}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+		_, err = admin.AssignRole(context.Background(), connect.NewRequest(&v1.AssignRoleRequest{UserId: "auditor-user", Role: "admin"}))
+		require.NoError(t, err)
+
+		_, err = auditor.RevokeRole(context.Background(), connect.NewRequest(&v1.RevokeRoleRequest{UserId: "admin-user", Role: "admin"}))
+		assert.NoError(t, err)
+
+		_, err = admin.ListRoles(context.Background(), connect.NewRequest(&v1.ListRolesRequest{}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+}
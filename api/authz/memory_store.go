@@ -0,0 +1,77 @@
+package authz
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryStore keeps roles in process, for tests and local tools
+type MemoryStore struct {
+	mu        sync.Mutex
+	roles     []Role
+	userRoles map[string][]string
+}
+
+// NewMemoryStore grants userRoles, a map of user id to role names, out of roles
+func NewMemoryStore(roles []Role, userRoles map[string][]string) *MemoryStore {
+	if userRoles == nil {
+		userRoles = map[string][]string{}
+	}
+
+	return &MemoryStore{roles: roles, userRoles: userRoles}
+}
+
+func (ms *MemoryStore) UserPermissions(ctx context.Context, userId string) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var permissions []string
+
+	for _, r := range ms.roles {
+		if slices.Contains(ms.userRoles[userId], r.Name) {
+			permissions = append(permissions, r.Permissions...)
+		}
+	}
+
+	return permissions, nil
+}
+
+func (ms *MemoryStore) ListRoles(ctx context.Context) ([]Role, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return slices.Clone(ms.roles), nil
+}
+
+func (ms *MemoryStore) ListUserRoles(ctx context.Context, userId string) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return slices.Clone(ms.userRoles[userId]), nil
+}
+
+func (ms *MemoryStore) AssignRole(ctx context.Context, userId string, role string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if !slices.ContainsFunc(ms.roles, func(r Role) bool { return r.Name == role }) {
+		return ErrUnknownRole
+	}
+
+	if !slices.Contains(ms.userRoles[userId], role) {
+		ms.userRoles[userId] = append(ms.userRoles[userId], role)
+	}
+
+	return nil
+}
+
+func (ms *MemoryStore) RevokeRole(ctx context.Context, userId string, role string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	before := len(ms.userRoles[userId])
+	ms.userRoles[userId] = slices.DeleteFunc(ms.userRoles[userId], func(r string) bool { return r == role })
+
+	return len(ms.userRoles[userId]) < before, nil
+}
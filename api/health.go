@@ -2,14 +2,28 @@ package api
 
 import (
 	"context"
+	"errors"
+	"simple-connect/api/health"
 	v1 "simple-connect/gen/proto/api/v1"
 
 	"connectrpc.com/connect"
 )
 
-type HealthService struct{}
+type HealthService struct {
+	monitor *health.Monitor
+}
+
+func NewHealthService(monitor *health.Monitor) *HealthService {
+	return &HealthService{monitor: monitor}
+}
 
 func (hs *HealthService) Check(context.Context, *connect.Request[v1.Empty]) (*connect.Response[v1.CheckResponse], error) {
+	serving, _ := hs.monitor.Status()
+
+	if !serving {
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("not serving"))
+	}
+
 	return connect.NewResponse(&v1.CheckResponse{
 		Message: "OK",
 	}), nil
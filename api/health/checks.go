@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresCheck pings the pool
+func PostgresCheck(pool *pgxpool.Pool) Check {
+	return Check{Name: "postgres", Check: pool.Ping}
+}
+
+// SessionStoreCheck looks up a session that never exists, which fails only when the store can't be queried
+func SessionStoreCheck(store scs.Store) Check {
+	return Check{Name: "sessions", Check: func(ctx context.Context) error {
+		if ctxStore, ok := store.(scs.CtxStore); ok {
+			_, _, err := ctxStore.FindCtx(ctx, "health-check")
+			return err
+		}
+
+		_, _, err := store.Find("health-check")
+		return err
+	}}
+}
+
+// MigrationsCheck fails until the database is at the newest goose migration in migrations
+func MigrationsCheck(pool *pgxpool.Pool, migrations fs.FS) (Check, error) {
+	want, err := latestMigration(migrations)
+
+	if err != nil {
+		return Check{}, err
+	}
+
+	return Check{Name: "migrations", Check: func(ctx context.Context) error {
+		var applied int64
+
+		// Goose adds a row per up and down, a version counts as applied when its latest row says so
+		err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM (
+				SELECT DISTINCT ON (version_id) version_id, is_applied FROM goose_db_version ORDER BY version_id, id DESC
+			) AS latest WHERE is_applied`).Scan(&applied)
+
+		if err != nil {
+			return err
+		}
+
+		if applied < want {
+			return fmt.Errorf("database is at migration %d, want %d", applied, want)
+		}
+
+		return nil
+	}}, nil
+}
+
+// latestMigration returns the highest version of the NNN_name.sql files in migrations
+func latestMigration(migrations fs.FS) (int64, error) {
+	names, err := fs.Glob(migrations, "*.sql")
+
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", name, err)
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
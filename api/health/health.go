@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"simple-connect/api/httputils"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout bounds each check so a hung dependency reads as failing
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check is a dependency the server needs to serve requests, Check returns an error while it can't
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a Check's last run. Errors are only logged, they may name hosts or
+// credentials.
+type CheckResult struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+}
+
+// Monitor runs checks in the background and keeps the server's serving status, so probes don't
+// query the database themselves. It is not serving until the first round of checks passes.
+type Monitor struct {
+	checks  []Check
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu       sync.RWMutex
+	serving  bool
+	shutdown bool
+	results  []CheckResult
+	// changed is closed and replaced whenever serving flips
+	changed chan struct{}
+}
+
+func NewMonitor(logger *slog.Logger, checks ...Check) *Monitor {
+	return &Monitor{
+		checks:  checks,
+		timeout: DefaultCheckTimeout,
+		logger:  logger,
+		changed: make(chan struct{}),
+	}
+}
+
+// Run refreshes the status every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh runs every check once and updates the status
+func (m *Monitor) Refresh(ctx context.Context) {
+	results := make([]CheckResult, len(m.checks))
+	serving := true
+
+	for i, check := range m.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, m.timeout)
+		err := check.Check(checkCtx)
+		cancel()
+
+		results[i] = CheckResult{Name: check.Name, Healthy: err == nil}
+
+		if err != nil {
+			serving = false
+			m.logger.Warn("Health check failing", slog.String("check", check.Name), slog.String("Error", err.Error()))
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.results = results
+	m.setServing(serving && !m.shutdown)
+}
+
+// Shutdown reports not serving from now on, so load balancers drain the server before it stops
+func (m *Monitor) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.shutdown = true
+	m.setServing(false)
+}
+
+// setServing must be called with mu held
+func (m *Monitor) setServing(serving bool) {
+	if m.serving == serving {
+		return
+	}
+
+	m.serving = serving
+	m.logger.Info("Serving status changed", slog.Bool("serving", serving))
+
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// Status reports whether the server is serving, with a channel that is closed once that changes
+func (m *Monitor) Status() (bool, <-chan struct{}) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.serving, m.changed
+}
+
+// Results returns the last run of every check
+func (m *Monitor) Results() []CheckResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]CheckResult(nil), m.results...)
+}
+
+type ReadinessResponse struct {
+	Serving bool          `json:"serving"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+// Liveness answers 200 OK while the process can handle requests at all. It ignores dependencies,
+// restarting the server wouldn't bring a database back.
+func (m *Monitor) Liveness(w http.ResponseWriter, r *http.Request) {
+	httputils.WriteJSON(w, r, map[string]string{"status": "OK"})
+}
+
+// Readiness answers 200 OK while the server is serving and 503 Service Unavailable otherwise
+func (m *Monitor) Readiness(w http.ResponseWriter, r *http.Request) {
+	serving, _ := m.Status()
+	status := http.StatusOK
+
+	if !serving {
+		status = http.StatusServiceUnavailable
+	}
+
+	httputils.WriteJSONStatus(w, status, ReadinessResponse{Serving: serving, Checks: m.Results()})
+}
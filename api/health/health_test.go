@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	healthv1 "simple-connect/gen/grpc/health/v1"
+	"simple-connect/gen/grpc/health/v1/healthv1connect"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+
+	t.Parallel()
+
+	var dbErr atomic.Pointer[error]
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	monitor := NewMonitor(logger, Check{Name: "postgres", Check: func(ctx context.Context) error {
+		if err := dbErr.Load(); err != nil {
+			return *err
+		}
+
+		return nil
+	}})
+
+	mux := http.NewServeMux()
+	mux.Handle(healthv1connect.NewHealthHandler(NewService(monitor, "proto.api.v1.AuthService")))
+	mux.HandleFunc("GET /livez", monitor.Liveness)
+	mux.HandleFunc("GET /readyz", monitor.Readiness)
+
+	server := httptest.NewUnstartedServer(mux)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := healthv1connect.NewHealthClient(server.Client(), server.URL)
+	ctx := context.Background()
+
+	check := func(service string) healthv1.HealthCheckResponse_ServingStatus {
+		res, err := client.Check(ctx, connect.NewRequest(&healthv1.HealthCheckRequest{Service: service}))
+		require.NoError(t, err)
+
+		return res.Msg.Status
+	}
+
+	ready := func() (int, ReadinessResponse) {
+		res, err := server.Client().Get(server.URL + "/readyz")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var body ReadinessResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+		return res.StatusCode, body
+	}
+
+	t.Run("not serving before the first checks", func(t *testing.T) {
+		assert.Equal(t, healthv1.HealthCheckResponse_NOT_SERVING, check(""))
+
+		status, _ := ready()
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+
+	t.Run("check follows dependencies", func(t *testing.T) {
+		monitor.Refresh(ctx)
+		assert.Equal(t, healthv1.HealthCheckResponse_SERVING, check(""))
+		assert.Equal(t, healthv1.HealthCheckResponse_SERVING, check("proto.api.v1.AuthService"))
+
+		status, body := ready()
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []CheckResult{{Name: "postgres", Healthy: true}}, body.Checks)
+
+		_, err := client.Check(ctx, connect.NewRequest(&healthv1.HealthCheckRequest{Service: "unknown"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+
+	t.Run("watch pushes changes", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.Watch(watchCtx, connect.NewRequest(&healthv1.HealthCheckRequest{}))
+		require.NoError(t, err)
+
+		require.True(t, stream.Receive())
+		assert.Equal(t, healthv1.HealthCheckResponse_SERVING, stream.Msg().Status)
+
+		err = errors.New("connection refused")
+		dbErr.Store(&err)
+		monitor.Refresh(ctx)
+
+		require.True(t, stream.Receive())
+		assert.Equal(t, healthv1.HealthCheckResponse_NOT_SERVING, stream.Msg().Status)
+
+		dbErr.Store(nil)
+		monitor.Refresh(ctx)
+
+		require.True(t, stream.Receive())
+		assert.Equal(t, healthv1.HealthCheckResponse_SERVING, stream.Msg().Status)
+
+		monitor.Shutdown()
+
+		require.True(t, stream.Receive())
+		assert.Equal(t, healthv1.HealthCheckResponse_NOT_SERVING, stream.Msg().Status)
+
+		// Watch only ends with the client, Close would wait for it otherwise
+		cancel()
+		assert.False(t, stream.Receive())
+		assert.NoError(t, stream.Close())
+	})
+
+	t.Run("liveness ignores dependencies", func(t *testing.T) {
+		res, err := server.Client().Get(server.URL + "/livez")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+func TestLatestMigration(t *testing.T) {
+
+	t.Parallel()
+
+	version, err := latestMigration(fstest.MapFS{
+		"20240922172330_init.sql":   {},
+		"20261017220000_magic.sql":  {},
+		"20261017100000_verify.sql": {},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(20261017220000), version)
+
+	_, err = latestMigration(fstest.MapFS{"init.sql": {}})
+	assert.Error(t, err)
+}
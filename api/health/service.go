@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	healthv1 "simple-connect/gen/grpc/health/v1"
+	"slices"
+
+	"connectrpc.com/connect"
+)
+
+// Service implements grpc.health.v1.Health. Every service it knows reports the monitor's status,
+// they all depend on the same database. connectrpc.com/grpchealth isn't used as its Watch is
+// unimplemented, and load balancers relying on Watch would never see a status change.
+type Service struct {
+	monitor  *Monitor
+	services []string
+}
+
+// NewService answers for the server as a whole, the empty service name, and for services
+func NewService(monitor *Monitor, services ...string) *Service {
+	return &Service{monitor: monitor, services: services}
+}
+
+func (s *Service) known(service string) bool {
+	return service == "" || slices.Contains(s.services, service)
+}
+
+func servingStatus(serving bool) healthv1.HealthCheckResponse_ServingStatus {
+	if serving {
+		return healthv1.HealthCheckResponse_SERVING
+	}
+
+	return healthv1.HealthCheckResponse_NOT_SERVING
+}
+
+func (s *Service) Check(ctx context.Context, req *connect.Request[healthv1.HealthCheckRequest]) (*connect.Response[healthv1.HealthCheckResponse], error) {
+	if !s.known(req.Msg.Service) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("unknown service"))
+	}
+
+	serving, _ := s.monitor.Status()
+
+	return connect.NewResponse(&healthv1.HealthCheckResponse{Status: servingStatus(serving)}), nil
+}
+
+// Watch sends the current status, then every change until the client goes away
+func (s *Service) Watch(ctx context.Context, req *connect.Request[healthv1.HealthCheckRequest], stream *connect.ServerStream[healthv1.HealthCheckResponse]) error {
+	if !s.known(req.Msg.Service) {
+		err := stream.Send(&healthv1.HealthCheckResponse{Status: healthv1.HealthCheckResponse_SERVICE_UNKNOWN})
+
+		if err != nil {
+			return err
+		}
+
+		// The set of services is fixed, so the status can't become known later
+		<-ctx.Done()
+		return nil
+	}
+
+	for {
+		serving, changed := s.monitor.Status()
+		err := stream.Send(&healthv1.HealthCheckResponse{Status: servingStatus(serving)})
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
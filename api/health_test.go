@@ -2,6 +2,10 @@ package api
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"simple-connect/api/health"
 	apiv1 "simple-connect/gen/proto/api/v1"
 	"simple-connect/gen/proto/api/v1/apiv1connect"
 	"testing"
@@ -14,7 +18,13 @@ func TestHealthHandler(t *testing.T) {
 
 	t.Parallel()
 
-	server := BootstrapTestHandler(apiv1connect.NewHealthServiceHandler(&HealthService{}))
+	var dbErr error
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	monitor := health.NewMonitor(logger, health.Check{Name: "postgres", Check: func(ctx context.Context) error { return dbErr }})
+	monitor.Refresh(context.Background())
+
+	server := BootstrapTestHandler(apiv1connect.NewHealthServiceHandler(NewHealthService(monitor)))
 
 	server.EnableHTTP2 = true
 	server.StartTLS()
@@ -29,5 +39,12 @@ func TestHealthHandler(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, "OK", resp.Msg.Message)
+
+		dbErr = errors.New("connection refused")
+		monitor.Refresh(context.Background())
+
+		_, err = client.Check(context.Background(), &connect.Request[apiv1.Empty]{})
+
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
 	})
 }
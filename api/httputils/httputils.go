@@ -54,15 +54,13 @@ func ReadJSON[T any](r *http.Request, target T) error {
 }
 
 func WriteJSON[T any](w http.ResponseWriter, r *http.Request, data T) error {
-	enc := json.NewEncoder(w)
+	return WriteJSONStatus(w, http.StatusOK, data)
+}
 
-	err := enc.Encode(data)
+// WriteJSONStatus writes data as JSON with the given status, for responses other than 200 OK
+func WriteJSONStatus[T any](w http.ResponseWriter, status int, data T) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
-	if err != nil {
-		return err
-	}
-
-	r.Header.Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	return nil
-}
\ No newline at end of file
+	return json.NewEncoder(w).Encode(data)
+}
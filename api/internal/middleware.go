@@ -3,7 +3,9 @@ package internal
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
@@ -18,6 +20,16 @@ type RequestLoggerContextKey string
 
 const RequestLoggerKey RequestLoggerContextKey = "logger"
 
+type clientInfoContextKey struct{}
+
+type requestIdContextKey struct{}
+
+// ClientInfo describes the client making the request
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
 type statusRecorder struct {
 	http.ResponseWriter
 	status int
@@ -33,7 +45,8 @@ func CorsMiddleware(origin string) alice.Constructor {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
-			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, X-User-Agent")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, X-User-Agent, X-Request-Id")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -45,16 +58,93 @@ func CorsMiddleware(origin string) alice.Constructor {
 	}
 }
 
+// maxRequestIdLength leaves room for the ids proxies and tracing systems generate, which are rarely over 64
+const maxRequestIdLength = 128
+
+// validRequestId accepts ids that are safe to log and echo, letters, digits, '-', '_' and '.'
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestIdMiddleware keeps a well formed X-Request-Id from the client or a proxy, so requests can be
+// followed across services, and generates one otherwise. The id is echoed in the response.
 func RequestIdMiddleware() alice.Constructor {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			r.Header.Set(RequestIdHeader, uuid.New().String())
-			next.ServeHTTP(w, r)
+			requestId := r.Header.Get(RequestIdHeader)
+
+			if !validRequestId(requestId) {
+				requestId = uuid.New().String()
+				r.Header.Set(RequestIdHeader, requestId)
+			}
+
+			w.Header().Set(RequestIdHeader, requestId)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIdContextKey{}, requestId)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the id RequestIdMiddleware gave the request, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey{}).(string)
+	return requestId
+}
+
+// ClientIP returns the request's client address. With trustProxy the right most X-Forwarded-For
+// entry is used, the one added by the proxy in front of the server. Entries to its left come from
+// the client and are ignored, so they can't dodge per-IP limits or fake the logged address.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+
+			if i := strings.LastIndex(last, ","); i != -1 {
+				last = last[i+1:]
+			}
+
+			if ip := net.ParseIP(strings.TrimSpace(last)); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ClientInfoMiddleware makes the client's address and user agent available to handlers
+// that only receive a context, such as Connect handlers
+func ClientInfoMiddleware(trustProxy bool) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := ClientInfo{IP: ClientIP(r, trustProxy), UserAgent: r.UserAgent()}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientInfoContextKey{}, info)))
 		})
 	}
 }
 
+// ClientInfoFromContext returns the client set by ClientInfoMiddleware, or the zero value
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoContextKey{}).(ClientInfo)
+	return info
+}
+
 func LoggingMiddleware(logger slog.Logger) alice.Constructor {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -78,12 +168,16 @@ func LoggingMiddleware(logger slog.Logger) alice.Constructor {
 }
 
 func RequestLogger(request *http.Request) *slog.Logger {
-	return request.Context().Value(RequestLoggerKey).(*slog.Logger)
+	return RpcLogger(request.Context())
 }
 
 type MiddlewareConfig struct {
 	CorsOrigin     string
 	SessionManager *scs.SessionManager
+	// TrustProxy reads the client address from the last X-Forwarded-For entry, set by a single proxy
+	TrustProxy bool
+	// RateLimit, when set, runs last so it sees the session, client and request logger
+	RateLimit alice.Constructor
 }
 
 func RootMiddleware(logger slog.Logger, cfg MiddlewareConfig) alice.Chain {
@@ -93,9 +187,21 @@ func RootMiddleware(logger slog.Logger, cfg MiddlewareConfig) alice.Chain {
 		HostsProxyHeaders: []string{"X-Forwarded-Host"},
 	})
 
-	return alice.New(cfg.SessionManager.LoadAndSave, RequestIdMiddleware(), LoggingMiddleware(logger), CorsMiddleware(cfg.CorsOrigin), secureMw.Handler)
+	chain := alice.New(cfg.SessionManager.LoadAndSave, RequestIdMiddleware(), ClientInfoMiddleware(cfg.TrustProxy), LoggingMiddleware(logger), CorsMiddleware(cfg.CorsOrigin), secureMw.Handler)
+
+	if cfg.RateLimit != nil {
+		chain = chain.Append(cfg.RateLimit)
+	}
+
+	return chain
 }
 
+// RpcLogger returns the request's logger, slog.Default() outside of LoggingMiddleware so errors
+// are never dropped
 func RpcLogger(ctx context.Context) *slog.Logger {
-	return ctx.Value(RequestLoggerKey).(*slog.Logger)
+	if logger, ok := ctx.Value(RequestLoggerKey).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
 }
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+
+	t.Parallel()
+
+	var seen string
+
+	handler := RequestIdMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{name: "well formed ids are kept", incoming: "req-01H8.abc_DEF", kept: true},
+		{name: "missing ids are generated", incoming: ""},
+		{name: "ids with other characters are replaced", incoming: "id\nInjected: header"},
+		{name: "long ids are replaced", incoming: strings.Repeat("a", maxRequestIdLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIdHeader, tt.incoming)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(RequestIdHeader))
+			assert.Equal(t, seen, req.Header.Get(RequestIdHeader))
+
+			if tt.kept {
+				assert.Equal(t, tt.incoming, seen)
+			} else {
+				assert.NotEqual(t, tt.incoming, seen)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		forwarded  []string
+		trustProxy bool
+		want       string
+	}{
+		{name: "the remote address is used without a proxy", forwarded: []string{"203.0.113.7"}, want: "192.0.2.1"},
+		{name: "the proxy's entry is used", forwarded: []string{"203.0.113.7"}, trustProxy: true, want: "203.0.113.7"},
+		{name: "client sent entries are ignored", forwarded: []string{"198.51.100.9, 203.0.113.7"}, trustProxy: true, want: "203.0.113.7"},
+		{name: "client sent headers are ignored", forwarded: []string{"198.51.100.9", "203.0.113.7"}, trustProxy: true, want: "203.0.113.7"},
+		{name: "malformed entries fall back to the remote address", forwarded: []string{"203.0.113.7, not-an-ip"}, trustProxy: true, want: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+
+			for _, forwarded := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", forwarded)
+			}
+
+			assert.Equal(t, tt.want, ClientIP(req, tt.trustProxy))
+		})
+	}
+}
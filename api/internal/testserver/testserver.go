@@ -0,0 +1,77 @@
+// Package testserver runs handlers behind a session manager for tests that sign in as several users
+package testserver
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/require"
+)
+
+// LoginFunc starts a session for userId, tests pass auth.Login. It is taken as a function so
+// package auth's own tests can use the server too.
+type LoginFunc func(r *http.Request, userId string) error
+
+// Server is an HTTP/2 TLS server. Mount handlers on Mux, GET /test-login?user= signs the client in.
+type Server struct {
+	*httptest.Server
+	Mux *http.ServeMux
+	t   *testing.T
+}
+
+// New starts a server that loads sessions from sessionManager. middleware runs inside the session
+// middleware, in front of Mux. The server is closed when the test ends.
+func New(t *testing.T, sessionManager *scs.SessionManager, login LoginFunc, middleware ...alice.Constructor) *Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test-login", func(w http.ResponseWriter, r *http.Request) {
+		err := login(r, r.URL.Query().Get("user"))
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	server := httptest.NewUnstartedServer(sessionManager.LoadAndSave(alice.New(middleware...).Then(mux)))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return &Server{Server: server, Mux: mux, t: t}
+}
+
+// NewClient returns a client with a cookie jar of its own, so each keeps its own session
+func (s *Server) NewClient() *http.Client {
+	client := *s.Server.Client()
+	jar, _ := cookiejar.New(nil)
+	client.Jar = jar
+
+	return &client
+}
+
+// SignIn signs client in as userId, header is added to the login request
+func (s *Server) SignIn(client *http.Client, userId string, header http.Header) {
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/test-login?user="+url.QueryEscape(userId), nil)
+	require.NoError(s.t, err)
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	res, err := client.Do(req)
+	require.NoError(s.t, err)
+	res.Body.Close()
+	require.Equal(s.t, http.StatusOK, res.StatusCode)
+}
+
+// ClientFor returns a new client signed in as userId
+func (s *Server) ClientFor(userId string) *http.Client {
+	client := s.NewClient()
+	s.SignIn(client, userId, nil)
+
+	return client
+}
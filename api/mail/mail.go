@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes messages to the logger instead of delivering them, for local development
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger.WithGroup("mail")}
+}
+
+func (lm *LogMailer) Send(ctx context.Context, msg Message) error {
+	lm.logger.Info("Sending mail", slog.String("to", msg.To), slog.String("subject", msg.Subject), slog.String("body", msg.Body))
+	return nil
+}
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{addr: net.JoinHostPort(host, port), from: from, auth: auth}
+}
+
+func (sm *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, raw, err := sm.compose(msg)
+
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(sm.addr, sm.auth, sm.from, []string{to}, raw)
+}
+
+// compose builds the raw message and returns the recipient's bare address. Addresses go through
+// net/mail and the subject is encoded, so neither can add headers with CR or LF.
+func (sm *SMTPMailer) compose(msg Message) (string, []byte, error) {
+	to, err := netmail.ParseAddress(msg.To)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", sm.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	return to.Address, []byte(b.String()), nil
+}
+
+// NewMailerFromEnv returns an SMTPMailer when SMTP_HOST is set, otherwise a LogMailer
+func NewMailerFromEnv(logger *slog.Logger) Mailer {
+	host := os.Getenv("SMTP_HOST")
+
+	if host == "" {
+		return NewLogMailer(logger)
+	}
+
+	port := os.Getenv("SMTP_PORT")
+
+	if port == "" {
+		port = "587"
+	}
+
+	return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+}
@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPMailerCompose(t *testing.T) {
+
+	t.Parallel()
+
+	mailer := NewSMTPMailer("localhost", "25", "", "", "noreply@example.com")
+
+	t.Run("subjects can't add headers", func(t *testing.T) {
+		to, raw, err := mailer.compose(Message{To: "jane@example.com", Subject: "Hi\r\nBcc: eve@example.com", Body: "body"})
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", to)
+
+		headers, _, _ := strings.Cut(string(raw), "\r\n\r\n")
+		assert.NotContains(t, headers, "\r\nBcc:")
+		assert.Len(t, strings.Split(headers, "\r\n"), 5)
+	})
+
+	t.Run("recipients with line breaks are refused", func(t *testing.T) {
+		_, _, err := mailer.compose(Message{To: "jane@example.com\r\nBcc: eve@example.com", Subject: "Hi"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,419 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"simple-connect/api/auth"
+	v1 "simple-connect/gen/proto/api/v1"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/alexedwards/scs/v2"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Handler serves OrganizationService. Membership roles are checked per organization here,
+// unlike permissions which are enforced by the authz interceptor.
+type Handler struct {
+	store          Store
+	sessionManager *scs.SessionManager
+	invitations    *Invitations
+}
+
+func NewHandler(store Store, sessionManager *scs.SessionManager, invitations *Invitations) *Handler {
+	return &Handler{store: store, sessionManager: sessionManager, invitations: invitations}
+}
+
+// membership returns the caller's view of the organization, failing with NotFound when they aren't a
+// member so organizations can't be probed, and PermissionDenied when their role ranks below minRole
+func (h *Handler) membership(ctx context.Context, orgId string, minRole string) (*Organization, error) {
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	org, err := h.store.GetOrganization(ctx, orgId, userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("organization not found"))
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if roleRank(org.Role) < roleRank(minRole) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("requires the "+minRole+" role"))
+	}
+
+	return org, nil
+}
+
+func (h *Handler) CreateOrganization(ctx context.Context, req *connect.Request[v1.CreateOrganizationRequest]) (*connect.Response[v1.CreateOrganizationResponse], error) {
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	name := strings.TrimSpace(req.Msg.Name)
+
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+
+	org, err := h.store.CreateOrganization(ctx, name, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.CreateOrganizationResponse{
+		Organization: org.toProto(),
+	}), nil
+}
+
+func (h *Handler) ListOrganizations(ctx context.Context, req *connect.Request[v1.ListOrganizationsRequest]) (*connect.Response[v1.ListOrganizationsResponse], error) {
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	orgs, err := h.store.ListOrganizations(ctx, userId)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListOrganizationsResponse{}
+
+	for _, o := range orgs {
+		res.Organizations = append(res.Organizations, o.toProto())
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (h *Handler) DeleteOrganization(ctx context.Context, req *connect.Request[v1.DeleteOrganizationRequest]) (*connect.Response[v1.DeleteOrganizationResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleOwner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.store.DeleteOrganization(ctx, org.ID)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if h.sessionManager.GetString(ctx, auth.SessionOrganizationKey) == org.ID {
+		h.sessionManager.Remove(ctx, auth.SessionOrganizationKey)
+	}
+
+	return connect.NewResponse(&v1.DeleteOrganizationResponse{}), nil
+}
+
+func (h *Handler) ListMembers(ctx context.Context, req *connect.Request[v1.ListMembersRequest]) (*connect.Response[v1.ListMembersResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleMember)
+
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := h.store.ListMembers(ctx, org.ID)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListMembersResponse{}
+
+	for _, m := range members {
+		member := &v1.OrganizationMember{
+			UserId:    m.UserID,
+			Role:      roleToProto(m.Role),
+			CreatedAt: timestamppb.New(m.CreatedAt),
+		}
+
+		if m.Email != nil {
+			member.Email = *m.Email
+		}
+
+		res.Members = append(res.Members, member)
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (h *Handler) UpdateMemberRole(ctx context.Context, req *connect.Request[v1.UpdateMemberRoleRequest]) (*connect.Response[v1.UpdateMemberRoleResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	role := roleFromProto(req.Msg.Role)
+
+	if role == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("role is required"))
+	}
+
+	target, err := h.store.GetOrganization(ctx, org.ID, req.Msg.UserId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("member not found"))
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	// Only owners make or unmake owners
+	if (role == RoleOwner || target.Role == RoleOwner) && org.Role != RoleOwner {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("requires the owner role"))
+	}
+
+	ok, err := h.store.UpdateMemberRole(ctx, org.ID, req.Msg.UserId, role)
+
+	if errors.Is(err, ErrLastOwner) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("member not found"))
+	}
+
+	return connect.NewResponse(&v1.UpdateMemberRoleResponse{}), nil
+}
+
+func (h *Handler) RemoveMember(ctx context.Context, req *connect.Request[v1.RemoveMemberRequest]) (*connect.Response[v1.RemoveMemberResponse], error) {
+
+	leaving := req.Msg.UserId == auth.UserIDFromContext(ctx)
+	minRole := RoleAdmin
+
+	if leaving {
+		minRole = RoleMember
+	}
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, minRole)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !leaving {
+		target, err := h.store.GetOrganization(ctx, org.ID, req.Msg.UserId)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("member not found"))
+		}
+
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+
+		if target.Role == RoleOwner && org.Role != RoleOwner {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("requires the owner role"))
+		}
+	}
+
+	ok, err := h.store.RemoveMember(ctx, org.ID, req.Msg.UserId)
+
+	if errors.Is(err, ErrLastOwner) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("member not found"))
+	}
+
+	if leaving && h.sessionManager.GetString(ctx, auth.SessionOrganizationKey) == org.ID {
+		h.sessionManager.Remove(ctx, auth.SessionOrganizationKey)
+	}
+
+	return connect.NewResponse(&v1.RemoveMemberResponse{}), nil
+}
+
+func (h *Handler) CreateInvitation(ctx context.Context, req *connect.Request[v1.CreateInvitationRequest]) (*connect.Response[v1.CreateInvitationResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(req.Msg.Email)
+
+	if !strings.Contains(email, "@") {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("a valid email is required"))
+	}
+
+	role := RoleMember
+
+	if req.Msg.Role != v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+		role = roleFromProto(req.Msg.Role)
+	}
+
+	if role == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("unknown role"))
+	}
+
+	if roleRank(role) > roleRank(org.Role) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("can't invite with a higher role than your own"))
+	}
+
+	inviter := auth.UserIDFromContext(ctx)
+
+	invitation, err := h.invitations.Send(ctx, org, Invitation{
+		Email:     email,
+		Role:      role,
+		InvitedBy: &inviter,
+	})
+
+	if errors.Is(err, ErrAlreadyMember) {
+		return nil, connect.NewError(connect.CodeAlreadyExists, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&v1.CreateInvitationResponse{
+		Invitation: invitation.toProto(),
+	}), nil
+}
+
+func (h *Handler) ListInvitations(ctx context.Context, req *connect.Request[v1.ListInvitationsRequest]) (*connect.Response[v1.ListInvitationsResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := h.store.ListInvitations(ctx, org.ID)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	res := &v1.ListInvitationsResponse{}
+
+	for _, i := range invitations {
+		res.Invitations = append(res.Invitations, i.toProto())
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (h *Handler) RevokeInvitation(ctx context.Context, req *connect.Request[v1.RevokeInvitationRequest]) (*connect.Response[v1.RevokeInvitationResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := h.store.DeleteInvitation(ctx, org.ID, req.Msg.Id)
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("invitation not found"))
+	}
+
+	return connect.NewResponse(&v1.RevokeInvitationResponse{}), nil
+}
+
+func (h *Handler) AcceptInvitation(ctx context.Context, req *connect.Request[v1.AcceptInvitationRequest]) (*connect.Response[v1.AcceptInvitationResponse], error) {
+
+	userId := auth.UserIDFromContext(ctx)
+
+	if userId == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("unauthorized"))
+	}
+
+	orgId, err := h.invitations.Accept(ctx, userId, req.Msg.Token)
+
+	if errors.Is(err, auth.ErrInvalidToken) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	if errors.Is(err, ErrEmailMismatch) {
+		return nil, connect.NewError(connect.CodePermissionDenied, err)
+	}
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	org, err := h.membership(ctx, orgId, RoleMember)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.sessionManager.Put(ctx, auth.SessionOrganizationKey, org.ID)
+
+	return connect.NewResponse(&v1.AcceptInvitationResponse{
+		Organization: org.toProto(),
+	}), nil
+}
+
+func (h *Handler) SetActiveOrganization(ctx context.Context, req *connect.Request[v1.SetActiveOrganizationRequest]) (*connect.Response[v1.SetActiveOrganizationResponse], error) {
+
+	org, err := h.membership(ctx, req.Msg.OrganizationId, RoleMember)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.sessionManager.Put(ctx, auth.SessionOrganizationKey, org.ID)
+
+	return connect.NewResponse(&v1.SetActiveOrganizationResponse{
+		Organization: org.toProto(),
+	}), nil
+}
+
+func (h *Handler) GetActiveOrganization(ctx context.Context, req *connect.Request[v1.GetActiveOrganizationRequest]) (*connect.Response[v1.GetActiveOrganizationResponse], error) {
+
+	orgId := h.sessionManager.GetString(ctx, auth.SessionOrganizationKey)
+
+	if orgId == "" {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no active organization"))
+	}
+
+	org, err := h.membership(ctx, orgId, RoleMember)
+
+	// The user was removed or the organization deleted since it was activated
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		h.sessionManager.Remove(ctx, auth.SessionOrganizationKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&v1.GetActiveOrganizationResponse{
+		Organization: org.toProto(),
+	}), nil
+}
@@ -0,0 +1,322 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"simple-connect/api/auth"
+	"simple-connect/api/internal/testserver"
+	"simple-connect/api/mail"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryInvitation struct {
+	Invitation
+	tokenHash string
+	accepted  bool
+}
+
+// memoryStore implements the parts of Store the tests exercise, the rest panic
+type memoryStore struct {
+	Store
+
+	mu          sync.Mutex
+	emails      map[string]string
+	orgs        map[string]string
+	members     map[string]map[string]string
+	invitations []*memoryInvitation
+}
+
+func newMemoryStore(emails map[string]string) *memoryStore {
+	return &memoryStore{emails: emails, orgs: map[string]string{}, members: map[string]map[string]string{}}
+}
+
+func (ms *memoryStore) CreateOrganization(ctx context.Context, name string, ownerId string) (*Organization, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	id := "org-" + strconv.Itoa(len(ms.orgs))
+	ms.orgs[id] = name
+	ms.members[id] = map[string]string{ownerId: RoleOwner}
+
+	return &Organization{ID: id, Name: name, Role: RoleOwner}, nil
+}
+
+func (ms *memoryStore) ListOrganizations(ctx context.Context, userId string) ([]Organization, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var orgs []Organization
+
+	for id, members := range ms.members {
+		if role, ok := members[userId]; ok {
+			orgs = append(orgs, Organization{ID: id, Name: ms.orgs[id], Role: role})
+		}
+	}
+
+	return orgs, nil
+}
+
+func (ms *memoryStore) GetOrganization(ctx context.Context, orgId string, userId string) (*Organization, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	role, ok := ms.members[orgId][userId]
+
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return &Organization{ID: orgId, Name: ms.orgs[orgId], Role: role}, nil
+}
+
+func (ms *memoryStore) DeleteOrganization(ctx context.Context, orgId string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.orgs, orgId)
+	delete(ms.members, orgId)
+
+	return nil
+}
+
+func (ms *memoryStore) ListMembers(ctx context.Context, orgId string) ([]Member, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var members []Member
+
+	for userId, role := range ms.members[orgId] {
+		email := ms.emails[userId]
+		members = append(members, Member{UserID: userId, Email: &email, Role: role})
+	}
+
+	return members, nil
+}
+
+func (ms *memoryStore) lastOwner(orgId string, userId string) bool {
+	owners := 0
+
+	for _, role := range ms.members[orgId] {
+		if role == RoleOwner {
+			owners++
+		}
+	}
+
+	return owners == 1 && ms.members[orgId][userId] == RoleOwner
+}
+
+func (ms *memoryStore) UpdateMemberRole(ctx context.Context, orgId string, userId string, role string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.members[orgId][userId]; !ok {
+		return false, nil
+	}
+
+	if role != RoleOwner && ms.lastOwner(orgId, userId) {
+		return false, ErrLastOwner
+	}
+
+	ms.members[orgId][userId] = role
+
+	return true, nil
+}
+
+func (ms *memoryStore) RemoveMember(ctx context.Context, orgId string, userId string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.members[orgId][userId]; !ok {
+		return false, nil
+	}
+
+	if ms.lastOwner(orgId, userId) {
+		return false, ErrLastOwner
+	}
+
+	delete(ms.members[orgId], userId)
+
+	return true, nil
+}
+
+func (ms *memoryStore) CreateInvitation(ctx context.Context, invitation Invitation, tokenHash string) (*Invitation, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	invitation.ID = "invitation-" + strconv.Itoa(len(ms.invitations))
+	ms.invitations = append(ms.invitations, &memoryInvitation{Invitation: invitation, tokenHash: tokenHash})
+
+	return &invitation, nil
+}
+
+func (ms *memoryStore) ListInvitations(ctx context.Context, orgId string) ([]Invitation, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var invitations []Invitation
+
+	for _, i := range ms.invitations {
+		if i.OrganizationID == orgId && !i.accepted {
+			invitations = append(invitations, i.Invitation)
+		}
+	}
+
+	return invitations, nil
+}
+
+func (ms *memoryStore) AcceptInvitation(ctx context.Context, tokenHash string, userId string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, i := range ms.invitations {
+		if i.tokenHash != tokenHash || i.accepted || time.Now().After(i.ExpiresAt) {
+			continue
+		}
+
+		if !strings.EqualFold(ms.emails[userId], i.Email) {
+			return "", ErrEmailMismatch
+		}
+
+		if _, ok := ms.members[i.OrganizationID][userId]; !ok {
+			ms.members[i.OrganizationID][userId] = i.Role
+		}
+
+		i.accepted = true
+
+		return i.OrganizationID, nil
+	}
+
+	return "", auth.ErrInvalidToken
+}
+
+// outbox records mail instead of sending it
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func (o *outbox) lastToken(t *testing.T) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	require.NotEmpty(t, o.messages)
+	body := o.messages[len(o.messages)-1].Body
+	link, err := url.Parse(strings.TrimSpace(body[strings.Index(body, "http"):]))
+	require.NoError(t, err)
+
+	return link.Query().Get("token")
+}
+
+func TestOrganizations(t *testing.T) {
+
+	t.Parallel()
+
+	store := newMemoryStore(map[string]string{
+		"owner":    "owner@example.com",
+		"invitee":  "invitee@example.com",
+		"stranger": "stranger@example.com",
+	})
+	sessionManager := auth.NewMemorySessionManager(false, "")
+	mailer := &outbox{}
+	invitations := NewInvitations(store, mailer, "http://app.test/accept-invitation")
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return auth.Login(r, sessionManager, auth.SessionData{UserID: userId})
+	})
+	server.Mux.Handle(apiv1connect.NewOrganizationServiceHandler(NewHandler(store, sessionManager, invitations), connect.WithInterceptors(
+		auth.NewAuthInterceptor(sessionManager, nil),
+	)))
+
+	clientFor := func(userId string) apiv1connect.OrganizationServiceClient {
+		return apiv1connect.NewOrganizationServiceClient(server.ClientFor(userId), server.URL)
+	}
+
+	ctx := context.Background()
+	owner := clientFor("owner")
+	invitee := clientFor("invitee")
+	stranger := clientFor("stranger")
+
+	created, err := owner.CreateOrganization(ctx, connect.NewRequest(&v1.CreateOrganizationRequest{Name: "Acme"}))
+	require.NoError(t, err)
+	orgId := created.Msg.Organization.Id
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, created.Msg.Organization.Role)
+
+	t.Run("non members can't see the organization", func(t *testing.T) {
+		_, err := stranger.ListMembers(ctx, connect.NewRequest(&v1.ListMembersRequest{OrganizationId: orgId}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+
+	t.Run("invitations are accepted by the invited email only", func(t *testing.T) {
+		_, err := owner.CreateInvitation(ctx, connect.NewRequest(&v1.CreateInvitationRequest{
+			OrganizationId: orgId,
+			Email:          "Invitee@example.com",
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_ADMIN,
+		}))
+		require.NoError(t, err)
+
+		token := mailer.lastToken(t)
+
+		_, err = stranger.AcceptInvitation(ctx, connect.NewRequest(&v1.AcceptInvitationRequest{Token: token}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+		accepted, err := invitee.AcceptInvitation(ctx, connect.NewRequest(&v1.AcceptInvitationRequest{Token: token}))
+		require.NoError(t, err)
+		assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_ADMIN, accepted.Msg.Organization.Role)
+
+		_, err = invitee.AcceptInvitation(ctx, connect.NewRequest(&v1.AcceptInvitationRequest{Token: token}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+		active, err := invitee.GetActiveOrganization(ctx, connect.NewRequest(&v1.GetActiveOrganizationRequest{}))
+		require.NoError(t, err)
+		assert.Equal(t, orgId, active.Msg.Organization.Id)
+	})
+
+	t.Run("only owners make owners", func(t *testing.T) {
+		_, err := invitee.UpdateMemberRole(ctx, connect.NewRequest(&v1.UpdateMemberRoleRequest{
+			OrganizationId: orgId,
+			UserId:         "invitee",
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+		}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+		_, err = invitee.CreateInvitation(ctx, connect.NewRequest(&v1.CreateInvitationRequest{
+			OrganizationId: orgId,
+			Email:          "another@example.com",
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+		}))
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+
+	t.Run("the last owner can't leave", func(t *testing.T) {
+		_, err := owner.RemoveMember(ctx, connect.NewRequest(&v1.RemoveMemberRequest{OrganizationId: orgId, UserId: "owner"}))
+		assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+	})
+
+	t.Run("leaving clears the active organization", func(t *testing.T) {
+		_, err := invitee.RemoveMember(ctx, connect.NewRequest(&v1.RemoveMemberRequest{OrganizationId: orgId, UserId: "invitee"}))
+		require.NoError(t, err)
+
+		_, err = invitee.GetActiveOrganization(ctx, connect.NewRequest(&v1.GetActiveOrganizationRequest{}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
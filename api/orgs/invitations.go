@@ -0,0 +1,65 @@
+package orgs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"simple-connect/api/auth"
+	"simple-connect/api/mail"
+	"time"
+)
+
+const InvitationLifetime = 7 * 24 * time.Hour
+
+// Invitations issues invitation emails and accepts their tokens. It implements auth.InvitationAcceptor.
+type Invitations struct {
+	store     Store
+	mailer    mail.Mailer
+	acceptURL string
+}
+
+// NewInvitations creates Invitations that mails links of the form acceptURL?token=...
+func NewInvitations(store Store, mailer mail.Mailer, acceptURL string) *Invitations {
+	return &Invitations{store: store, mailer: mailer, acceptURL: acceptURL}
+}
+
+// Send stores a new invitation to the organization and mails its link
+func (inv *Invitations) Send(ctx context.Context, org *Organization, invitation Invitation) (*Invitation, error) {
+	token, err := auth.GenerateToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.OrganizationID = org.ID
+	invitation.ExpiresAt = time.Now().Add(InvitationLifetime)
+
+	created, err := inv.store.CreateInvitation(ctx, invitation, auth.HashToken(token))
+
+	if err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", inv.acceptURL, url.QueryEscape(token))
+
+	err = inv.mailer.Send(ctx, mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You're invited to join %s", org.Name),
+		Body:    fmt.Sprintf("You've been invited to join %s. Open the link below to accept, it expires in 7 days.\n\n%s\n", org.Name, link),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Accept consumes the token for the user and returns the organization they joined
+func (inv *Invitations) Accept(ctx context.Context, userId string, token string) (string, error) {
+	if token == "" {
+		return "", auth.ErrInvalidToken
+	}
+
+	return inv.store.AcceptInvitation(ctx, auth.HashToken(token), userId)
+}
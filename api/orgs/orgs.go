@@ -0,0 +1,120 @@
+// Package orgs groups users into organizations. Members have an owner, admin or member role
+// within each organization and new members join through emailed invitations.
+package orgs
+
+import (
+	"context"
+	"simple-connect/api/apierrors"
+	v1 "simple-connect/gen/proto/api/v1"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const RoleOwner = "owner"
+const RoleAdmin = "admin"
+const RoleMember = "member"
+
+var ErrLastOwner = apierrors.New(connect.CodeFailedPrecondition, apierrors.ReasonLastOwner, "an organization needs at least one owner")
+var ErrAlreadyMember = apierrors.New(connect.CodeAlreadyExists, apierrors.ReasonAlreadyMember, "user is already a member of the organization")
+var ErrEmailMismatch = apierrors.New(connect.CodePermissionDenied, apierrors.ReasonInvitationEmailMismatch, "invitation was sent to a different email address")
+
+// roleRank orders roles so a role includes everything ranked below it
+func roleRank(role string) int {
+	switch role {
+	case RoleOwner:
+		return 3
+	case RoleAdmin:
+		return 2
+	case RoleMember:
+		return 1
+	}
+
+	return 0
+}
+
+func roleToProto(role string) v1.OrganizationRole {
+	switch role {
+	case RoleOwner:
+		return v1.OrganizationRole_ORGANIZATION_ROLE_OWNER
+	case RoleAdmin:
+		return v1.OrganizationRole_ORGANIZATION_ROLE_ADMIN
+	case RoleMember:
+		return v1.OrganizationRole_ORGANIZATION_ROLE_MEMBER
+	}
+
+	return v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED
+}
+
+func roleFromProto(role v1.OrganizationRole) string {
+	switch role {
+	case v1.OrganizationRole_ORGANIZATION_ROLE_OWNER:
+		return RoleOwner
+	case v1.OrganizationRole_ORGANIZATION_ROLE_ADMIN:
+		return RoleAdmin
+	case v1.OrganizationRole_ORGANIZATION_ROLE_MEMBER:
+		return RoleMember
+	}
+
+	return ""
+}
+
+// Organization is an organization as seen by one of its members
+type Organization struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	Role      string    `db:"role"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (o *Organization) toProto() *v1.Organization {
+	return &v1.Organization{
+		Id:        o.ID,
+		Name:      o.Name,
+		Role:      roleToProto(o.Role),
+		CreatedAt: timestamppb.New(o.CreatedAt),
+	}
+}
+
+type Member struct {
+	UserID    string    `db:"user_id"`
+	Email     *string   `db:"email"`
+	Role      string    `db:"role"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Invitation struct {
+	ID             string    `db:"id"`
+	OrganizationID string    `db:"organization_id"`
+	Email          string    `db:"email"`
+	Role           string    `db:"role"`
+	InvitedBy      *string   `db:"invited_by"`
+	ExpiresAt      time.Time `db:"expires_at"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (i *Invitation) toProto() *v1.OrganizationInvitation {
+	return &v1.OrganizationInvitation{
+		Id:        i.ID,
+		Email:     i.Email,
+		Role:      roleToProto(i.Role),
+		ExpiresAt: timestamppb.New(i.ExpiresAt),
+		CreatedAt: timestamppb.New(i.CreatedAt),
+	}
+}
+
+type Store interface {
+	CreateOrganization(ctx context.Context, name string, ownerId string) (*Organization, error)
+	ListOrganizations(ctx context.Context, userId string) ([]Organization, error)
+	// GetOrganization returns pgx.ErrNoRows when the user isn't a member
+	GetOrganization(ctx context.Context, orgId string, userId string) (*Organization, error)
+	DeleteOrganization(ctx context.Context, orgId string) error
+	ListMembers(ctx context.Context, orgId string) ([]Member, error)
+	UpdateMemberRole(ctx context.Context, orgId string, userId string, role string) (bool, error)
+	RemoveMember(ctx context.Context, orgId string, userId string) (bool, error)
+	CreateInvitation(ctx context.Context, invitation Invitation, tokenHash string) (*Invitation, error)
+	ListInvitations(ctx context.Context, orgId string) ([]Invitation, error)
+	DeleteInvitation(ctx context.Context, orgId string, id string) (bool, error)
+	AcceptInvitation(ctx context.Context, tokenHash string, userId string) (string, error)
+}
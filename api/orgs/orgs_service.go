@@ -0,0 +1,336 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"simple-connect/api/auth"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OrganizationService struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrganizationService(pool *pgxpool.Pool) *OrganizationService {
+	return &OrganizationService{pool: pool}
+}
+
+const invitationColumns = "id, organization_id, email, role, invited_by, expires_at, created_at"
+
+func (or *OrganizationService) CreateOrganization(ctx context.Context, name string, ownerId string) (*Organization, error) {
+
+	tx, err := or.pool.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	org := &Organization{Name: name, Role: RoleOwner}
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO organizations (name) VALUES ($1) RETURNING id, created_at",
+		name).Scan(&org.ID, &org.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)",
+		org.ID, ownerId, RoleOwner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return org, tx.Commit(ctx)
+}
+
+func (or *OrganizationService) ListOrganizations(ctx context.Context, userId string) ([]Organization, error) {
+
+	rows, err := or.pool.Query(ctx,
+		`SELECT o.id, o.name, m.role, o.created_at FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name`,
+		userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Organization])
+}
+
+func (or *OrganizationService) GetOrganization(ctx context.Context, orgId string, userId string) (*Organization, error) {
+
+	rows, err := or.pool.Query(ctx,
+		`SELECT o.id, o.name, m.role, o.created_at FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE o.id = $1 AND m.user_id = $2`,
+		orgId, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[Organization])
+}
+
+func (or *OrganizationService) DeleteOrganization(ctx context.Context, orgId string) error {
+
+	_, err := or.pool.Exec(ctx, "DELETE FROM organizations WHERE id = $1", orgId)
+
+	return err
+}
+
+func (or *OrganizationService) ListMembers(ctx context.Context, orgId string) ([]Member, error) {
+
+	rows, err := or.pool.Query(ctx,
+		`SELECT m.user_id, u.email, m.role, m.created_at FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.created_at`,
+		orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Member])
+}
+
+// lockOwners locks the organization's owner rows so concurrent demotions can't remove every owner
+func lockOwners(ctx context.Context, tx pgx.Tx, orgId string) ([]string, error) {
+
+	rows, err := tx.Query(ctx,
+		"SELECT user_id FROM organization_members WHERE organization_id = $1 AND role = $2 FOR UPDATE",
+		orgId, RoleOwner)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// isLastOwner reports whether userId is the organization's only owner
+func isLastOwner(owners []string, userId string) bool {
+	return len(owners) == 1 && owners[0] == userId
+}
+
+// UpdateMemberRole changes the member's role, returning ErrLastOwner instead of demoting the only owner
+func (or *OrganizationService) UpdateMemberRole(ctx context.Context, orgId string, userId string, role string) (bool, error) {
+
+	tx, err := or.pool.Begin(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	owners, err := lockOwners(ctx, tx, orgId)
+
+	if err != nil {
+		return false, err
+	}
+
+	if role != RoleOwner && isLastOwner(owners, userId) {
+		return false, ErrLastOwner
+	}
+
+	res, err := tx.Exec(ctx,
+		"UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2",
+		orgId, userId, role)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, tx.Commit(ctx)
+}
+
+// RemoveMember removes the member, returning ErrLastOwner instead of removing the only owner
+func (or *OrganizationService) RemoveMember(ctx context.Context, orgId string, userId string) (bool, error) {
+
+	tx, err := or.pool.Begin(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	owners, err := lockOwners(ctx, tx, orgId)
+
+	if err != nil {
+		return false, err
+	}
+
+	if isLastOwner(owners, userId) {
+		return false, ErrLastOwner
+	}
+
+	res, err := tx.Exec(ctx,
+		"DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2",
+		orgId, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, tx.Commit(ctx)
+}
+
+// CreateInvitation stores the invitation, replacing pending invitations for the same email. Returns
+// ErrAlreadyMember when a user with that email is in the organization.
+func (or *OrganizationService) CreateInvitation(ctx context.Context, invitation Invitation, tokenHash string) (*Invitation, error) {
+
+	tx, err := or.pool.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var member bool
+
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM organization_members m JOIN users u ON u.id = m.user_id
+			WHERE m.organization_id = $1 AND lower(u.email) = lower($2)
+		)`,
+		invitation.OrganizationID, invitation.Email).Scan(&member)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	_, err = tx.Exec(ctx,
+		"DELETE FROM organization_invitations WHERE organization_id = $1 AND lower(email) = lower($2) AND accepted_at IS NULL",
+		invitation.OrganizationID, invitation.Email)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx,
+		"INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at) VALUES (@organization_id, @email, @role, @token_hash, @invited_by, @expires_at) RETURNING "+invitationColumns,
+		pgx.NamedArgs{
+			"organization_id": invitation.OrganizationID,
+			"email":           invitation.Email,
+			"role":            invitation.Role,
+			"token_hash":      tokenHash,
+			"invited_by":      invitation.InvitedBy,
+			"expires_at":      invitation.ExpiresAt,
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[Invitation])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, tx.Commit(ctx)
+}
+
+// ListInvitations returns the organization's pending invitations
+func (or *OrganizationService) ListInvitations(ctx context.Context, orgId string) ([]Invitation, error) {
+
+	rows, err := or.pool.Query(ctx,
+		"SELECT "+invitationColumns+" FROM organization_invitations WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP ORDER BY created_at DESC",
+		orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Invitation])
+}
+
+func (or *OrganizationService) DeleteInvitation(ctx context.Context, orgId string, id string) (bool, error) {
+
+	res, err := or.pool.Exec(ctx,
+		"DELETE FROM organization_invitations WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL",
+		id, orgId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// AcceptInvitation adds the user to the invitation's organization and returns its id. The invitation
+// must be pending and sent to the user's email. Users who are already members keep their role.
+func (or *OrganizationService) AcceptInvitation(ctx context.Context, tokenHash string, userId string) (string, error) {
+
+	tx, err := or.pool.Begin(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var id, orgId, email, role string
+
+	err = tx.QueryRow(ctx,
+		`SELECT id, organization_id, email, role FROM organization_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		FOR UPDATE`,
+		tokenHash).Scan(&id, &orgId, &email, &role)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", auth.ErrInvalidToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	var userEmail *string
+
+	err = tx.QueryRow(ctx, "SELECT email FROM users WHERE id = $1", userId).Scan(&userEmail)
+
+	if err != nil {
+		return "", err
+	}
+
+	if userEmail == nil || !strings.EqualFold(*userEmail, email) {
+		return "", ErrEmailMismatch
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT (organization_id, user_id) DO NOTHING",
+		orgId, userId, role)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE organization_invitations SET accepted_at = CURRENT_TIMESTAMP, accepted_by = $2 WHERE id = $1",
+		id, userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return orgId, tx.Commit(ctx)
+}
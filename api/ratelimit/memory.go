@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often full buckets are dropped from a MemoryStore
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	bucket
+	limit Limit
+}
+
+// MemoryStore keeps buckets in process, limits are per replica
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (ms *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if now.Sub(ms.lastSweep) >= memorySweepInterval {
+		ms.sweep(now)
+	}
+
+	b, ok := ms.buckets[key]
+
+	if !ok {
+		b = &memoryBucket{bucket: newBucket(limit, now)}
+		ms.buckets[key] = b
+	}
+
+	b.limit = limit
+
+	return b.take(limit, now), nil
+}
+
+func (ms *MemoryStore) sweep(now time.Time) {
+	for key, b := range ms.buckets {
+		if b.full(b.limit, now) {
+			delete(ms.buckets, key)
+		}
+	}
+
+	ms.lastSweep = now
+}
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/internal"
+
+	"connectrpc.com/connect"
+	"github.com/justinas/alice"
+)
+
+var ErrRateLimited = apierrors.New(connect.CodeResourceExhausted, apierrors.ReasonRateLimited, "rate limit exceeded")
+
+// Middleware limits the routes in Config.Routes and answers 429 Too Many Requests once a client is out of
+// tokens. It must wrap handlers registered on a ServeMux, which sets the pattern it matches on.
+func (l *Limiter) Middleware() alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := l.config.Routes[r.Pattern]
+
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := l.take(r.Context(), r.Pattern, rule, r.Header)
+
+			if err != nil {
+				l.failOpen(internal.RequestLogger(r), r.Pattern, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if res == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setHeaders(w.Header(), res)
+
+			if !res.Allowed {
+				apierrors.WriteJSON(w, r, ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Interceptor limits Connect procedures, refusing calls with CodeResourceExhausted. Place it before
+// auth.AuthInterceptor so floods are refused before their bearer tokens are looked up. ByUser then
+// reads the session, and bearer callers are best keyed by IP since their tokens aren't checked yet.
+func (l *Limiter) Interceptor() connect.Interceptor {
+	return &interceptor{limiter: l}
+}
+
+type interceptor struct {
+	limiter *Limiter
+}
+
+func (i *interceptor) rule(procedure string) (Rule, bool) {
+	if rule, ok := i.limiter.config.Procedures[procedure]; ok {
+		return rule, true
+	}
+
+	if i.limiter.config.DefaultProcedure != nil {
+		return *i.limiter.config.DefaultProcedure, true
+	}
+
+	return Rule{}, false
+}
+
+// check returns the result to report in headers, nil when the procedure isn't limited
+func (i *interceptor) check(ctx context.Context, spec connect.Spec, header http.Header) (*Result, error) {
+	rule, ok := i.rule(spec.Procedure)
+
+	if !ok {
+		return nil, nil
+	}
+
+	res, err := i.limiter.take(ctx, spec.Procedure, rule, header)
+
+	if err != nil {
+		i.limiter.failOpen(internal.RpcLogger(ctx), spec.Procedure, err)
+		return nil, nil
+	}
+
+	if res != nil && !res.Allowed {
+		connectErr := connect.NewError(connect.CodeResourceExhausted, ErrRateLimited)
+		setHeaders(connectErr.Meta(), res)
+		return nil, connectErr
+	}
+
+	return res, nil
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		res, err := i.check(ctx, req.Spec(), req.Header())
+
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := next(ctx, req)
+
+		if res == nil {
+			return response, err
+		}
+
+		if err != nil {
+			var connectErr *connect.Error
+
+			if errors.As(err, &connectErr) {
+				setHeaders(connectErr.Meta(), res)
+			}
+
+			return response, err
+		}
+
+		setHeaders(response.Header(), res)
+
+		return response, nil
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		res, err := i.check(ctx, conn.Spec(), conn.RequestHeader())
+
+		if err != nil {
+			return err
+		}
+
+		if res != nil {
+			setHeaders(conn.ResponseHeader(), res)
+		}
+
+		return next(ctx, conn)
+	}
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresPruneInterval is how often a PostgresStore deletes buckets that have refilled
+const postgresPruneInterval = 10 * time.Minute
+
+// refilledTokens is the tokens of the conflicting bucket b at @now, capped at @burst
+const refilledTokens = "LEAST(@burst::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM @now::timestamptz - b.updated_at)::float8, 0) * @rate::float8)"
+
+// takeQuery takes a token in a single statement. Buckets without one are left alone, so no row is returned
+// when the request is refused. full_at is when the bucket has refilled, NULL for limits that never refill.
+var takeQuery = fmt.Sprintf(`INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, full_at)
+	VALUES (@key, @burst::float8 - 1, @now::timestamptz, @now::timestamptz + interval '1 second' / NULLIF(@rate::float8, 0))
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = %[1]s - 1,
+		updated_at = GREATEST(b.updated_at, @now::timestamptz),
+		full_at = GREATEST(b.updated_at, @now::timestamptz) + (@burst::float8 + 1 - %[1]s) * interval '1 second' / NULLIF(@rate::float8, 0)
+	WHERE %[1]s >= 1
+	RETURNING tokens`, refilledTokens)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so limits hold across replicas. Each
+// limited request costs a statement, refused ones a second.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (ps *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	b := newBucket(limit, now)
+
+	if limit.Burst < 1 {
+		return b.result(limit, false), nil
+	}
+
+	err := ps.prune(ctx, now)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	err = ps.pool.QueryRow(ctx, takeQuery, pgx.NamedArgs{
+		"key":   key,
+		"now":   now,
+		"rate":  limit.Rate,
+		"burst": float64(limit.Burst),
+	}).Scan(&b.tokens)
+
+	if err == nil {
+		return b.result(limit, true), nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Result{}, err
+	}
+
+	err = ps.pool.QueryRow(ctx,
+		"SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1",
+		key).Scan(&b.tokens, &b.updated)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	b.refill(limit, now)
+
+	return b.result(limit, false), nil
+}
+
+// prune deletes refilled buckets at most once per postgresPruneInterval, a full bucket is the same as none
+func (ps *PostgresStore) prune(ctx context.Context, now time.Time) error {
+	ps.mu.Lock()
+
+	if now.Sub(ps.lastPrune) < postgresPruneInterval {
+		ps.mu.Unlock()
+		return nil
+	}
+
+	ps.lastPrune = now
+	ps.mu.Unlock()
+
+	_, err := ps.pool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE full_at <= $1", now)
+
+	return err
+}
@@ -0,0 +1,243 @@
+// Package ratelimit limits how often clients may call routes and procedures with token buckets,
+// keyed by client address, user or API key.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"simple-connect/api/auth"
+	"simple-connect/api/internal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// Limit is a token bucket holding Burst tokens, refilled at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute, all of which may be made at once
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// PerHour allows n requests an hour, all of which may be made at once
+func PerHour(n int) Limit {
+	return Limit{Rate: float64(n) / 3600, Burst: n}
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available, zero when Allowed
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take removes a token from the key's bucket if there is one, creating a full bucket for unseen keys
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// bucket is the token bucket arithmetic shared by the stores
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newBucket(limit Limit, now time.Time) bucket {
+	return bucket{tokens: float64(limit.Burst), updated: now}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+}
+
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	b.refill(limit, now)
+
+	allowed := b.tokens >= 1
+
+	if allowed {
+		b.tokens--
+	}
+
+	return b.result(limit, allowed)
+}
+
+// result reports the bucket's tokens after a token was taken, or refused
+func (b *bucket) result(limit Limit, allowed bool) Result {
+	res := Result{Limit: limit.Burst, Allowed: allowed}
+
+	if !allowed && limit.Rate > 0 {
+		res.RetryAfter = seconds(max(1-b.tokens, 0) / limit.Rate)
+	}
+
+	res.Remaining = int(b.tokens)
+
+	if limit.Rate > 0 {
+		res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+// full reports whether the bucket has refilled, so forgetting it changes nothing
+func (b *bucket) full(limit Limit, now time.Time) bool {
+	return b.tokens+now.Sub(b.updated).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+// KeyFunc names the client a request counts against, "" when it can't tell
+type KeyFunc func(ctx context.Context, header http.Header) string
+
+// ByIP keys requests by client address, it needs internal.ClientInfoMiddleware in front of the handler
+func ByIP(ctx context.Context, header http.Header) string {
+	if ip := internal.ClientInfoFromContext(ctx).IP; ip != "" {
+		return "ip:" + ip
+	}
+
+	return ""
+}
+
+// ByAPIKey keys requests by the personal access token they carry, without checking it is valid
+func ByAPIKey(ctx context.Context, header http.Header) string {
+	scheme, token, ok := strings.Cut(header.Get("Authorization"), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(token, auth.AccessTokenPrefix) {
+		return ""
+	}
+
+	return "key:" + auth.HashToken(token)
+}
+
+// ByUser keys requests by the principal auth.AuthInterceptor resolved, falling back to the session's user
+// where the interceptor hasn't run, such as in middleware or in an interceptor placed before it
+func ByUser(sessionManager *scs.SessionManager) KeyFunc {
+	return func(ctx context.Context, header http.Header) string {
+		userId := auth.UserIDFromContext(ctx)
+
+		if userId == "" {
+			userId = sessionManager.GetString(ctx, auth.SessionUserKey)
+		}
+
+		if userId == "" {
+			return ""
+		}
+
+		return "user:" + userId
+	}
+}
+
+// FirstOf uses the first key that isn't ""
+func FirstOf(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context, header http.Header) string {
+		for _, key := range keys {
+			if k := key(ctx, header); k != "" {
+				return k
+			}
+		}
+
+		return ""
+	}
+}
+
+type Rule struct {
+	Limit Limit
+	// Key defaults to ByIP. Requests without a key are not limited.
+	Key KeyFunc
+}
+
+type Config struct {
+	// Routes limits REST routes by their ServeMux pattern, e.g. "POST /auth/signup/{$}"
+	Routes map[string]Rule
+	// Procedures limits Connect procedures by name, e.g. apiv1connect.AuthServiceVerifyMFAProcedure
+	Procedures map[string]Rule
+	// DefaultProcedure applies to procedures without a rule of their own, nil leaves them unlimited. With a
+	// PostgresStore every call then costs a statement on top of its own queries, roughly doubling the
+	// database load of procedures that make a single query.
+	DefaultProcedure *Rule
+}
+
+// Limiter enforces a Config through Middleware and Interceptor. Each route and procedure has its own buckets.
+// Requests are let through when the store fails, so an outage of it doesn't take the API down too.
+type Limiter struct {
+	store  Store
+	config Config
+	now    func() time.Time
+
+	storeFailures atomic.Int64
+}
+
+func New(store Store, config Config) *Limiter {
+	return &Limiter{store: store, config: config, now: time.Now}
+}
+
+// take applies the rule to the request, returning nil when it isn't limited
+func (l *Limiter) take(ctx context.Context, name string, rule Rule, header http.Header) (*Result, error) {
+	keyFunc := rule.Key
+
+	if keyFunc == nil {
+		keyFunc = ByIP
+	}
+
+	key := keyFunc(ctx, header)
+
+	if key == "" {
+		return nil, nil
+	}
+
+	res, err := l.store.Take(ctx, name+"|"+key, rule.Limit, l.now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// StoreFailures is how many requests were let through unlimited because the store failed
+func (l *Limiter) StoreFailures() int64 {
+	return l.storeFailures.Load()
+}
+
+// failOpen counts and logs a store failure, the request goes ahead without a limit
+func (l *Limiter) failOpen(logger *slog.Logger, name string, err error) {
+	failures := l.storeFailures.Add(1)
+
+	logger.Error("rate limit store failed, allowing the request",
+		slog.String("Error", err.Error()),
+		slog.String("limit", name),
+		slog.Int64("storeFailures", failures))
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// setHeaders writes the RateLimit-* headers, and Retry-After when the request was refused
+func setHeaders(header http.Header, res *Result) {
+	header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	header.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+	if !res.Allowed {
+		header.Set("Retry-After", ceilSeconds(res.RetryAfter))
+	}
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"simple-connect/api/internal"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := PerMinute(2)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	res, err := store.Take(ctx, "key", limit, now)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, _ = store.Take(ctx, "key", limit, now)
+	assert.True(t, res.Allowed)
+
+	res, _ = store.Take(ctx, "key", limit, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 30*time.Second, res.RetryAfter)
+	assert.Equal(t, time.Minute, res.Reset)
+
+	res, _ = store.Take(ctx, "other", limit, now)
+	assert.True(t, res.Allowed, "keys have their own buckets")
+
+	res, _ = store.Take(ctx, "key", limit, now.Add(30*time.Second))
+	assert.True(t, res.Allowed, "tokens refill over time")
+}
+
+func TestLimiter(t *testing.T) {
+
+	t.Parallel()
+
+	limiter := New(NewMemoryStore(), Config{
+		Routes: map[string]Rule{
+			"POST /signup/{$}": {Limit: PerMinute(1)},
+		},
+		DefaultProcedure: &Rule{Limit: PerMinute(1)},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := alice.New(internal.ClientInfoMiddleware(false), internal.LoggingMiddleware(*logger), limiter.Middleware())
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /signup/{$}", chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.Handle("GET /unlimited/{$}", chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	healthPath, healthHandler := apiv1connect.NewHealthServiceHandler(&apiv1connect.UnimplementedHealthServiceHandler{}, connect.WithInterceptors(limiter.Interceptor()))
+	mux.Handle(healthPath, chain.Then(healthHandler))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("routes answer 429 with headers", func(t *testing.T) {
+		res, err := http.Post(server.URL+"/signup/", "application/json", nil)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "1", res.Header.Get("RateLimit-Limit"))
+		assert.Equal(t, "0", res.Header.Get("RateLimit-Remaining"))
+
+		res, err = http.Post(server.URL+"/signup/", "application/json", nil)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "60", res.Header.Get("Retry-After"))
+	})
+
+	t.Run("routes without a rule are not limited", func(t *testing.T) {
+		for range 3 {
+			res, err := http.Get(server.URL + "/unlimited/")
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Empty(t, res.Header.Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("procedures are refused with resource exhausted", func(t *testing.T) {
+		client := apiv1connect.NewHealthServiceClient(http.DefaultClient, server.URL)
+
+		_, err := client.Check(context.Background(), connect.NewRequest(&v1.Empty{}))
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+
+		_, err = client.Check(context.Background(), connect.NewRequest(&v1.Empty{}))
+		require.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, "60", connectErr.Meta().Get("Retry-After"))
+	})
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func TestLimiterFailsOpen(t *testing.T) {
+
+	t.Parallel()
+
+	limiter := New(failingStore{}, Config{DefaultProcedure: &Rule{Limit: PerMinute(1)}})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := alice.New(internal.ClientInfoMiddleware(false), internal.LoggingMiddleware(*logger))
+
+	mux := http.NewServeMux()
+	healthPath, healthHandler := apiv1connect.NewHealthServiceHandler(&apiv1connect.UnimplementedHealthServiceHandler{}, connect.WithInterceptors(limiter.Interceptor()))
+	mux.Handle(healthPath, chain.Then(healthHandler))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := apiv1connect.NewHealthServiceClient(http.DefaultClient, server.URL)
+
+	for range 2 {
+		_, err := client.Check(context.Background(), connect.NewRequest(&v1.Empty{}))
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+	}
+
+	assert.Equal(t, int64(2), limiter.StoreFailures())
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"log/slog"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"github.com/justinas/alice"
+)
+
+// PermissionReflection lets users describe the API through gRPC reflection in production, where it
+// isn't public
+const PermissionReflection = "reflection.read"
+
+// apiServices are the services of gen/proto/api/v1
+var apiServices = []string{
+	apiv1connect.HealthServiceName,
+	apiv1connect.AuthServiceName,
+	apiv1connect.ProtectedAuthServiceName,
+	apiv1connect.PasskeyServiceName,
+	apiv1connect.ProtectedPasskeyServiceName,
+	apiv1connect.AuthorizationServiceName,
+	apiv1connect.OrganizationServiceName,
+	apiv1connect.AuditServiceName,
+	apiv1connect.AccountLockoutServiceName,
+}
+
+// mountReflection serves gRPC reflection v1 and v1alpha for apiServices, so grpcurl and Postman can
+// discover them. Gate it with options, it describes every procedure.
+func (s *Server) mountReflection(mw alice.Chain, options ...connect.HandlerOption) {
+	reflector := grpcreflect.NewStaticReflector(apiServices...)
+
+	reflectionPath, reflectionHandler := grpcreflect.NewHandlerV1(reflector, options...)
+	s.logger.Debug("Mounting reflection handler at", slog.String("path", reflectionPath))
+	s.mux.Handle(reflectionPath, mw.Then(reflectionHandler))
+
+	// Older clients, grpcurl among them, still ask for v1alpha
+	reflectionAlphaPath, reflectionAlphaHandler := grpcreflect.NewHandlerV1Alpha(reflector, options...)
+	s.logger.Debug("Mounting reflection handler at", slog.String("path", reflectionAlphaPath))
+	s.mux.Handle(reflectionAlphaPath, mw.Then(reflectionAlphaHandler))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"simple-connect/api/auth"
+	"simple-connect/api/authz"
+	"simple-connect/api/internal/testserver"
+	"testing"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestReflection(t *testing.T) {
+
+	t.Parallel()
+
+	sessionManager := auth.NewMemorySessionManager(false, "")
+	authorizer := authz.NewAuthorizer(authz.NewMemoryStore([]authz.Role{{Name: "admin", Permissions: []string{PermissionReflection}}}, map[string][]string{
+		"admin-user": {"admin"},
+	}))
+
+	server := testserver.New(t, sessionManager, func(r *http.Request, userId string) error {
+		return auth.Login(r, sessionManager, auth.SessionData{UserID: userId})
+	})
+
+	s := &Server{mux: server.Mux, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s.mountReflection(alice.New(), connect.WithInterceptors(
+		auth.NewAuthInterceptor(sessionManager, nil),
+		authz.NewPermissionInterceptor(authorizer, PermissionReflection),
+	))
+
+	listServices := func(userId string) ([]protoreflect.FullName, error) {
+		client := server.NewClient()
+
+		if userId != "" {
+			client = server.ClientFor(userId)
+		}
+
+		stream := grpcreflect.NewClient(client, server.URL, connect.WithGRPC()).NewStream(context.Background())
+		defer stream.Close()
+
+		return stream.ListServices()
+	}
+
+	t.Run("admins can list the api", func(t *testing.T) {
+		services, err := listServices("admin-user")
+		require.NoError(t, err)
+
+		var names []string
+
+		for _, service := range services {
+			names = append(names, string(service))
+		}
+
+		assert.ElementsMatch(t, apiServices, names)
+	})
+
+	t.Run("other users can't", func(t *testing.T) {
+		_, err := listServices("")
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+
+		_, err = listServices("someone")
+		assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+	})
+}
@@ -0,0 +1,259 @@
+// Package secrets encrypts values at rest with envelope encryption. Each record gets its own
+// random data key which encrypts the values, and the data key is wrapped by a key encryption
+// key from the key ring. Rotating the key ring only requires rewrapping data keys.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const keySize = 32
+
+var ErrNoKeys = errors.New("no encryption keys configured")
+var ErrUnknownKey = errors.New("unknown encryption key")
+var ErrDecrypt = errors.New("unable to decrypt value")
+
+// Envelope is stored alongside the encrypted values
+type Envelope struct {
+	// KeyID names the key ring key that wrapped DataKey
+	KeyID string
+	// DataKey is the record's data key, encrypted by the key ring key
+	DataKey []byte
+}
+
+// KeyRing holds the key encryption keys by id. New data keys are always wrapped with the
+// primary key, older keys are kept so existing records can still be decrypted.
+type KeyRing struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+func NewKeyRing(primary string, keys map[string][]byte) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the key ring", primary)
+	}
+
+	kr := &KeyRing{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		kr.keys[id] = aead
+	}
+
+	return kr, nil
+}
+
+// KeyRingFromEnv reads TOKEN_ENCRYPTION_KEYS, a comma separated list of id:base64key pairs with
+// 32 byte keys, and TOKEN_ENCRYPTION_PRIMARY_KEY, which defaults to the last key in the list.
+func KeyRingFromEnv() (*KeyRing, error) {
+	keys := map[string][]byte{}
+	primary := os.Getenv("TOKEN_ENCRYPTION_PRIMARY_KEY")
+	last := ""
+
+	for _, entry := range strings.Split(os.Getenv("TOKEN_ENCRYPTION_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+
+		if !ok || id == "" {
+			return nil, errors.New("TOKEN_ENCRYPTION_KEYS entries must be id:base64key")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		keys[id] = key
+		last = id
+	}
+
+	if primary == "" {
+		primary = last
+	}
+
+	return NewKeyRing(primary, keys)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("keys must be %d bytes", keySize)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	_, err := io.ReadFull(rand.Reader, nonce)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// unwrap decrypts the envelope's data key
+func (kr *KeyRing) unwrap(env Envelope) ([]byte, error) {
+	kek, ok := kr.keys[env.KeyID]
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+
+	return open(kek, env.DataKey, []byte(env.KeyID))
+}
+
+// Encrypt encrypts the values under a new data key. Empty values stay empty so optional
+// columns remain distinguishable. Ciphertexts are base64 encoded.
+func (kr *KeyRing) Encrypt(values ...string) (Envelope, []string, error) {
+	dataKey := make([]byte, keySize)
+
+	_, err := io.ReadFull(rand.Reader, dataKey)
+
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	wrapped, err := seal(kr.keys[kr.primary], dataKey, []byte(kr.primary))
+
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+
+	ciphertexts := make([]string, len(values))
+
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+
+		sealed, err := seal(aead, []byte(v), nil)
+
+		if err != nil {
+			return Envelope{}, nil, err
+		}
+
+		ciphertexts[i] = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	return Envelope{KeyID: kr.primary, DataKey: wrapped}, ciphertexts, nil
+}
+
+// Decrypt reverses Encrypt for values sealed under the envelope's data key
+func (kr *KeyRing) Decrypt(env Envelope, ciphertexts ...string) ([]string, error) {
+	dataKey, err := kr.unwrap(env)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(ciphertexts))
+
+	for i, c := range ciphertexts {
+		if c == "" {
+			continue
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(c)
+
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+
+		plaintext, err := open(aead, sealed, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = string(plaintext)
+	}
+
+	return values, nil
+}
+
+// NeedsRewrap reports whether the envelope was wrapped by a key other than the primary
+func (kr *KeyRing) NeedsRewrap(env Envelope) bool {
+	return env.KeyID != kr.primary
+}
+
+// Rewrap re-encrypts the envelope's data key with the primary key, leaving the values untouched
+func (kr *KeyRing) Rewrap(env Envelope) (Envelope, error) {
+	dataKey, err := kr.unwrap(env)
+
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	wrapped, err := seal(kr.keys[kr.primary], dataKey, []byte(kr.primary))
+
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{KeyID: kr.primary, DataKey: wrapped}, nil
+}
+
+// PrimaryKeyID is the id of the key new data keys are wrapped with
+func (kr *KeyRing) PrimaryKeyID() string {
+	return kr.primary
+}
@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRing(t *testing.T) {
+
+	t.Parallel()
+
+	oldKey := bytes.Repeat([]byte{1}, keySize)
+	newKey := bytes.Repeat([]byte{2}, keySize)
+
+	old, err := NewKeyRing("old", map[string][]byte{"old": oldKey})
+	require.NoError(t, err)
+
+	rotated, err := NewKeyRing("new", map[string][]byte{"old": oldKey, "new": newKey})
+	require.NoError(t, err)
+
+	env, ciphertexts, err := old.Encrypt("access-token", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "old", env.KeyID)
+	assert.NotContains(t, ciphertexts[0], "access-token")
+	assert.Empty(t, ciphertexts[1])
+
+	t.Run("older keys still decrypt", func(t *testing.T) {
+		values, err := rotated.Decrypt(env, ciphertexts...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"access-token", ""}, values)
+	})
+
+	t.Run("rewrap moves the data key to the primary key", func(t *testing.T) {
+		assert.True(t, rotated.NeedsRewrap(env))
+
+		rewrapped, err := rotated.Rewrap(env)
+		require.NoError(t, err)
+		assert.Equal(t, "new", rewrapped.KeyID)
+		assert.False(t, rotated.NeedsRewrap(rewrapped))
+
+		values, err := rotated.Decrypt(rewrapped, ciphertexts...)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", values[0])
+
+		// The old key alone can no longer open it
+		_, err = old.Decrypt(rewrapped, ciphertexts...)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("tampered values fail to decrypt", func(t *testing.T) {
+		sealed, _ := base64.StdEncoding.DecodeString(ciphertexts[0])
+		sealed[len(sealed)-1] ^= 1
+
+		_, err := old.Decrypt(env, base64.StdEncoding.EncodeToString(sealed))
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("data keys don't move between key ids", func(t *testing.T) {
+		_, err := rotated.Decrypt(Envelope{KeyID: "new", DataKey: env.DataKey}, ciphertexts...)
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+}
+
+func TestKeyRingFromEnv(t *testing.T) {
+	t.Setenv("TOKEN_ENCRYPTION_KEYS", "a:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, keySize))+",b:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, keySize)))
+	t.Setenv("TOKEN_ENCRYPTION_PRIMARY_KEY", "")
+
+	kr, err := KeyRingFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "b", kr.PrimaryKeyID())
+
+	t.Setenv("TOKEN_ENCRYPTION_KEYS", "short:"+base64.StdEncoding.EncodeToString([]byte("short")))
+
+	_, err = KeyRingFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("TOKEN_ENCRYPTION_KEYS", "")
+
+	_, err = KeyRingFromEnv()
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
@@ -5,17 +5,35 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"simple-connect/api/apierrors"
+	"simple-connect/api/audit"
 	"simple-connect/api/auth"
+	"simple-connect/api/authz"
 	"simple-connect/api/data"
+	"simple-connect/api/health"
 	"simple-connect/api/internal"
+	"simple-connect/api/mail"
+	"simple-connect/api/orgs"
+	"simple-connect/api/ratelimit"
+	"simple-connect/api/secrets"
+	"simple-connect/api/validation"
+	"simple-connect/gen/grpc/health/v1/healthv1connect"
 	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"simple-connect/migrations"
+	"time"
 
+	"connectrpc.com/connect"
 	"github.com/alexedwards/scs/v2"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
+// healthCheckInterval is how often dependencies are checked, and so how quickly readiness follows them
+const healthCheckInterval = 5 * time.Second
+
 type Server struct {
 	mux            *http.ServeMux
 	srv            *http2.Server
@@ -24,6 +42,18 @@ type Server struct {
 	Addr           string
 	allowedHosts   []string
 	pool           *pgxpool.Pool
+	mailer         mail.Mailer
+	appURL         string
+	webAuthn       *webauthn.WebAuthn
+	providers      *auth.ProviderRegistry
+	keyRing        *secrets.KeyRing
+	trustProxy     bool
+	rateLimitStore ratelimit.Store
+	passwordPolicy auth.PasswordPolicy
+	passwordHasher auth.PasswordHasher
+	validator      *validation.Validator
+	health         *health.Monitor
+	isProd         bool
 	ctx            context.Context
 }
 
@@ -31,6 +61,17 @@ type ServerConfig struct {
 	Port         string
 	LogLevel     slog.Level
 	AllowedHosts []string
+	// AppURL is the base URL of the frontend, used to build links sent by email and where provider sign ins land
+	AppURL string
+	// WebAuthnRPID is the passkey relying party id, defaults to the AppURL host
+	WebAuthnRPID string
+	// TrustProxy takes client addresses from the last X-Forwarded-For entry, enable it only behind a proxy that appends it
+	TrustProxy bool
+	// MemoryRateLimits keeps rate limit buckets in process instead of postgres, for single replica deployments
+	MemoryRateLimits bool
+	// BreachedPasswordsDir holds Pwned Passwords range files that new passwords are screened against,
+	// screening is off when it is empty
+	BreachedPasswordsDir string
 }
 
 func NewServer(cfg ServerConfig, isProd bool) (*Server, error) {
@@ -55,6 +96,78 @@ func NewServer(cfg ServerConfig, isProd bool) (*Server, error) {
 
 	logger := internal.BootstrapLogger(cfg.LogLevel, logFormat, !isProd)
 
+	rpId := cfg.WebAuthnRPID
+
+	if rpId == "" {
+		appURL, err := url.Parse(cfg.AppURL)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rpId = appURL.Hostname()
+	}
+
+	webAuthn, err := auth.NewWebAuthn(rpId, "simple-connect", []string{cfg.AppURL})
+
+	if err != nil {
+		return nil, err
+	}
+
+	keyRing, err := secrets.KeyRingFromEnv()
+
+	if err != nil {
+		return nil, fmt.Errorf("token encryption keys: %w", err)
+	}
+
+	providers := auth.NewProviderRegistry()
+
+	for _, providerCfg := range auth.ProviderConfigsFromEnv() {
+		err = providers.Register(providerCfg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Debug("Registered OIDC provider", slog.String("provider", providerCfg.Name), slog.String("issuer", providerCfg.Issuer))
+	}
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewPostgresStore(pool)
+
+	if cfg.MemoryRateLimits {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+
+	passwordHasher, err := auth.PasswordHasherFromEnv()
+
+	if err != nil {
+		return nil, fmt.Errorf("password hasher: %w", err)
+	}
+
+	passwordPolicy := auth.DefaultPasswordPolicy
+
+	if cfg.BreachedPasswordsDir != "" {
+		passwordPolicy.Breached = auth.NewKAnonymityChecker(auth.DirRangeSource(cfg.BreachedPasswordsDir))
+	}
+
+	validator, err := validation.NewValidator()
+
+	if err != nil {
+		return nil, fmt.Errorf("request validator: %w", err)
+	}
+
+	migrationsCheck, err := health.MigrationsCheck(pool, migrations.FS)
+
+	if err != nil {
+		return nil, fmt.Errorf("migrations health check: %w", err)
+	}
+
+	monitor := health.NewMonitor(logger,
+		health.PostgresCheck(pool),
+		health.SessionStoreCheck(sessionManager.Store),
+		migrationsCheck,
+	)
+
 	return &Server{
 		mux:            http.NewServeMux(),
 		srv:            &http2.Server{},
@@ -63,53 +176,163 @@ func NewServer(cfg ServerConfig, isProd bool) (*Server, error) {
 		Addr:           fmt.Sprintf(":%s", cfg.Port),
 		allowedHosts:   cfg.AllowedHosts,
 		pool:           pool,
+		mailer:         mail.NewMailerFromEnv(logger),
+		appURL:         cfg.AppURL,
+		webAuthn:       webAuthn,
+		providers:      providers,
+		keyRing:        keyRing,
+		trustProxy:     cfg.TrustProxy,
+		rateLimitStore: rateLimitStore,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		validator:      validator,
+		health:         monitor,
+		isProd:         isProd,
 		ctx:            ctx,
 	}, nil
 }
 
 func (s *Server) MountHandlers() {
 
+	limiter := ratelimit.New(s.rateLimitStore, ratelimit.Config{
+		Routes: map[string]ratelimit.Rule{
+			"POST /auth/signup/{$}":     {Limit: ratelimit.PerHour(10)},
+			"POST /auth/login/{$}":      {Limit: ratelimit.PerMinute(20)},
+			"POST /auth/magic-link/{$}": {Limit: ratelimit.PerMinute(20)},
+		},
+		Procedures: map[string]ratelimit.Rule{
+			apiv1connect.AuthServiceSignupProcedure:               {Limit: ratelimit.PerHour(10)},
+			apiv1connect.AuthServiceLoginProcedure:                {Limit: ratelimit.PerMinute(20)},
+			apiv1connect.AuthServiceRequestPasswordResetProcedure: {Limit: ratelimit.PerHour(5)},
+			apiv1connect.AuthServiceVerifyMFAProcedure:            {Limit: ratelimit.PerMinute(10)},
+			apiv1connect.AuthServiceRequestMagicLinkProcedure:     {Limit: ratelimit.PerHour(5)},
+		},
+		DefaultProcedure: &ratelimit.Rule{
+			Limit: ratelimit.PerMinute(300),
+			// Bearer tokens aren't checked yet when the limiter runs, so their callers are keyed by IP
+			Key: ratelimit.FirstOf(ratelimit.ByUser(s.sessionManager), ratelimit.ByIP),
+		},
+	})
+
 	rootMw := internal.RootMiddleware(*s.logger, internal.MiddlewareConfig{
 		CorsOrigin:     s.allowedHosts[0],
 		SessionManager: s.sessionManager,
+		TrustProxy:     s.trustProxy,
+		RateLimit:      limiter.Middleware(),
 	})
 
+	authStore := auth.NewAuthService(s.pool, s.keyRing, s.passwordHasher)
+	authzStore := authz.NewAuthorizationService(s.pool)
+	orgStore := orgs.NewOrganizationService(s.pool)
+	invitations := orgs.NewInvitations(orgStore, s.mailer, s.appURL+"/accept-invitation")
+	auditStore := audit.NewAuditService(s.pool)
+	recorder := audit.NewRecorder(auditStore, s.logger)
+
+	authorizer := authz.NewAuthorizer(authzStore)
+
 	authMw := rootMw.Append(auth.RequireAuthMiddleWare(s.sessionManager))
+	rpcOpts := connect.WithInterceptors(
+		apierrors.NewInterceptor(),
+		// Malformed requests are refused before they cost a rate limit token or a lookup
+		validation.NewInterceptor(s.validator),
+		limiter.Interceptor(),
+		auth.NewAuthInterceptor(s.sessionManager, authStore),
+		authz.NewInterceptor(authorizer),
+		auth.NewRequireVerifiedInterceptor(authStore,
+			apiv1connect.OrganizationServiceCreateOrganizationProcedure,
+			apiv1connect.OrganizationServiceCreateInvitationProcedure,
+		),
+	)
 
-	healthPath, healthHandler := apiv1connect.NewHealthServiceHandler(&HealthService{})
+	healthPath, healthHandler := apiv1connect.NewHealthServiceHandler(NewHealthService(s.health), rpcOpts)
 	s.logger.Debug("Mounting health handler at", slog.String("path", healthPath))
 	s.mux.Handle(healthPath, rootMw.Then(healthHandler))
 
-	authStore := auth.NewAuthService(s.pool)
-	authHandler := auth.NewAuthHandler(authStore, s.sessionManager)
-	authPath, authRpc := apiv1connect.NewAuthServiceHandler(authHandler)
+	// Probes skip the root middleware, kubelet calls them too often for them to be logged or limited
+	grpcHealth := health.NewService(s.health, apiServices...)
+	grpcHealthPath, grpcHealthHandler := healthv1connect.NewHealthHandler(grpcHealth, connect.WithInterceptors(apierrors.NewInterceptor()))
+	s.logger.Debug("Mounting grpc health handler at", slog.String("path", grpcHealthPath))
+	s.mux.Handle(grpcHealthPath, grpcHealthHandler)
+	s.mux.HandleFunc("GET /livez", s.health.Liveness)
+	s.mux.HandleFunc("GET /readyz", s.health.Readiness)
+
+	if s.isProd {
+		s.mountReflection(rootMw, connect.WithInterceptors(
+			apierrors.NewInterceptor(),
+			limiter.Interceptor(),
+			auth.NewAuthInterceptor(s.sessionManager, authStore),
+			authz.NewPermissionInterceptor(authorizer, PermissionReflection),
+		))
+	} else {
+		s.mountReflection(rootMw, connect.WithInterceptors(apierrors.NewInterceptor()))
+	}
+
+	verifier := auth.NewEmailVerifier(authStore, s.mailer, s.appURL+"/verify-email")
+	resetter := auth.NewPasswordResetter(authStore, s.mailer, s.appURL+"/reset-password", s.passwordPolicy)
+	throttle := auth.NewLoginThrottle(authStore, auth.DefaultThrottleConfig)
+	magicLinks := auth.NewMagicLinker(authStore, s.mailer, s.appURL+"/magic-link")
+	authHandler := auth.NewAuthHandler(authStore, s.sessionManager, verifier, resetter, invitations, recorder, throttle, s.passwordPolicy, magicLinks, s.validator)
+	authPath, authRpc := apiv1connect.NewAuthServiceHandler(authHandler, rpcOpts)
 	s.logger.Debug("Mounting auth handler at", slog.String("path", authPath))
 	s.mux.Handle(authPath, rootMw.Then(authRpc))
-	s.mux.Handle("POST /auth/login/{$}", rootMw.ThenFunc(authHandler.Login))
-	s.mux.Handle("POST /auth/signup/{$}", rootMw.ThenFunc(authHandler.Signup))
+	s.mux.Handle("POST /auth/login/{$}", rootMw.ThenFunc(authHandler.HandleLogin))
+	s.mux.Handle("POST /auth/signup/{$}", rootMw.ThenFunc(authHandler.HandleSignup))
+	s.mux.Handle("POST /auth/magic-link/{$}", rootMw.ThenFunc(authHandler.VerifyMagicLink))
+	s.mux.Handle("POST /auth/logout/{$}", authMw.ThenFunc(authHandler.HandleLogout))
 
+	// State and nonce cookies follow the session cookie, so they are Secure in production
 	providerHandler := &auth.ProviderHandler{
-		Domain:         "",
-		Secure:         false,
+		Domain:         s.sessionManager.Cookie.Domain,
+		Secure:         s.sessionManager.Cookie.Secure,
 		AuthStore:      authStore,
 		SessionManager: s.sessionManager,
-		RedirectURI:    "http://localhost:8000/health",
+		RedirectURI:    s.appURL,
+		MFARedirectURI: s.appURL + "/mfa",
+		Providers:      s.providers,
+		Invitations:    invitations,
+		Audit:          recorder,
 	}
 
-	s.mux.Handle("GET /auth/google/{$}", rootMw.ThenFunc(providerHandler.HandleGoogleAuth))
-	s.mux.Handle("GET /auth/google/callback/{$}", rootMw.ThenFunc(providerHandler.HandleGoogleCallback))
+	s.mux.Handle("GET /auth/{provider}/{$}", rootMw.ThenFunc(providerHandler.HandleAuth))
+	s.mux.Handle("GET /auth/{provider}/callback/{$}", rootMw.ThenFunc(providerHandler.HandleCallback))
 
-	protectedAuthHandler := auth.NewProtectedAuthHandler(authStore, s.sessionManager)
-	protectedAuthPath, protectedAuthRpc := apiv1connect.NewProtectedAuthServiceHandler(protectedAuthHandler)
+	protectedAuthHandler := auth.NewProtectedAuthHandler(authStore, s.sessionManager, verifier, s.providers, recorder)
+	protectedAuthPath, protectedAuthRpc := apiv1connect.NewProtectedAuthServiceHandler(protectedAuthHandler, rpcOpts)
 	s.logger.Debug("Mounting protected auth handler at", slog.String("path", protectedAuthPath))
-	s.mux.Handle(protectedAuthPath, authMw.Then(protectedAuthRpc))
-	s.mux.Handle("POST /auth/logout/{$}", authMw.ThenFunc(protectedAuthHandler.Logout))
+	s.mux.Handle(protectedAuthPath, rootMw.Then(protectedAuthRpc))
+
+	passkeyHandler := auth.NewPasskeyHandler(authStore, s.sessionManager, s.webAuthn)
+	passkeyPath, passkeyRpc := apiv1connect.NewPasskeyServiceHandler(passkeyHandler, rpcOpts)
+	s.logger.Debug("Mounting passkey handler at", slog.String("path", passkeyPath))
+	s.mux.Handle(passkeyPath, rootMw.Then(passkeyRpc))
+
+	protectedPasskeyPath, protectedPasskeyRpc := apiv1connect.NewProtectedPasskeyServiceHandler(passkeyHandler, rpcOpts)
+	s.logger.Debug("Mounting protected passkey handler at", slog.String("path", protectedPasskeyPath))
+	s.mux.Handle(protectedPasskeyPath, rootMw.Then(protectedPasskeyRpc))
+
+	authzPath, authzRpc := apiv1connect.NewAuthorizationServiceHandler(authz.NewHandler(authzStore), rpcOpts)
+	s.logger.Debug("Mounting authorization handler at", slog.String("path", authzPath))
+	s.mux.Handle(authzPath, rootMw.Then(authzRpc))
+
+	orgPath, orgRpc := apiv1connect.NewOrganizationServiceHandler(orgs.NewHandler(orgStore, s.sessionManager, invitations), rpcOpts)
+	s.logger.Debug("Mounting organization handler at", slog.String("path", orgPath))
+	s.mux.Handle(orgPath, rootMw.Then(orgRpc))
+
+	auditPath, auditRpc := apiv1connect.NewAuditServiceHandler(audit.NewHandler(auditStore, authorizer), rpcOpts)
+	s.logger.Debug("Mounting audit handler at", slog.String("path", auditPath))
+	s.mux.Handle(auditPath, rootMw.Then(auditRpc))
+
+	lockoutPath, lockoutRpc := apiv1connect.NewAccountLockoutServiceHandler(auth.NewAccountLockoutHandler(authStore, throttle, recorder), rpcOpts)
+	s.logger.Debug("Mounting account lockout handler at", slog.String("path", lockoutPath))
+	s.mux.Handle(lockoutPath, rootMw.Then(lockoutRpc))
 }
 
 func (s *Server) Start() error {
 
 	s.MountHandlers()
 
+	go s.health.Run(s.ctx, healthCheckInterval)
+
 	s.logger.Info("Starting server at", slog.String("addr", s.Addr))
 
 	return http.ListenAndServe(s.Addr, h2c.NewHandler(s.mux, s.srv))
@@ -118,6 +341,8 @@ func (s *Server) Start() error {
 func (s *Server) Cleanup(ctx context.Context) error {
 	s.logger.Info("Shutting down server")
 
+	s.health.Shutdown()
+
 	s.pool.Close()
 	return nil
 }
@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"context"
+	"errors"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+)
+
+// Validator checks messages against their buf.validate constraints
+type Validator struct {
+	validator protovalidate.Validator
+}
+
+func NewValidator() (*Validator, error) {
+	validator, err := protovalidate.New()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{validator: validator}, nil
+}
+
+// Validate returns an InvalidArgument error carrying a buf.validate.Violations detail, with one
+// violation per broken field constraint, or nil when msg is valid
+func (v *Validator) Validate(msg any) error {
+	message, ok := msg.(proto.Message)
+
+	if !ok {
+		return nil
+	}
+
+	err := v.validator.Validate(message)
+
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *protovalidate.ValidationError
+
+	if !errors.As(err, &validationErr) {
+		// The constraints themselves are broken, that is our fault rather than the caller's
+		return connect.NewError(connect.CodeInternal, err)
+	}
+
+	connectErr := connect.NewError(connect.CodeInvalidArgument, err)
+
+	if detail, detailErr := connect.NewErrorDetail(validationErr.ToProto()); detailErr == nil {
+		connectErr.AddDetail(detail)
+	}
+
+	return connectErr
+}
+
+// Interceptor validates every request message a handler receives
+type Interceptor struct {
+	validator *Validator
+}
+
+func NewInterceptor(validator *Validator) *Interceptor {
+	return &Interceptor{validator: validator}
+}
+
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		err := i.validator.Validate(req.Any())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ctx, &validatingConn{StreamingHandlerConn: conn, validator: i.validator})
+	}
+}
+
+// validatingConn validates each message a stream receives
+type validatingConn struct {
+	connect.StreamingHandlerConn
+	validator *Validator
+}
+
+func (c *validatingConn) Receive(msg any) error {
+	err := c.StreamingHandlerConn.Receive(msg)
+
+	if err != nil {
+		return err
+	}
+
+	return c.validator.Validate(msg)
+}
@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	v1 "simple-connect/gen/proto/api/v1"
+	"simple-connect/gen/proto/api/v1/apiv1connect"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor(t *testing.T) {
+
+	t.Parallel()
+
+	validator, err := NewValidator()
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewAuthServiceHandler(&apiv1connect.UnimplementedAuthServiceHandler{}, connect.WithInterceptors(NewInterceptor(validator))))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := apiv1connect.NewAuthServiceClient(http.DefaultClient, server.URL)
+
+	t.Run("invalid messages never reach the handler", func(t *testing.T) {
+		_, err := client.Login(context.Background(), connect.NewRequest(&v1.LoginRequest{Email: "not an email"}))
+		require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		require.Len(t, connectErr.Details(), 1)
+
+		detail, err := connectErr.Details()[0].Value()
+		require.NoError(t, err)
+
+		var fields []string
+
+		for _, violation := range detail.(*validate.Violations).Violations {
+			fields = append(fields, protovalidate.FieldPathString(violation.GetField()))
+		}
+
+		assert.ElementsMatch(t, []string{"email", "password"}, fields)
+	})
+
+	t.Run("valid messages pass through", func(t *testing.T) {
+		_, err := client.Login(context.Background(), connect.NewRequest(&v1.LoginRequest{Email: "jane@example.com", Password: "secret"}))
+		assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+	})
+}
@@ -50,6 +50,11 @@ func main() {
 	// Server
 	appEnv := os.Getenv("APP_ENV")
 	allowedHosts := os.Getenv("ALLOWED_HOSTS")
+	appURL := os.Getenv("APP_URL")
+	webAuthnRPID := os.Getenv("WEBAUTHN_RP_ID")
+	trustProxy := os.Getenv("TRUST_PROXY") == "true"
+	memoryRateLimits := os.Getenv("RATE_LIMIT_STORE") == "memory"
+	breachedPasswordsDir := os.Getenv("BREACHED_PASSWORDS_DIR")
 
 	hosts := strings.Split(allowedHosts, ",")
 
@@ -64,9 +69,14 @@ func main() {
 	}
 
 	cfg := api.ServerConfig{
-		Port:         args.Port,
-		LogLevel:     logLevel,
-		AllowedHosts: hosts,
+		Port:                 args.Port,
+		LogLevel:             logLevel,
+		AllowedHosts:         hosts,
+		AppURL:               appURL,
+		WebAuthnRPID:         webAuthnRPID,
+		TrustProxy:           trustProxy,
+		MemoryRateLimits:     memoryRateLimits,
+		BreachedPasswordsDir: breachedPasswordsDir,
 	}
 
 	server, err := api.NewServer(cfg, !isDebug)
@@ -0,0 +1,55 @@
+// grant-role grants a role to the user with the given email, e.g. to bootstrap the first admin
+// who can then manage roles through AuthorizationService.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"simple-connect/api/auth"
+	"simple-connect/api/authz"
+	"simple-connect/api/data"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	var email, role string
+	flag.StringVar(&email, "email", "", "email of the user to grant the role to")
+	flag.StringVar(&role, "role", "admin", "name of the role to grant")
+	flag.Parse()
+
+	if email == "" {
+		log.Fatal("-email is required")
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("Error loading .env file")
+	}
+
+	ctx := context.Background()
+
+	pool, err := data.NewPool(ctx, false)
+
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	defer pool.Close()
+
+	// Looking users up doesn't touch provider tokens, so no key ring is needed
+	user, err := auth.NewAuthService(pool, nil, nil).GetUserByEmail(ctx, email)
+
+	if err != nil {
+		log.Fatalf("Error finding user %q: %v", email, err)
+	}
+
+	err = authz.NewAuthorizationService(pool).AssignRole(ctx, user.ID, role)
+
+	if err != nil {
+		log.Fatalf("Error granting role %q: %v", role, err)
+	}
+
+	log.Printf("Granted role %q to %s", role, email)
+}
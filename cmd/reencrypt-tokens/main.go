@@ -0,0 +1,50 @@
+// reencrypt-tokens moves every stored provider token onto the primary key of the
+// TOKEN_ENCRYPTION_KEYS key ring. Run it after adding a new primary key, then remove
+// the old key from the ring once it reports no remaining rows.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"simple-connect/api/auth"
+	"simple-connect/api/data"
+	"simple-connect/api/secrets"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	var batchSize int
+	flag.IntVar(&batchSize, "batch", 100, "accounts to update per transaction")
+	flag.Parse()
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("Error loading .env file")
+	}
+
+	ctx := context.Background()
+
+	keyRing, err := secrets.KeyRingFromEnv()
+
+	if err != nil {
+		log.Fatalf("Error loading token encryption keys: %v", err)
+	}
+
+	pool, err := data.NewPool(ctx, false)
+
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	defer pool.Close()
+
+	n, err := auth.NewAuthService(pool, keyRing, nil).ReencryptAccountTokens(ctx, batchSize)
+
+	if err != nil {
+		log.Fatalf("Error re-encrypting tokens after %d accounts: %v", n, err)
+	}
+
+	log.Printf("Re-encrypted tokens for %d accounts with key %q", n, keyRing.PrimaryKeyID())
+}
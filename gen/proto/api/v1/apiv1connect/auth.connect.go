@@ -35,19 +35,112 @@ const (
 // reflection-formatted method names, remove the leading slash and convert the remaining slash to a
 // period.
 const (
+	// AuthServiceLoginProcedure is the fully-qualified name of the AuthService's Login RPC.
+	AuthServiceLoginProcedure = "/proto.api.v1.AuthService/Login"
+	// AuthServiceSignupProcedure is the fully-qualified name of the AuthService's Signup RPC.
+	AuthServiceSignupProcedure = "/proto.api.v1.AuthService/Signup"
+	// AuthServiceLogoutProcedure is the fully-qualified name of the AuthService's Logout RPC.
+	AuthServiceLogoutProcedure = "/proto.api.v1.AuthService/Logout"
+	// AuthServiceVerifyEmailProcedure is the fully-qualified name of the AuthService's VerifyEmail RPC.
+	AuthServiceVerifyEmailProcedure = "/proto.api.v1.AuthService/VerifyEmail"
+	// AuthServiceRequestPasswordResetProcedure is the fully-qualified name of the AuthService's
+	// RequestPasswordReset RPC.
+	AuthServiceRequestPasswordResetProcedure = "/proto.api.v1.AuthService/RequestPasswordReset"
+	// AuthServiceResetPasswordProcedure is the fully-qualified name of the AuthService's ResetPassword
+	// RPC.
+	AuthServiceResetPasswordProcedure = "/proto.api.v1.AuthService/ResetPassword"
+	// AuthServiceVerifyMFAProcedure is the fully-qualified name of the AuthService's VerifyMFA RPC.
+	AuthServiceVerifyMFAProcedure = "/proto.api.v1.AuthService/VerifyMFA"
+	// AuthServiceRequestMagicLinkProcedure is the fully-qualified name of the AuthService's
+	// RequestMagicLink RPC.
+	AuthServiceRequestMagicLinkProcedure = "/proto.api.v1.AuthService/RequestMagicLink"
 	// ProtectedAuthServiceMeProcedure is the fully-qualified name of the ProtectedAuthService's Me RPC.
 	ProtectedAuthServiceMeProcedure = "/proto.api.v1.ProtectedAuthService/Me"
+	// ProtectedAuthServiceResendVerificationEmailProcedure is the fully-qualified name of the
+	// ProtectedAuthService's ResendVerificationEmail RPC.
+	ProtectedAuthServiceResendVerificationEmailProcedure = "/proto.api.v1.ProtectedAuthService/ResendVerificationEmail"
+	// ProtectedAuthServiceEnrollTOTPProcedure is the fully-qualified name of the ProtectedAuthService's
+	// EnrollTOTP RPC.
+	ProtectedAuthServiceEnrollTOTPProcedure = "/proto.api.v1.ProtectedAuthService/EnrollTOTP"
+	// ProtectedAuthServiceConfirmTOTPProcedure is the fully-qualified name of the
+	// ProtectedAuthService's ConfirmTOTP RPC.
+	ProtectedAuthServiceConfirmTOTPProcedure = "/proto.api.v1.ProtectedAuthService/ConfirmTOTP"
+	// ProtectedAuthServiceDisableTOTPProcedure is the fully-qualified name of the
+	// ProtectedAuthService's DisableTOTP RPC.
+	ProtectedAuthServiceDisableTOTPProcedure = "/proto.api.v1.ProtectedAuthService/DisableTOTP"
+	// ProtectedAuthServiceRegenerateRecoveryCodesProcedure is the fully-qualified name of the
+	// ProtectedAuthService's RegenerateRecoveryCodes RPC.
+	ProtectedAuthServiceRegenerateRecoveryCodesProcedure = "/proto.api.v1.ProtectedAuthService/RegenerateRecoveryCodes"
+	// ProtectedAuthServiceListLinkedAccountsProcedure is the fully-qualified name of the
+	// ProtectedAuthService's ListLinkedAccounts RPC.
+	ProtectedAuthServiceListLinkedAccountsProcedure = "/proto.api.v1.ProtectedAuthService/ListLinkedAccounts"
+	// ProtectedAuthServiceLinkProviderProcedure is the fully-qualified name of the
+	// ProtectedAuthService's LinkProvider RPC.
+	ProtectedAuthServiceLinkProviderProcedure = "/proto.api.v1.ProtectedAuthService/LinkProvider"
+	// ProtectedAuthServiceUnlinkProviderProcedure is the fully-qualified name of the
+	// ProtectedAuthService's UnlinkProvider RPC.
+	ProtectedAuthServiceUnlinkProviderProcedure = "/proto.api.v1.ProtectedAuthService/UnlinkProvider"
+	// ProtectedAuthServiceListSessionsProcedure is the fully-qualified name of the
+	// ProtectedAuthService's ListSessions RPC.
+	ProtectedAuthServiceListSessionsProcedure = "/proto.api.v1.ProtectedAuthService/ListSessions"
+	// ProtectedAuthServiceRevokeSessionProcedure is the fully-qualified name of the
+	// ProtectedAuthService's RevokeSession RPC.
+	ProtectedAuthServiceRevokeSessionProcedure = "/proto.api.v1.ProtectedAuthService/RevokeSession"
+	// ProtectedAuthServiceRevokeOtherSessionsProcedure is the fully-qualified name of the
+	// ProtectedAuthService's RevokeOtherSessions RPC.
+	ProtectedAuthServiceRevokeOtherSessionsProcedure = "/proto.api.v1.ProtectedAuthService/RevokeOtherSessions"
+	// ProtectedAuthServiceCreateAccessTokenProcedure is the fully-qualified name of the
+	// ProtectedAuthService's CreateAccessToken RPC.
+	ProtectedAuthServiceCreateAccessTokenProcedure = "/proto.api.v1.ProtectedAuthService/CreateAccessToken"
+	// ProtectedAuthServiceListAccessTokensProcedure is the fully-qualified name of the
+	// ProtectedAuthService's ListAccessTokens RPC.
+	ProtectedAuthServiceListAccessTokensProcedure = "/proto.api.v1.ProtectedAuthService/ListAccessTokens"
+	// ProtectedAuthServiceRevokeAccessTokenProcedure is the fully-qualified name of the
+	// ProtectedAuthService's RevokeAccessToken RPC.
+	ProtectedAuthServiceRevokeAccessTokenProcedure = "/proto.api.v1.ProtectedAuthService/RevokeAccessToken"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	authServiceServiceDescriptor           = v1.File_proto_api_v1_auth_proto.Services().ByName("AuthService")
-	protectedAuthServiceServiceDescriptor  = v1.File_proto_api_v1_auth_proto.Services().ByName("ProtectedAuthService")
-	protectedAuthServiceMeMethodDescriptor = protectedAuthServiceServiceDescriptor.Methods().ByName("Me")
+	authServiceServiceDescriptor                                = v1.File_proto_api_v1_auth_proto.Services().ByName("AuthService")
+	authServiceLoginMethodDescriptor                            = authServiceServiceDescriptor.Methods().ByName("Login")
+	authServiceSignupMethodDescriptor                           = authServiceServiceDescriptor.Methods().ByName("Signup")
+	authServiceLogoutMethodDescriptor                           = authServiceServiceDescriptor.Methods().ByName("Logout")
+	authServiceVerifyEmailMethodDescriptor                      = authServiceServiceDescriptor.Methods().ByName("VerifyEmail")
+	authServiceRequestPasswordResetMethodDescriptor             = authServiceServiceDescriptor.Methods().ByName("RequestPasswordReset")
+	authServiceResetPasswordMethodDescriptor                    = authServiceServiceDescriptor.Methods().ByName("ResetPassword")
+	authServiceVerifyMFAMethodDescriptor                        = authServiceServiceDescriptor.Methods().ByName("VerifyMFA")
+	authServiceRequestMagicLinkMethodDescriptor                 = authServiceServiceDescriptor.Methods().ByName("RequestMagicLink")
+	protectedAuthServiceServiceDescriptor                       = v1.File_proto_api_v1_auth_proto.Services().ByName("ProtectedAuthService")
+	protectedAuthServiceMeMethodDescriptor                      = protectedAuthServiceServiceDescriptor.Methods().ByName("Me")
+	protectedAuthServiceResendVerificationEmailMethodDescriptor = protectedAuthServiceServiceDescriptor.Methods().ByName("ResendVerificationEmail")
+	protectedAuthServiceEnrollTOTPMethodDescriptor              = protectedAuthServiceServiceDescriptor.Methods().ByName("EnrollTOTP")
+	protectedAuthServiceConfirmTOTPMethodDescriptor             = protectedAuthServiceServiceDescriptor.Methods().ByName("ConfirmTOTP")
+	protectedAuthServiceDisableTOTPMethodDescriptor             = protectedAuthServiceServiceDescriptor.Methods().ByName("DisableTOTP")
+	protectedAuthServiceRegenerateRecoveryCodesMethodDescriptor = protectedAuthServiceServiceDescriptor.Methods().ByName("RegenerateRecoveryCodes")
+	protectedAuthServiceListLinkedAccountsMethodDescriptor      = protectedAuthServiceServiceDescriptor.Methods().ByName("ListLinkedAccounts")
+	protectedAuthServiceLinkProviderMethodDescriptor            = protectedAuthServiceServiceDescriptor.Methods().ByName("LinkProvider")
+	protectedAuthServiceUnlinkProviderMethodDescriptor          = protectedAuthServiceServiceDescriptor.Methods().ByName("UnlinkProvider")
+	protectedAuthServiceListSessionsMethodDescriptor            = protectedAuthServiceServiceDescriptor.Methods().ByName("ListSessions")
+	protectedAuthServiceRevokeSessionMethodDescriptor           = protectedAuthServiceServiceDescriptor.Methods().ByName("RevokeSession")
+	protectedAuthServiceRevokeOtherSessionsMethodDescriptor     = protectedAuthServiceServiceDescriptor.Methods().ByName("RevokeOtherSessions")
+	protectedAuthServiceCreateAccessTokenMethodDescriptor       = protectedAuthServiceServiceDescriptor.Methods().ByName("CreateAccessToken")
+	protectedAuthServiceListAccessTokensMethodDescriptor        = protectedAuthServiceServiceDescriptor.Methods().ByName("ListAccessTokens")
+	protectedAuthServiceRevokeAccessTokenMethodDescriptor       = protectedAuthServiceServiceDescriptor.Methods().ByName("RevokeAccessToken")
 )
 
 // AuthServiceClient is a client for the proto.api.v1.AuthService service.
 type AuthServiceClient interface {
+	Login(context.Context, *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error)
+	Signup(context.Context, *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error)
+	Logout(context.Context, *connect.Request[v1.LogoutRequest]) (*connect.Response[v1.LogoutResponse], error)
+	VerifyEmail(context.Context, *connect.Request[v1.VerifyEmailRequest]) (*connect.Response[v1.VerifyEmailResponse], error)
+	RequestPasswordReset(context.Context, *connect.Request[v1.RequestPasswordResetRequest]) (*connect.Response[v1.RequestPasswordResetResponse], error)
+	ResetPassword(context.Context, *connect.Request[v1.ResetPasswordRequest]) (*connect.Response[v1.ResetPasswordResponse], error)
+	VerifyMFA(context.Context, *connect.Request[v1.VerifyMFARequest]) (*connect.Response[v1.VerifyMFAResponse], error)
+	// RequestMagicLink emails a sign in link, creating the account when it is used. It succeeds for any
+	// address so callers can't tell which are registered.
+	RequestMagicLink(context.Context, *connect.Request[v1.RequestMagicLinkRequest]) (*connect.Response[v1.RequestMagicLinkResponse], error)
 }
 
 // NewAuthServiceClient constructs a client for the proto.api.v1.AuthService service. By default, it
@@ -58,15 +151,123 @@ type AuthServiceClient interface {
 // The URL supplied here should be the base URL for the Connect or gRPC server (for example,
 // http://api.acme.com or https://acme.com/grpc).
 func NewAuthServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AuthServiceClient {
-	return &authServiceClient{}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &authServiceClient{
+		login: connect.NewClient[v1.LoginRequest, v1.LoginResponse](
+			httpClient,
+			baseURL+AuthServiceLoginProcedure,
+			connect.WithSchema(authServiceLoginMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		signup: connect.NewClient[v1.SignupRequest, v1.SignupResponse](
+			httpClient,
+			baseURL+AuthServiceSignupProcedure,
+			connect.WithSchema(authServiceSignupMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		logout: connect.NewClient[v1.LogoutRequest, v1.LogoutResponse](
+			httpClient,
+			baseURL+AuthServiceLogoutProcedure,
+			connect.WithSchema(authServiceLogoutMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		verifyEmail: connect.NewClient[v1.VerifyEmailRequest, v1.VerifyEmailResponse](
+			httpClient,
+			baseURL+AuthServiceVerifyEmailProcedure,
+			connect.WithSchema(authServiceVerifyEmailMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		requestPasswordReset: connect.NewClient[v1.RequestPasswordResetRequest, v1.RequestPasswordResetResponse](
+			httpClient,
+			baseURL+AuthServiceRequestPasswordResetProcedure,
+			connect.WithSchema(authServiceRequestPasswordResetMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		resetPassword: connect.NewClient[v1.ResetPasswordRequest, v1.ResetPasswordResponse](
+			httpClient,
+			baseURL+AuthServiceResetPasswordProcedure,
+			connect.WithSchema(authServiceResetPasswordMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		verifyMFA: connect.NewClient[v1.VerifyMFARequest, v1.VerifyMFAResponse](
+			httpClient,
+			baseURL+AuthServiceVerifyMFAProcedure,
+			connect.WithSchema(authServiceVerifyMFAMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		requestMagicLink: connect.NewClient[v1.RequestMagicLinkRequest, v1.RequestMagicLinkResponse](
+			httpClient,
+			baseURL+AuthServiceRequestMagicLinkProcedure,
+			connect.WithSchema(authServiceRequestMagicLinkMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
 }
 
 // authServiceClient implements AuthServiceClient.
 type authServiceClient struct {
+	login                *connect.Client[v1.LoginRequest, v1.LoginResponse]
+	signup               *connect.Client[v1.SignupRequest, v1.SignupResponse]
+	logout               *connect.Client[v1.LogoutRequest, v1.LogoutResponse]
+	verifyEmail          *connect.Client[v1.VerifyEmailRequest, v1.VerifyEmailResponse]
+	requestPasswordReset *connect.Client[v1.RequestPasswordResetRequest, v1.RequestPasswordResetResponse]
+	resetPassword        *connect.Client[v1.ResetPasswordRequest, v1.ResetPasswordResponse]
+	verifyMFA            *connect.Client[v1.VerifyMFARequest, v1.VerifyMFAResponse]
+	requestMagicLink     *connect.Client[v1.RequestMagicLinkRequest, v1.RequestMagicLinkResponse]
+}
+
+// Login calls proto.api.v1.AuthService.Login.
+func (c *authServiceClient) Login(ctx context.Context, req *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error) {
+	return c.login.CallUnary(ctx, req)
+}
+
+// Signup calls proto.api.v1.AuthService.Signup.
+func (c *authServiceClient) Signup(ctx context.Context, req *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error) {
+	return c.signup.CallUnary(ctx, req)
+}
+
+// Logout calls proto.api.v1.AuthService.Logout.
+func (c *authServiceClient) Logout(ctx context.Context, req *connect.Request[v1.LogoutRequest]) (*connect.Response[v1.LogoutResponse], error) {
+	return c.logout.CallUnary(ctx, req)
+}
+
+// VerifyEmail calls proto.api.v1.AuthService.VerifyEmail.
+func (c *authServiceClient) VerifyEmail(ctx context.Context, req *connect.Request[v1.VerifyEmailRequest]) (*connect.Response[v1.VerifyEmailResponse], error) {
+	return c.verifyEmail.CallUnary(ctx, req)
+}
+
+// RequestPasswordReset calls proto.api.v1.AuthService.RequestPasswordReset.
+func (c *authServiceClient) RequestPasswordReset(ctx context.Context, req *connect.Request[v1.RequestPasswordResetRequest]) (*connect.Response[v1.RequestPasswordResetResponse], error) {
+	return c.requestPasswordReset.CallUnary(ctx, req)
+}
+
+// ResetPassword calls proto.api.v1.AuthService.ResetPassword.
+func (c *authServiceClient) ResetPassword(ctx context.Context, req *connect.Request[v1.ResetPasswordRequest]) (*connect.Response[v1.ResetPasswordResponse], error) {
+	return c.resetPassword.CallUnary(ctx, req)
+}
+
+// VerifyMFA calls proto.api.v1.AuthService.VerifyMFA.
+func (c *authServiceClient) VerifyMFA(ctx context.Context, req *connect.Request[v1.VerifyMFARequest]) (*connect.Response[v1.VerifyMFAResponse], error) {
+	return c.verifyMFA.CallUnary(ctx, req)
+}
+
+// RequestMagicLink calls proto.api.v1.AuthService.RequestMagicLink.
+func (c *authServiceClient) RequestMagicLink(ctx context.Context, req *connect.Request[v1.RequestMagicLinkRequest]) (*connect.Response[v1.RequestMagicLinkResponse], error) {
+	return c.requestMagicLink.CallUnary(ctx, req)
 }
 
 // AuthServiceHandler is an implementation of the proto.api.v1.AuthService service.
 type AuthServiceHandler interface {
+	Login(context.Context, *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error)
+	Signup(context.Context, *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error)
+	Logout(context.Context, *connect.Request[v1.LogoutRequest]) (*connect.Response[v1.LogoutResponse], error)
+	VerifyEmail(context.Context, *connect.Request[v1.VerifyEmailRequest]) (*connect.Response[v1.VerifyEmailResponse], error)
+	RequestPasswordReset(context.Context, *connect.Request[v1.RequestPasswordResetRequest]) (*connect.Response[v1.RequestPasswordResetResponse], error)
+	ResetPassword(context.Context, *connect.Request[v1.ResetPasswordRequest]) (*connect.Response[v1.ResetPasswordResponse], error)
+	VerifyMFA(context.Context, *connect.Request[v1.VerifyMFARequest]) (*connect.Response[v1.VerifyMFAResponse], error)
+	// RequestMagicLink emails a sign in link, creating the account when it is used. It succeeds for any
+	// address so callers can't tell which are registered.
+	RequestMagicLink(context.Context, *connect.Request[v1.RequestMagicLinkRequest]) (*connect.Response[v1.RequestMagicLinkResponse], error)
 }
 
 // NewAuthServiceHandler builds an HTTP handler from the service implementation. It returns the path
@@ -75,8 +276,72 @@ type AuthServiceHandler interface {
 // By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
 // and JSON codecs. They also support gzip compression.
 func NewAuthServiceHandler(svc AuthServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	authServiceLoginHandler := connect.NewUnaryHandler(
+		AuthServiceLoginProcedure,
+		svc.Login,
+		connect.WithSchema(authServiceLoginMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceSignupHandler := connect.NewUnaryHandler(
+		AuthServiceSignupProcedure,
+		svc.Signup,
+		connect.WithSchema(authServiceSignupMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceLogoutHandler := connect.NewUnaryHandler(
+		AuthServiceLogoutProcedure,
+		svc.Logout,
+		connect.WithSchema(authServiceLogoutMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceVerifyEmailHandler := connect.NewUnaryHandler(
+		AuthServiceVerifyEmailProcedure,
+		svc.VerifyEmail,
+		connect.WithSchema(authServiceVerifyEmailMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceRequestPasswordResetHandler := connect.NewUnaryHandler(
+		AuthServiceRequestPasswordResetProcedure,
+		svc.RequestPasswordReset,
+		connect.WithSchema(authServiceRequestPasswordResetMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceResetPasswordHandler := connect.NewUnaryHandler(
+		AuthServiceResetPasswordProcedure,
+		svc.ResetPassword,
+		connect.WithSchema(authServiceResetPasswordMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceVerifyMFAHandler := connect.NewUnaryHandler(
+		AuthServiceVerifyMFAProcedure,
+		svc.VerifyMFA,
+		connect.WithSchema(authServiceVerifyMFAMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authServiceRequestMagicLinkHandler := connect.NewUnaryHandler(
+		AuthServiceRequestMagicLinkProcedure,
+		svc.RequestMagicLink,
+		connect.WithSchema(authServiceRequestMagicLinkMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/proto.api.v1.AuthService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
+		case AuthServiceLoginProcedure:
+			authServiceLoginHandler.ServeHTTP(w, r)
+		case AuthServiceSignupProcedure:
+			authServiceSignupHandler.ServeHTTP(w, r)
+		case AuthServiceLogoutProcedure:
+			authServiceLogoutHandler.ServeHTTP(w, r)
+		case AuthServiceVerifyEmailProcedure:
+			authServiceVerifyEmailHandler.ServeHTTP(w, r)
+		case AuthServiceRequestPasswordResetProcedure:
+			authServiceRequestPasswordResetHandler.ServeHTTP(w, r)
+		case AuthServiceResetPasswordProcedure:
+			authServiceResetPasswordHandler.ServeHTTP(w, r)
+		case AuthServiceVerifyMFAProcedure:
+			authServiceVerifyMFAHandler.ServeHTTP(w, r)
+		case AuthServiceRequestMagicLinkProcedure:
+			authServiceRequestMagicLinkHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -86,9 +351,55 @@ func NewAuthServiceHandler(svc AuthServiceHandler, opts ...connect.HandlerOption
 // UnimplementedAuthServiceHandler returns CodeUnimplemented from all methods.
 type UnimplementedAuthServiceHandler struct{}
 
+func (UnimplementedAuthServiceHandler) Login(context.Context, *connect.Request[v1.LoginRequest]) (*connect.Response[v1.LoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.Login is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) Signup(context.Context, *connect.Request[v1.SignupRequest]) (*connect.Response[v1.SignupResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.Signup is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) Logout(context.Context, *connect.Request[v1.LogoutRequest]) (*connect.Response[v1.LogoutResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.Logout is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) VerifyEmail(context.Context, *connect.Request[v1.VerifyEmailRequest]) (*connect.Response[v1.VerifyEmailResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.VerifyEmail is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) RequestPasswordReset(context.Context, *connect.Request[v1.RequestPasswordResetRequest]) (*connect.Response[v1.RequestPasswordResetResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.RequestPasswordReset is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) ResetPassword(context.Context, *connect.Request[v1.ResetPasswordRequest]) (*connect.Response[v1.ResetPasswordResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.ResetPassword is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) VerifyMFA(context.Context, *connect.Request[v1.VerifyMFARequest]) (*connect.Response[v1.VerifyMFAResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.VerifyMFA is not implemented"))
+}
+
+func (UnimplementedAuthServiceHandler) RequestMagicLink(context.Context, *connect.Request[v1.RequestMagicLinkRequest]) (*connect.Response[v1.RequestMagicLinkResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthService.RequestMagicLink is not implemented"))
+}
+
 // ProtectedAuthServiceClient is a client for the proto.api.v1.ProtectedAuthService service.
 type ProtectedAuthServiceClient interface {
 	Me(context.Context, *connect.Request[v1.MeRequest]) (*connect.Response[v1.ReadUser], error)
+	ResendVerificationEmail(context.Context, *connect.Request[v1.ResendVerificationEmailRequest]) (*connect.Response[v1.ResendVerificationEmailResponse], error)
+	EnrollTOTP(context.Context, *connect.Request[v1.EnrollTOTPRequest]) (*connect.Response[v1.EnrollTOTPResponse], error)
+	ConfirmTOTP(context.Context, *connect.Request[v1.ConfirmTOTPRequest]) (*connect.Response[v1.ConfirmTOTPResponse], error)
+	DisableTOTP(context.Context, *connect.Request[v1.DisableTOTPRequest]) (*connect.Response[v1.DisableTOTPResponse], error)
+	RegenerateRecoveryCodes(context.Context, *connect.Request[v1.RegenerateRecoveryCodesRequest]) (*connect.Response[v1.RegenerateRecoveryCodesResponse], error)
+	ListLinkedAccounts(context.Context, *connect.Request[v1.ListLinkedAccountsRequest]) (*connect.Response[v1.ListLinkedAccountsResponse], error)
+	LinkProvider(context.Context, *connect.Request[v1.LinkProviderRequest]) (*connect.Response[v1.LinkProviderResponse], error)
+	UnlinkProvider(context.Context, *connect.Request[v1.UnlinkProviderRequest]) (*connect.Response[v1.UnlinkProviderResponse], error)
+	ListSessions(context.Context, *connect.Request[v1.ListSessionsRequest]) (*connect.Response[v1.ListSessionsResponse], error)
+	RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error)
+	RevokeOtherSessions(context.Context, *connect.Request[v1.RevokeOtherSessionsRequest]) (*connect.Response[v1.RevokeOtherSessionsResponse], error)
+	CreateAccessToken(context.Context, *connect.Request[v1.CreateAccessTokenRequest]) (*connect.Response[v1.CreateAccessTokenResponse], error)
+	ListAccessTokens(context.Context, *connect.Request[v1.ListAccessTokensRequest]) (*connect.Response[v1.ListAccessTokensResponse], error)
+	RevokeAccessToken(context.Context, *connect.Request[v1.RevokeAccessTokenRequest]) (*connect.Response[v1.RevokeAccessTokenResponse], error)
 }
 
 // NewProtectedAuthServiceClient constructs a client for the proto.api.v1.ProtectedAuthService
@@ -105,6 +416,94 @@ func NewProtectedAuthServiceClient(httpClient connect.HTTPClient, baseURL string
 			httpClient,
 			baseURL+ProtectedAuthServiceMeProcedure,
 			connect.WithSchema(protectedAuthServiceMeMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		resendVerificationEmail: connect.NewClient[v1.ResendVerificationEmailRequest, v1.ResendVerificationEmailResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceResendVerificationEmailProcedure,
+			connect.WithSchema(protectedAuthServiceResendVerificationEmailMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		enrollTOTP: connect.NewClient[v1.EnrollTOTPRequest, v1.EnrollTOTPResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceEnrollTOTPProcedure,
+			connect.WithSchema(protectedAuthServiceEnrollTOTPMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		confirmTOTP: connect.NewClient[v1.ConfirmTOTPRequest, v1.ConfirmTOTPResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceConfirmTOTPProcedure,
+			connect.WithSchema(protectedAuthServiceConfirmTOTPMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		disableTOTP: connect.NewClient[v1.DisableTOTPRequest, v1.DisableTOTPResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceDisableTOTPProcedure,
+			connect.WithSchema(protectedAuthServiceDisableTOTPMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		regenerateRecoveryCodes: connect.NewClient[v1.RegenerateRecoveryCodesRequest, v1.RegenerateRecoveryCodesResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceRegenerateRecoveryCodesProcedure,
+			connect.WithSchema(protectedAuthServiceRegenerateRecoveryCodesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listLinkedAccounts: connect.NewClient[v1.ListLinkedAccountsRequest, v1.ListLinkedAccountsResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceListLinkedAccountsProcedure,
+			connect.WithSchema(protectedAuthServiceListLinkedAccountsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		linkProvider: connect.NewClient[v1.LinkProviderRequest, v1.LinkProviderResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceLinkProviderProcedure,
+			connect.WithSchema(protectedAuthServiceLinkProviderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		unlinkProvider: connect.NewClient[v1.UnlinkProviderRequest, v1.UnlinkProviderResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceUnlinkProviderProcedure,
+			connect.WithSchema(protectedAuthServiceUnlinkProviderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listSessions: connect.NewClient[v1.ListSessionsRequest, v1.ListSessionsResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceListSessionsProcedure,
+			connect.WithSchema(protectedAuthServiceListSessionsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		revokeSession: connect.NewClient[v1.RevokeSessionRequest, v1.RevokeSessionResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceRevokeSessionProcedure,
+			connect.WithSchema(protectedAuthServiceRevokeSessionMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		revokeOtherSessions: connect.NewClient[v1.RevokeOtherSessionsRequest, v1.RevokeOtherSessionsResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceRevokeOtherSessionsProcedure,
+			connect.WithSchema(protectedAuthServiceRevokeOtherSessionsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createAccessToken: connect.NewClient[v1.CreateAccessTokenRequest, v1.CreateAccessTokenResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceCreateAccessTokenProcedure,
+			connect.WithSchema(protectedAuthServiceCreateAccessTokenMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listAccessTokens: connect.NewClient[v1.ListAccessTokensRequest, v1.ListAccessTokensResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceListAccessTokensProcedure,
+			connect.WithSchema(protectedAuthServiceListAccessTokensMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		revokeAccessToken: connect.NewClient[v1.RevokeAccessTokenRequest, v1.RevokeAccessTokenResponse](
+			httpClient,
+			baseURL+ProtectedAuthServiceRevokeAccessTokenProcedure,
+			connect.WithSchema(protectedAuthServiceRevokeAccessTokenMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
 	}
@@ -112,7 +511,21 @@ func NewProtectedAuthServiceClient(httpClient connect.HTTPClient, baseURL string
 
 // protectedAuthServiceClient implements ProtectedAuthServiceClient.
 type protectedAuthServiceClient struct {
-	me *connect.Client[v1.MeRequest, v1.ReadUser]
+	me                      *connect.Client[v1.MeRequest, v1.ReadUser]
+	resendVerificationEmail *connect.Client[v1.ResendVerificationEmailRequest, v1.ResendVerificationEmailResponse]
+	enrollTOTP              *connect.Client[v1.EnrollTOTPRequest, v1.EnrollTOTPResponse]
+	confirmTOTP             *connect.Client[v1.ConfirmTOTPRequest, v1.ConfirmTOTPResponse]
+	disableTOTP             *connect.Client[v1.DisableTOTPRequest, v1.DisableTOTPResponse]
+	regenerateRecoveryCodes *connect.Client[v1.RegenerateRecoveryCodesRequest, v1.RegenerateRecoveryCodesResponse]
+	listLinkedAccounts      *connect.Client[v1.ListLinkedAccountsRequest, v1.ListLinkedAccountsResponse]
+	linkProvider            *connect.Client[v1.LinkProviderRequest, v1.LinkProviderResponse]
+	unlinkProvider          *connect.Client[v1.UnlinkProviderRequest, v1.UnlinkProviderResponse]
+	listSessions            *connect.Client[v1.ListSessionsRequest, v1.ListSessionsResponse]
+	revokeSession           *connect.Client[v1.RevokeSessionRequest, v1.RevokeSessionResponse]
+	revokeOtherSessions     *connect.Client[v1.RevokeOtherSessionsRequest, v1.RevokeOtherSessionsResponse]
+	createAccessToken       *connect.Client[v1.CreateAccessTokenRequest, v1.CreateAccessTokenResponse]
+	listAccessTokens        *connect.Client[v1.ListAccessTokensRequest, v1.ListAccessTokensResponse]
+	revokeAccessToken       *connect.Client[v1.RevokeAccessTokenRequest, v1.RevokeAccessTokenResponse]
 }
 
 // Me calls proto.api.v1.ProtectedAuthService.Me.
@@ -120,10 +533,94 @@ func (c *protectedAuthServiceClient) Me(ctx context.Context, req *connect.Reques
 	return c.me.CallUnary(ctx, req)
 }
 
+// ResendVerificationEmail calls proto.api.v1.ProtectedAuthService.ResendVerificationEmail.
+func (c *protectedAuthServiceClient) ResendVerificationEmail(ctx context.Context, req *connect.Request[v1.ResendVerificationEmailRequest]) (*connect.Response[v1.ResendVerificationEmailResponse], error) {
+	return c.resendVerificationEmail.CallUnary(ctx, req)
+}
+
+// EnrollTOTP calls proto.api.v1.ProtectedAuthService.EnrollTOTP.
+func (c *protectedAuthServiceClient) EnrollTOTP(ctx context.Context, req *connect.Request[v1.EnrollTOTPRequest]) (*connect.Response[v1.EnrollTOTPResponse], error) {
+	return c.enrollTOTP.CallUnary(ctx, req)
+}
+
+// ConfirmTOTP calls proto.api.v1.ProtectedAuthService.ConfirmTOTP.
+func (c *protectedAuthServiceClient) ConfirmTOTP(ctx context.Context, req *connect.Request[v1.ConfirmTOTPRequest]) (*connect.Response[v1.ConfirmTOTPResponse], error) {
+	return c.confirmTOTP.CallUnary(ctx, req)
+}
+
+// DisableTOTP calls proto.api.v1.ProtectedAuthService.DisableTOTP.
+func (c *protectedAuthServiceClient) DisableTOTP(ctx context.Context, req *connect.Request[v1.DisableTOTPRequest]) (*connect.Response[v1.DisableTOTPResponse], error) {
+	return c.disableTOTP.CallUnary(ctx, req)
+}
+
+// RegenerateRecoveryCodes calls proto.api.v1.ProtectedAuthService.RegenerateRecoveryCodes.
+func (c *protectedAuthServiceClient) RegenerateRecoveryCodes(ctx context.Context, req *connect.Request[v1.RegenerateRecoveryCodesRequest]) (*connect.Response[v1.RegenerateRecoveryCodesResponse], error) {
+	return c.regenerateRecoveryCodes.CallUnary(ctx, req)
+}
+
+// ListLinkedAccounts calls proto.api.v1.ProtectedAuthService.ListLinkedAccounts.
+func (c *protectedAuthServiceClient) ListLinkedAccounts(ctx context.Context, req *connect.Request[v1.ListLinkedAccountsRequest]) (*connect.Response[v1.ListLinkedAccountsResponse], error) {
+	return c.listLinkedAccounts.CallUnary(ctx, req)
+}
+
+// LinkProvider calls proto.api.v1.ProtectedAuthService.LinkProvider.
+func (c *protectedAuthServiceClient) LinkProvider(ctx context.Context, req *connect.Request[v1.LinkProviderRequest]) (*connect.Response[v1.LinkProviderResponse], error) {
+	return c.linkProvider.CallUnary(ctx, req)
+}
+
+// UnlinkProvider calls proto.api.v1.ProtectedAuthService.UnlinkProvider.
+func (c *protectedAuthServiceClient) UnlinkProvider(ctx context.Context, req *connect.Request[v1.UnlinkProviderRequest]) (*connect.Response[v1.UnlinkProviderResponse], error) {
+	return c.unlinkProvider.CallUnary(ctx, req)
+}
+
+// ListSessions calls proto.api.v1.ProtectedAuthService.ListSessions.
+func (c *protectedAuthServiceClient) ListSessions(ctx context.Context, req *connect.Request[v1.ListSessionsRequest]) (*connect.Response[v1.ListSessionsResponse], error) {
+	return c.listSessions.CallUnary(ctx, req)
+}
+
+// RevokeSession calls proto.api.v1.ProtectedAuthService.RevokeSession.
+func (c *protectedAuthServiceClient) RevokeSession(ctx context.Context, req *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error) {
+	return c.revokeSession.CallUnary(ctx, req)
+}
+
+// RevokeOtherSessions calls proto.api.v1.ProtectedAuthService.RevokeOtherSessions.
+func (c *protectedAuthServiceClient) RevokeOtherSessions(ctx context.Context, req *connect.Request[v1.RevokeOtherSessionsRequest]) (*connect.Response[v1.RevokeOtherSessionsResponse], error) {
+	return c.revokeOtherSessions.CallUnary(ctx, req)
+}
+
+// CreateAccessToken calls proto.api.v1.ProtectedAuthService.CreateAccessToken.
+func (c *protectedAuthServiceClient) CreateAccessToken(ctx context.Context, req *connect.Request[v1.CreateAccessTokenRequest]) (*connect.Response[v1.CreateAccessTokenResponse], error) {
+	return c.createAccessToken.CallUnary(ctx, req)
+}
+
+// ListAccessTokens calls proto.api.v1.ProtectedAuthService.ListAccessTokens.
+func (c *protectedAuthServiceClient) ListAccessTokens(ctx context.Context, req *connect.Request[v1.ListAccessTokensRequest]) (*connect.Response[v1.ListAccessTokensResponse], error) {
+	return c.listAccessTokens.CallUnary(ctx, req)
+}
+
+// RevokeAccessToken calls proto.api.v1.ProtectedAuthService.RevokeAccessToken.
+func (c *protectedAuthServiceClient) RevokeAccessToken(ctx context.Context, req *connect.Request[v1.RevokeAccessTokenRequest]) (*connect.Response[v1.RevokeAccessTokenResponse], error) {
+	return c.revokeAccessToken.CallUnary(ctx, req)
+}
+
 // ProtectedAuthServiceHandler is an implementation of the proto.api.v1.ProtectedAuthService
 // service.
 type ProtectedAuthServiceHandler interface {
 	Me(context.Context, *connect.Request[v1.MeRequest]) (*connect.Response[v1.ReadUser], error)
+	ResendVerificationEmail(context.Context, *connect.Request[v1.ResendVerificationEmailRequest]) (*connect.Response[v1.ResendVerificationEmailResponse], error)
+	EnrollTOTP(context.Context, *connect.Request[v1.EnrollTOTPRequest]) (*connect.Response[v1.EnrollTOTPResponse], error)
+	ConfirmTOTP(context.Context, *connect.Request[v1.ConfirmTOTPRequest]) (*connect.Response[v1.ConfirmTOTPResponse], error)
+	DisableTOTP(context.Context, *connect.Request[v1.DisableTOTPRequest]) (*connect.Response[v1.DisableTOTPResponse], error)
+	RegenerateRecoveryCodes(context.Context, *connect.Request[v1.RegenerateRecoveryCodesRequest]) (*connect.Response[v1.RegenerateRecoveryCodesResponse], error)
+	ListLinkedAccounts(context.Context, *connect.Request[v1.ListLinkedAccountsRequest]) (*connect.Response[v1.ListLinkedAccountsResponse], error)
+	LinkProvider(context.Context, *connect.Request[v1.LinkProviderRequest]) (*connect.Response[v1.LinkProviderResponse], error)
+	UnlinkProvider(context.Context, *connect.Request[v1.UnlinkProviderRequest]) (*connect.Response[v1.UnlinkProviderResponse], error)
+	ListSessions(context.Context, *connect.Request[v1.ListSessionsRequest]) (*connect.Response[v1.ListSessionsResponse], error)
+	RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error)
+	RevokeOtherSessions(context.Context, *connect.Request[v1.RevokeOtherSessionsRequest]) (*connect.Response[v1.RevokeOtherSessionsResponse], error)
+	CreateAccessToken(context.Context, *connect.Request[v1.CreateAccessTokenRequest]) (*connect.Response[v1.CreateAccessTokenResponse], error)
+	ListAccessTokens(context.Context, *connect.Request[v1.ListAccessTokensRequest]) (*connect.Response[v1.ListAccessTokensResponse], error)
+	RevokeAccessToken(context.Context, *connect.Request[v1.RevokeAccessTokenRequest]) (*connect.Response[v1.RevokeAccessTokenResponse], error)
 }
 
 // NewProtectedAuthServiceHandler builds an HTTP handler from the service implementation. It returns
@@ -136,12 +633,128 @@ func NewProtectedAuthServiceHandler(svc ProtectedAuthServiceHandler, opts ...con
 		ProtectedAuthServiceMeProcedure,
 		svc.Me,
 		connect.WithSchema(protectedAuthServiceMeMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceResendVerificationEmailHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceResendVerificationEmailProcedure,
+		svc.ResendVerificationEmail,
+		connect.WithSchema(protectedAuthServiceResendVerificationEmailMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceEnrollTOTPHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceEnrollTOTPProcedure,
+		svc.EnrollTOTP,
+		connect.WithSchema(protectedAuthServiceEnrollTOTPMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceConfirmTOTPHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceConfirmTOTPProcedure,
+		svc.ConfirmTOTP,
+		connect.WithSchema(protectedAuthServiceConfirmTOTPMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceDisableTOTPHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceDisableTOTPProcedure,
+		svc.DisableTOTP,
+		connect.WithSchema(protectedAuthServiceDisableTOTPMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceRegenerateRecoveryCodesHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceRegenerateRecoveryCodesProcedure,
+		svc.RegenerateRecoveryCodes,
+		connect.WithSchema(protectedAuthServiceRegenerateRecoveryCodesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceListLinkedAccountsHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceListLinkedAccountsProcedure,
+		svc.ListLinkedAccounts,
+		connect.WithSchema(protectedAuthServiceListLinkedAccountsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceLinkProviderHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceLinkProviderProcedure,
+		svc.LinkProvider,
+		connect.WithSchema(protectedAuthServiceLinkProviderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceUnlinkProviderHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceUnlinkProviderProcedure,
+		svc.UnlinkProvider,
+		connect.WithSchema(protectedAuthServiceUnlinkProviderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceListSessionsHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceListSessionsProcedure,
+		svc.ListSessions,
+		connect.WithSchema(protectedAuthServiceListSessionsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceRevokeSessionHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceRevokeSessionProcedure,
+		svc.RevokeSession,
+		connect.WithSchema(protectedAuthServiceRevokeSessionMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceRevokeOtherSessionsHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceRevokeOtherSessionsProcedure,
+		svc.RevokeOtherSessions,
+		connect.WithSchema(protectedAuthServiceRevokeOtherSessionsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceCreateAccessTokenHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceCreateAccessTokenProcedure,
+		svc.CreateAccessToken,
+		connect.WithSchema(protectedAuthServiceCreateAccessTokenMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceListAccessTokensHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceListAccessTokensProcedure,
+		svc.ListAccessTokens,
+		connect.WithSchema(protectedAuthServiceListAccessTokensMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedAuthServiceRevokeAccessTokenHandler := connect.NewUnaryHandler(
+		ProtectedAuthServiceRevokeAccessTokenProcedure,
+		svc.RevokeAccessToken,
+		connect.WithSchema(protectedAuthServiceRevokeAccessTokenMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
 	return "/proto.api.v1.ProtectedAuthService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ProtectedAuthServiceMeProcedure:
 			protectedAuthServiceMeHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceResendVerificationEmailProcedure:
+			protectedAuthServiceResendVerificationEmailHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceEnrollTOTPProcedure:
+			protectedAuthServiceEnrollTOTPHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceConfirmTOTPProcedure:
+			protectedAuthServiceConfirmTOTPHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceDisableTOTPProcedure:
+			protectedAuthServiceDisableTOTPHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceRegenerateRecoveryCodesProcedure:
+			protectedAuthServiceRegenerateRecoveryCodesHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceListLinkedAccountsProcedure:
+			protectedAuthServiceListLinkedAccountsHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceLinkProviderProcedure:
+			protectedAuthServiceLinkProviderHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceUnlinkProviderProcedure:
+			protectedAuthServiceUnlinkProviderHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceListSessionsProcedure:
+			protectedAuthServiceListSessionsHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceRevokeSessionProcedure:
+			protectedAuthServiceRevokeSessionHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceRevokeOtherSessionsProcedure:
+			protectedAuthServiceRevokeOtherSessionsHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceCreateAccessTokenProcedure:
+			protectedAuthServiceCreateAccessTokenHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceListAccessTokensProcedure:
+			protectedAuthServiceListAccessTokensHandler.ServeHTTP(w, r)
+		case ProtectedAuthServiceRevokeAccessTokenProcedure:
+			protectedAuthServiceRevokeAccessTokenHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -154,3 +767,59 @@ type UnimplementedProtectedAuthServiceHandler struct{}
 func (UnimplementedProtectedAuthServiceHandler) Me(context.Context, *connect.Request[v1.MeRequest]) (*connect.Response[v1.ReadUser], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.Me is not implemented"))
 }
+
+func (UnimplementedProtectedAuthServiceHandler) ResendVerificationEmail(context.Context, *connect.Request[v1.ResendVerificationEmailRequest]) (*connect.Response[v1.ResendVerificationEmailResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.ResendVerificationEmail is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) EnrollTOTP(context.Context, *connect.Request[v1.EnrollTOTPRequest]) (*connect.Response[v1.EnrollTOTPResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.EnrollTOTP is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) ConfirmTOTP(context.Context, *connect.Request[v1.ConfirmTOTPRequest]) (*connect.Response[v1.ConfirmTOTPResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.ConfirmTOTP is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) DisableTOTP(context.Context, *connect.Request[v1.DisableTOTPRequest]) (*connect.Response[v1.DisableTOTPResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.DisableTOTP is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) RegenerateRecoveryCodes(context.Context, *connect.Request[v1.RegenerateRecoveryCodesRequest]) (*connect.Response[v1.RegenerateRecoveryCodesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.RegenerateRecoveryCodes is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) ListLinkedAccounts(context.Context, *connect.Request[v1.ListLinkedAccountsRequest]) (*connect.Response[v1.ListLinkedAccountsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.ListLinkedAccounts is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) LinkProvider(context.Context, *connect.Request[v1.LinkProviderRequest]) (*connect.Response[v1.LinkProviderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.LinkProvider is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) UnlinkProvider(context.Context, *connect.Request[v1.UnlinkProviderRequest]) (*connect.Response[v1.UnlinkProviderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.UnlinkProvider is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) ListSessions(context.Context, *connect.Request[v1.ListSessionsRequest]) (*connect.Response[v1.ListSessionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.ListSessions is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.RevokeSession is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) RevokeOtherSessions(context.Context, *connect.Request[v1.RevokeOtherSessionsRequest]) (*connect.Response[v1.RevokeOtherSessionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.RevokeOtherSessions is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) CreateAccessToken(context.Context, *connect.Request[v1.CreateAccessTokenRequest]) (*connect.Response[v1.CreateAccessTokenResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.CreateAccessToken is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) ListAccessTokens(context.Context, *connect.Request[v1.ListAccessTokensRequest]) (*connect.Response[v1.ListAccessTokensResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.ListAccessTokens is not implemented"))
+}
+
+func (UnimplementedProtectedAuthServiceHandler) RevokeAccessToken(context.Context, *connect.Request[v1.RevokeAccessTokenRequest]) (*connect.Response[v1.RevokeAccessTokenResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedAuthService.RevokeAccessToken is not implemented"))
+}
@@ -0,0 +1,240 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: proto/api/v1/authz.proto
+
+package apiv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	v1 "simple-connect/gen/proto/api/v1"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// AuthorizationServiceName is the fully-qualified name of the AuthorizationService service.
+	AuthorizationServiceName = "proto.api.v1.AuthorizationService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// AuthorizationServiceListMyPermissionsProcedure is the fully-qualified name of the
+	// AuthorizationService's ListMyPermissions RPC.
+	AuthorizationServiceListMyPermissionsProcedure = "/proto.api.v1.AuthorizationService/ListMyPermissions"
+	// AuthorizationServiceListRolesProcedure is the fully-qualified name of the AuthorizationService's
+	// ListRoles RPC.
+	AuthorizationServiceListRolesProcedure = "/proto.api.v1.AuthorizationService/ListRoles"
+	// AuthorizationServiceListUserRolesProcedure is the fully-qualified name of the
+	// AuthorizationService's ListUserRoles RPC.
+	AuthorizationServiceListUserRolesProcedure = "/proto.api.v1.AuthorizationService/ListUserRoles"
+	// AuthorizationServiceAssignRoleProcedure is the fully-qualified name of the AuthorizationService's
+	// AssignRole RPC.
+	AuthorizationServiceAssignRoleProcedure = "/proto.api.v1.AuthorizationService/AssignRole"
+	// AuthorizationServiceRevokeRoleProcedure is the fully-qualified name of the AuthorizationService's
+	// RevokeRole RPC.
+	AuthorizationServiceRevokeRoleProcedure = "/proto.api.v1.AuthorizationService/RevokeRole"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	authorizationServiceServiceDescriptor                 = v1.File_proto_api_v1_authz_proto.Services().ByName("AuthorizationService")
+	authorizationServiceListMyPermissionsMethodDescriptor = authorizationServiceServiceDescriptor.Methods().ByName("ListMyPermissions")
+	authorizationServiceListRolesMethodDescriptor         = authorizationServiceServiceDescriptor.Methods().ByName("ListRoles")
+	authorizationServiceListUserRolesMethodDescriptor     = authorizationServiceServiceDescriptor.Methods().ByName("ListUserRoles")
+	authorizationServiceAssignRoleMethodDescriptor        = authorizationServiceServiceDescriptor.Methods().ByName("AssignRole")
+	authorizationServiceRevokeRoleMethodDescriptor        = authorizationServiceServiceDescriptor.Methods().ByName("RevokeRole")
+)
+
+// AuthorizationServiceClient is a client for the proto.api.v1.AuthorizationService service.
+type AuthorizationServiceClient interface {
+	ListMyPermissions(context.Context, *connect.Request[v1.ListMyPermissionsRequest]) (*connect.Response[v1.ListMyPermissionsResponse], error)
+	ListRoles(context.Context, *connect.Request[v1.ListRolesRequest]) (*connect.Response[v1.ListRolesResponse], error)
+	ListUserRoles(context.Context, *connect.Request[v1.ListUserRolesRequest]) (*connect.Response[v1.ListUserRolesResponse], error)
+	AssignRole(context.Context, *connect.Request[v1.AssignRoleRequest]) (*connect.Response[v1.AssignRoleResponse], error)
+	RevokeRole(context.Context, *connect.Request[v1.RevokeRoleRequest]) (*connect.Response[v1.RevokeRoleResponse], error)
+}
+
+// NewAuthorizationServiceClient constructs a client for the proto.api.v1.AuthorizationService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAuthorizationServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AuthorizationServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &authorizationServiceClient{
+		listMyPermissions: connect.NewClient[v1.ListMyPermissionsRequest, v1.ListMyPermissionsResponse](
+			httpClient,
+			baseURL+AuthorizationServiceListMyPermissionsProcedure,
+			connect.WithSchema(authorizationServiceListMyPermissionsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		listRoles: connect.NewClient[v1.ListRolesRequest, v1.ListRolesResponse](
+			httpClient,
+			baseURL+AuthorizationServiceListRolesProcedure,
+			connect.WithSchema(authorizationServiceListRolesMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		listUserRoles: connect.NewClient[v1.ListUserRolesRequest, v1.ListUserRolesResponse](
+			httpClient,
+			baseURL+AuthorizationServiceListUserRolesProcedure,
+			connect.WithSchema(authorizationServiceListUserRolesMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		assignRole: connect.NewClient[v1.AssignRoleRequest, v1.AssignRoleResponse](
+			httpClient,
+			baseURL+AuthorizationServiceAssignRoleProcedure,
+			connect.WithSchema(authorizationServiceAssignRoleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		revokeRole: connect.NewClient[v1.RevokeRoleRequest, v1.RevokeRoleResponse](
+			httpClient,
+			baseURL+AuthorizationServiceRevokeRoleProcedure,
+			connect.WithSchema(authorizationServiceRevokeRoleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// authorizationServiceClient implements AuthorizationServiceClient.
+type authorizationServiceClient struct {
+	listMyPermissions *connect.Client[v1.ListMyPermissionsRequest, v1.ListMyPermissionsResponse]
+	listRoles         *connect.Client[v1.ListRolesRequest, v1.ListRolesResponse]
+	listUserRoles     *connect.Client[v1.ListUserRolesRequest, v1.ListUserRolesResponse]
+	assignRole        *connect.Client[v1.AssignRoleRequest, v1.AssignRoleResponse]
+	revokeRole        *connect.Client[v1.RevokeRoleRequest, v1.RevokeRoleResponse]
+}
+
+// ListMyPermissions calls proto.api.v1.AuthorizationService.ListMyPermissions.
+func (c *authorizationServiceClient) ListMyPermissions(ctx context.Context, req *connect.Request[v1.ListMyPermissionsRequest]) (*connect.Response[v1.ListMyPermissionsResponse], error) {
+	return c.listMyPermissions.CallUnary(ctx, req)
+}
+
+// ListRoles calls proto.api.v1.AuthorizationService.ListRoles.
+func (c *authorizationServiceClient) ListRoles(ctx context.Context, req *connect.Request[v1.ListRolesRequest]) (*connect.Response[v1.ListRolesResponse], error) {
+	return c.listRoles.CallUnary(ctx, req)
+}
+
+// ListUserRoles calls proto.api.v1.AuthorizationService.ListUserRoles.
+func (c *authorizationServiceClient) ListUserRoles(ctx context.Context, req *connect.Request[v1.ListUserRolesRequest]) (*connect.Response[v1.ListUserRolesResponse], error) {
+	return c.listUserRoles.CallUnary(ctx, req)
+}
+
+// AssignRole calls proto.api.v1.AuthorizationService.AssignRole.
+func (c *authorizationServiceClient) AssignRole(ctx context.Context, req *connect.Request[v1.AssignRoleRequest]) (*connect.Response[v1.AssignRoleResponse], error) {
+	return c.assignRole.CallUnary(ctx, req)
+}
+
+// RevokeRole calls proto.api.v1.AuthorizationService.RevokeRole.
+func (c *authorizationServiceClient) RevokeRole(ctx context.Context, req *connect.Request[v1.RevokeRoleRequest]) (*connect.Response[v1.RevokeRoleResponse], error) {
+	return c.revokeRole.CallUnary(ctx, req)
+}
+
+// AuthorizationServiceHandler is an implementation of the proto.api.v1.AuthorizationService
+// service.
+type AuthorizationServiceHandler interface {
+	ListMyPermissions(context.Context, *connect.Request[v1.ListMyPermissionsRequest]) (*connect.Response[v1.ListMyPermissionsResponse], error)
+	ListRoles(context.Context, *connect.Request[v1.ListRolesRequest]) (*connect.Response[v1.ListRolesResponse], error)
+	ListUserRoles(context.Context, *connect.Request[v1.ListUserRolesRequest]) (*connect.Response[v1.ListUserRolesResponse], error)
+	AssignRole(context.Context, *connect.Request[v1.AssignRoleRequest]) (*connect.Response[v1.AssignRoleResponse], error)
+	RevokeRole(context.Context, *connect.Request[v1.RevokeRoleRequest]) (*connect.Response[v1.RevokeRoleResponse], error)
+}
+
+// NewAuthorizationServiceHandler builds an HTTP handler from the service implementation. It returns
+// the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAuthorizationServiceHandler(svc AuthorizationServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	authorizationServiceListMyPermissionsHandler := connect.NewUnaryHandler(
+		AuthorizationServiceListMyPermissionsProcedure,
+		svc.ListMyPermissions,
+		connect.WithSchema(authorizationServiceListMyPermissionsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	authorizationServiceListRolesHandler := connect.NewUnaryHandler(
+		AuthorizationServiceListRolesProcedure,
+		svc.ListRoles,
+		connect.WithSchema(authorizationServiceListRolesMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	authorizationServiceListUserRolesHandler := connect.NewUnaryHandler(
+		AuthorizationServiceListUserRolesProcedure,
+		svc.ListUserRoles,
+		connect.WithSchema(authorizationServiceListUserRolesMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	authorizationServiceAssignRoleHandler := connect.NewUnaryHandler(
+		AuthorizationServiceAssignRoleProcedure,
+		svc.AssignRole,
+		connect.WithSchema(authorizationServiceAssignRoleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	authorizationServiceRevokeRoleHandler := connect.NewUnaryHandler(
+		AuthorizationServiceRevokeRoleProcedure,
+		svc.RevokeRole,
+		connect.WithSchema(authorizationServiceRevokeRoleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/proto.api.v1.AuthorizationService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AuthorizationServiceListMyPermissionsProcedure:
+			authorizationServiceListMyPermissionsHandler.ServeHTTP(w, r)
+		case AuthorizationServiceListRolesProcedure:
+			authorizationServiceListRolesHandler.ServeHTTP(w, r)
+		case AuthorizationServiceListUserRolesProcedure:
+			authorizationServiceListUserRolesHandler.ServeHTTP(w, r)
+		case AuthorizationServiceAssignRoleProcedure:
+			authorizationServiceAssignRoleHandler.ServeHTTP(w, r)
+		case AuthorizationServiceRevokeRoleProcedure:
+			authorizationServiceRevokeRoleHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAuthorizationServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAuthorizationServiceHandler struct{}
+
+func (UnimplementedAuthorizationServiceHandler) ListMyPermissions(context.Context, *connect.Request[v1.ListMyPermissionsRequest]) (*connect.Response[v1.ListMyPermissionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthorizationService.ListMyPermissions is not implemented"))
+}
+
+func (UnimplementedAuthorizationServiceHandler) ListRoles(context.Context, *connect.Request[v1.ListRolesRequest]) (*connect.Response[v1.ListRolesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthorizationService.ListRoles is not implemented"))
+}
+
+func (UnimplementedAuthorizationServiceHandler) ListUserRoles(context.Context, *connect.Request[v1.ListUserRolesRequest]) (*connect.Response[v1.ListUserRolesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthorizationService.ListUserRoles is not implemented"))
+}
+
+func (UnimplementedAuthorizationServiceHandler) AssignRole(context.Context, *connect.Request[v1.AssignRoleRequest]) (*connect.Response[v1.AssignRoleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthorizationService.AssignRole is not implemented"))
+}
+
+func (UnimplementedAuthorizationServiceHandler) RevokeRole(context.Context, *connect.Request[v1.RevokeRoleRequest]) (*connect.Response[v1.RevokeRoleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AuthorizationService.RevokeRole is not implemented"))
+}
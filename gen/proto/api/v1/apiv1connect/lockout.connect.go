@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: proto/api/v1/lockout.proto
+
+package apiv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	v1 "simple-connect/gen/proto/api/v1"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// AccountLockoutServiceName is the fully-qualified name of the AccountLockoutService service.
+	AccountLockoutServiceName = "proto.api.v1.AccountLockoutService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// AccountLockoutServiceGetAccountLockoutProcedure is the fully-qualified name of the
+	// AccountLockoutService's GetAccountLockout RPC.
+	AccountLockoutServiceGetAccountLockoutProcedure = "/proto.api.v1.AccountLockoutService/GetAccountLockout"
+	// AccountLockoutServiceUnlockAccountProcedure is the fully-qualified name of the
+	// AccountLockoutService's UnlockAccount RPC.
+	AccountLockoutServiceUnlockAccountProcedure = "/proto.api.v1.AccountLockoutService/UnlockAccount"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	accountLockoutServiceServiceDescriptor                 = v1.File_proto_api_v1_lockout_proto.Services().ByName("AccountLockoutService")
+	accountLockoutServiceGetAccountLockoutMethodDescriptor = accountLockoutServiceServiceDescriptor.Methods().ByName("GetAccountLockout")
+	accountLockoutServiceUnlockAccountMethodDescriptor     = accountLockoutServiceServiceDescriptor.Methods().ByName("UnlockAccount")
+)
+
+// AccountLockoutServiceClient is a client for the proto.api.v1.AccountLockoutService service.
+type AccountLockoutServiceClient interface {
+	GetAccountLockout(context.Context, *connect.Request[v1.GetAccountLockoutRequest]) (*connect.Response[v1.GetAccountLockoutResponse], error)
+	UnlockAccount(context.Context, *connect.Request[v1.UnlockAccountRequest]) (*connect.Response[v1.UnlockAccountResponse], error)
+}
+
+// NewAccountLockoutServiceClient constructs a client for the proto.api.v1.AccountLockoutService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAccountLockoutServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AccountLockoutServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &accountLockoutServiceClient{
+		getAccountLockout: connect.NewClient[v1.GetAccountLockoutRequest, v1.GetAccountLockoutResponse](
+			httpClient,
+			baseURL+AccountLockoutServiceGetAccountLockoutProcedure,
+			connect.WithSchema(accountLockoutServiceGetAccountLockoutMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		unlockAccount: connect.NewClient[v1.UnlockAccountRequest, v1.UnlockAccountResponse](
+			httpClient,
+			baseURL+AccountLockoutServiceUnlockAccountProcedure,
+			connect.WithSchema(accountLockoutServiceUnlockAccountMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// accountLockoutServiceClient implements AccountLockoutServiceClient.
+type accountLockoutServiceClient struct {
+	getAccountLockout *connect.Client[v1.GetAccountLockoutRequest, v1.GetAccountLockoutResponse]
+	unlockAccount     *connect.Client[v1.UnlockAccountRequest, v1.UnlockAccountResponse]
+}
+
+// GetAccountLockout calls proto.api.v1.AccountLockoutService.GetAccountLockout.
+func (c *accountLockoutServiceClient) GetAccountLockout(ctx context.Context, req *connect.Request[v1.GetAccountLockoutRequest]) (*connect.Response[v1.GetAccountLockoutResponse], error) {
+	return c.getAccountLockout.CallUnary(ctx, req)
+}
+
+// UnlockAccount calls proto.api.v1.AccountLockoutService.UnlockAccount.
+func (c *accountLockoutServiceClient) UnlockAccount(ctx context.Context, req *connect.Request[v1.UnlockAccountRequest]) (*connect.Response[v1.UnlockAccountResponse], error) {
+	return c.unlockAccount.CallUnary(ctx, req)
+}
+
+// AccountLockoutServiceHandler is an implementation of the proto.api.v1.AccountLockoutService
+// service.
+type AccountLockoutServiceHandler interface {
+	GetAccountLockout(context.Context, *connect.Request[v1.GetAccountLockoutRequest]) (*connect.Response[v1.GetAccountLockoutResponse], error)
+	UnlockAccount(context.Context, *connect.Request[v1.UnlockAccountRequest]) (*connect.Response[v1.UnlockAccountResponse], error)
+}
+
+// NewAccountLockoutServiceHandler builds an HTTP handler from the service implementation. It
+// returns the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAccountLockoutServiceHandler(svc AccountLockoutServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	accountLockoutServiceGetAccountLockoutHandler := connect.NewUnaryHandler(
+		AccountLockoutServiceGetAccountLockoutProcedure,
+		svc.GetAccountLockout,
+		connect.WithSchema(accountLockoutServiceGetAccountLockoutMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	accountLockoutServiceUnlockAccountHandler := connect.NewUnaryHandler(
+		AccountLockoutServiceUnlockAccountProcedure,
+		svc.UnlockAccount,
+		connect.WithSchema(accountLockoutServiceUnlockAccountMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/proto.api.v1.AccountLockoutService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AccountLockoutServiceGetAccountLockoutProcedure:
+			accountLockoutServiceGetAccountLockoutHandler.ServeHTTP(w, r)
+		case AccountLockoutServiceUnlockAccountProcedure:
+			accountLockoutServiceUnlockAccountHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAccountLockoutServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAccountLockoutServiceHandler struct{}
+
+func (UnimplementedAccountLockoutServiceHandler) GetAccountLockout(context.Context, *connect.Request[v1.GetAccountLockoutRequest]) (*connect.Response[v1.GetAccountLockoutResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AccountLockoutService.GetAccountLockout is not implemented"))
+}
+
+func (UnimplementedAccountLockoutServiceHandler) UnlockAccount(context.Context, *connect.Request[v1.UnlockAccountRequest]) (*connect.Response[v1.UnlockAccountResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.AccountLockoutService.UnlockAccount is not implemented"))
+}
@@ -0,0 +1,451 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: proto/api/v1/organization.proto
+
+package apiv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	v1 "simple-connect/gen/proto/api/v1"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// OrganizationServiceName is the fully-qualified name of the OrganizationService service.
+	OrganizationServiceName = "proto.api.v1.OrganizationService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// OrganizationServiceCreateOrganizationProcedure is the fully-qualified name of the
+	// OrganizationService's CreateOrganization RPC.
+	OrganizationServiceCreateOrganizationProcedure = "/proto.api.v1.OrganizationService/CreateOrganization"
+	// OrganizationServiceListOrganizationsProcedure is the fully-qualified name of the
+	// OrganizationService's ListOrganizations RPC.
+	OrganizationServiceListOrganizationsProcedure = "/proto.api.v1.OrganizationService/ListOrganizations"
+	// OrganizationServiceDeleteOrganizationProcedure is the fully-qualified name of the
+	// OrganizationService's DeleteOrganization RPC.
+	OrganizationServiceDeleteOrganizationProcedure = "/proto.api.v1.OrganizationService/DeleteOrganization"
+	// OrganizationServiceListMembersProcedure is the fully-qualified name of the OrganizationService's
+	// ListMembers RPC.
+	OrganizationServiceListMembersProcedure = "/proto.api.v1.OrganizationService/ListMembers"
+	// OrganizationServiceUpdateMemberRoleProcedure is the fully-qualified name of the
+	// OrganizationService's UpdateMemberRole RPC.
+	OrganizationServiceUpdateMemberRoleProcedure = "/proto.api.v1.OrganizationService/UpdateMemberRole"
+	// OrganizationServiceRemoveMemberProcedure is the fully-qualified name of the OrganizationService's
+	// RemoveMember RPC.
+	OrganizationServiceRemoveMemberProcedure = "/proto.api.v1.OrganizationService/RemoveMember"
+	// OrganizationServiceCreateInvitationProcedure is the fully-qualified name of the
+	// OrganizationService's CreateInvitation RPC.
+	OrganizationServiceCreateInvitationProcedure = "/proto.api.v1.OrganizationService/CreateInvitation"
+	// OrganizationServiceListInvitationsProcedure is the fully-qualified name of the
+	// OrganizationService's ListInvitations RPC.
+	OrganizationServiceListInvitationsProcedure = "/proto.api.v1.OrganizationService/ListInvitations"
+	// OrganizationServiceRevokeInvitationProcedure is the fully-qualified name of the
+	// OrganizationService's RevokeInvitation RPC.
+	OrganizationServiceRevokeInvitationProcedure = "/proto.api.v1.OrganizationService/RevokeInvitation"
+	// OrganizationServiceAcceptInvitationProcedure is the fully-qualified name of the
+	// OrganizationService's AcceptInvitation RPC.
+	OrganizationServiceAcceptInvitationProcedure = "/proto.api.v1.OrganizationService/AcceptInvitation"
+	// OrganizationServiceSetActiveOrganizationProcedure is the fully-qualified name of the
+	// OrganizationService's SetActiveOrganization RPC.
+	OrganizationServiceSetActiveOrganizationProcedure = "/proto.api.v1.OrganizationService/SetActiveOrganization"
+	// OrganizationServiceGetActiveOrganizationProcedure is the fully-qualified name of the
+	// OrganizationService's GetActiveOrganization RPC.
+	OrganizationServiceGetActiveOrganizationProcedure = "/proto.api.v1.OrganizationService/GetActiveOrganization"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	organizationServiceServiceDescriptor                     = v1.File_proto_api_v1_organization_proto.Services().ByName("OrganizationService")
+	organizationServiceCreateOrganizationMethodDescriptor    = organizationServiceServiceDescriptor.Methods().ByName("CreateOrganization")
+	organizationServiceListOrganizationsMethodDescriptor     = organizationServiceServiceDescriptor.Methods().ByName("ListOrganizations")
+	organizationServiceDeleteOrganizationMethodDescriptor    = organizationServiceServiceDescriptor.Methods().ByName("DeleteOrganization")
+	organizationServiceListMembersMethodDescriptor           = organizationServiceServiceDescriptor.Methods().ByName("ListMembers")
+	organizationServiceUpdateMemberRoleMethodDescriptor      = organizationServiceServiceDescriptor.Methods().ByName("UpdateMemberRole")
+	organizationServiceRemoveMemberMethodDescriptor          = organizationServiceServiceDescriptor.Methods().ByName("RemoveMember")
+	organizationServiceCreateInvitationMethodDescriptor      = organizationServiceServiceDescriptor.Methods().ByName("CreateInvitation")
+	organizationServiceListInvitationsMethodDescriptor       = organizationServiceServiceDescriptor.Methods().ByName("ListInvitations")
+	organizationServiceRevokeInvitationMethodDescriptor      = organizationServiceServiceDescriptor.Methods().ByName("RevokeInvitation")
+	organizationServiceAcceptInvitationMethodDescriptor      = organizationServiceServiceDescriptor.Methods().ByName("AcceptInvitation")
+	organizationServiceSetActiveOrganizationMethodDescriptor = organizationServiceServiceDescriptor.Methods().ByName("SetActiveOrganization")
+	organizationServiceGetActiveOrganizationMethodDescriptor = organizationServiceServiceDescriptor.Methods().ByName("GetActiveOrganization")
+)
+
+// OrganizationServiceClient is a client for the proto.api.v1.OrganizationService service.
+type OrganizationServiceClient interface {
+	CreateOrganization(context.Context, *connect.Request[v1.CreateOrganizationRequest]) (*connect.Response[v1.CreateOrganizationResponse], error)
+	ListOrganizations(context.Context, *connect.Request[v1.ListOrganizationsRequest]) (*connect.Response[v1.ListOrganizationsResponse], error)
+	DeleteOrganization(context.Context, *connect.Request[v1.DeleteOrganizationRequest]) (*connect.Response[v1.DeleteOrganizationResponse], error)
+	ListMembers(context.Context, *connect.Request[v1.ListMembersRequest]) (*connect.Response[v1.ListMembersResponse], error)
+	UpdateMemberRole(context.Context, *connect.Request[v1.UpdateMemberRoleRequest]) (*connect.Response[v1.UpdateMemberRoleResponse], error)
+	RemoveMember(context.Context, *connect.Request[v1.RemoveMemberRequest]) (*connect.Response[v1.RemoveMemberResponse], error)
+	CreateInvitation(context.Context, *connect.Request[v1.CreateInvitationRequest]) (*connect.Response[v1.CreateInvitationResponse], error)
+	ListInvitations(context.Context, *connect.Request[v1.ListInvitationsRequest]) (*connect.Response[v1.ListInvitationsResponse], error)
+	RevokeInvitation(context.Context, *connect.Request[v1.RevokeInvitationRequest]) (*connect.Response[v1.RevokeInvitationResponse], error)
+	AcceptInvitation(context.Context, *connect.Request[v1.AcceptInvitationRequest]) (*connect.Response[v1.AcceptInvitationResponse], error)
+	SetActiveOrganization(context.Context, *connect.Request[v1.SetActiveOrganizationRequest]) (*connect.Response[v1.SetActiveOrganizationResponse], error)
+	GetActiveOrganization(context.Context, *connect.Request[v1.GetActiveOrganizationRequest]) (*connect.Response[v1.GetActiveOrganizationResponse], error)
+}
+
+// NewOrganizationServiceClient constructs a client for the proto.api.v1.OrganizationService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewOrganizationServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) OrganizationServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &organizationServiceClient{
+		createOrganization: connect.NewClient[v1.CreateOrganizationRequest, v1.CreateOrganizationResponse](
+			httpClient,
+			baseURL+OrganizationServiceCreateOrganizationProcedure,
+			connect.WithSchema(organizationServiceCreateOrganizationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listOrganizations: connect.NewClient[v1.ListOrganizationsRequest, v1.ListOrganizationsResponse](
+			httpClient,
+			baseURL+OrganizationServiceListOrganizationsProcedure,
+			connect.WithSchema(organizationServiceListOrganizationsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		deleteOrganization: connect.NewClient[v1.DeleteOrganizationRequest, v1.DeleteOrganizationResponse](
+			httpClient,
+			baseURL+OrganizationServiceDeleteOrganizationProcedure,
+			connect.WithSchema(organizationServiceDeleteOrganizationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listMembers: connect.NewClient[v1.ListMembersRequest, v1.ListMembersResponse](
+			httpClient,
+			baseURL+OrganizationServiceListMembersProcedure,
+			connect.WithSchema(organizationServiceListMembersMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		updateMemberRole: connect.NewClient[v1.UpdateMemberRoleRequest, v1.UpdateMemberRoleResponse](
+			httpClient,
+			baseURL+OrganizationServiceUpdateMemberRoleProcedure,
+			connect.WithSchema(organizationServiceUpdateMemberRoleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		removeMember: connect.NewClient[v1.RemoveMemberRequest, v1.RemoveMemberResponse](
+			httpClient,
+			baseURL+OrganizationServiceRemoveMemberProcedure,
+			connect.WithSchema(organizationServiceRemoveMemberMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createInvitation: connect.NewClient[v1.CreateInvitationRequest, v1.CreateInvitationResponse](
+			httpClient,
+			baseURL+OrganizationServiceCreateInvitationProcedure,
+			connect.WithSchema(organizationServiceCreateInvitationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listInvitations: connect.NewClient[v1.ListInvitationsRequest, v1.ListInvitationsResponse](
+			httpClient,
+			baseURL+OrganizationServiceListInvitationsProcedure,
+			connect.WithSchema(organizationServiceListInvitationsMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		revokeInvitation: connect.NewClient[v1.RevokeInvitationRequest, v1.RevokeInvitationResponse](
+			httpClient,
+			baseURL+OrganizationServiceRevokeInvitationProcedure,
+			connect.WithSchema(organizationServiceRevokeInvitationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		acceptInvitation: connect.NewClient[v1.AcceptInvitationRequest, v1.AcceptInvitationResponse](
+			httpClient,
+			baseURL+OrganizationServiceAcceptInvitationProcedure,
+			connect.WithSchema(organizationServiceAcceptInvitationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		setActiveOrganization: connect.NewClient[v1.SetActiveOrganizationRequest, v1.SetActiveOrganizationResponse](
+			httpClient,
+			baseURL+OrganizationServiceSetActiveOrganizationProcedure,
+			connect.WithSchema(organizationServiceSetActiveOrganizationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getActiveOrganization: connect.NewClient[v1.GetActiveOrganizationRequest, v1.GetActiveOrganizationResponse](
+			httpClient,
+			baseURL+OrganizationServiceGetActiveOrganizationProcedure,
+			connect.WithSchema(organizationServiceGetActiveOrganizationMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// organizationServiceClient implements OrganizationServiceClient.
+type organizationServiceClient struct {
+	createOrganization    *connect.Client[v1.CreateOrganizationRequest, v1.CreateOrganizationResponse]
+	listOrganizations     *connect.Client[v1.ListOrganizationsRequest, v1.ListOrganizationsResponse]
+	deleteOrganization    *connect.Client[v1.DeleteOrganizationRequest, v1.DeleteOrganizationResponse]
+	listMembers           *connect.Client[v1.ListMembersRequest, v1.ListMembersResponse]
+	updateMemberRole      *connect.Client[v1.UpdateMemberRoleRequest, v1.UpdateMemberRoleResponse]
+	removeMember          *connect.Client[v1.RemoveMemberRequest, v1.RemoveMemberResponse]
+	createInvitation      *connect.Client[v1.CreateInvitationRequest, v1.CreateInvitationResponse]
+	listInvitations       *connect.Client[v1.ListInvitationsRequest, v1.ListInvitationsResponse]
+	revokeInvitation      *connect.Client[v1.RevokeInvitationRequest, v1.RevokeInvitationResponse]
+	acceptInvitation      *connect.Client[v1.AcceptInvitationRequest, v1.AcceptInvitationResponse]
+	setActiveOrganization *connect.Client[v1.SetActiveOrganizationRequest, v1.SetActiveOrganizationResponse]
+	getActiveOrganization *connect.Client[v1.GetActiveOrganizationRequest, v1.GetActiveOrganizationResponse]
+}
+
+// CreateOrganization calls proto.api.v1.OrganizationService.CreateOrganization.
+func (c *organizationServiceClient) CreateOrganization(ctx context.Context, req *connect.Request[v1.CreateOrganizationRequest]) (*connect.Response[v1.CreateOrganizationResponse], error) {
+	return c.createOrganization.CallUnary(ctx, req)
+}
+
+// ListOrganizations calls proto.api.v1.OrganizationService.ListOrganizations.
+func (c *organizationServiceClient) ListOrganizations(ctx context.Context, req *connect.Request[v1.ListOrganizationsRequest]) (*connect.Response[v1.ListOrganizationsResponse], error) {
+	return c.listOrganizations.CallUnary(ctx, req)
+}
+
+// DeleteOrganization calls proto.api.v1.OrganizationService.DeleteOrganization.
+func (c *organizationServiceClient) DeleteOrganization(ctx context.Context, req *connect.Request[v1.DeleteOrganizationRequest]) (*connect.Response[v1.DeleteOrganizationResponse], error) {
+	return c.deleteOrganization.CallUnary(ctx, req)
+}
+
+// ListMembers calls proto.api.v1.OrganizationService.ListMembers.
+func (c *organizationServiceClient) ListMembers(ctx context.Context, req *connect.Request[v1.ListMembersRequest]) (*connect.Response[v1.ListMembersResponse], error) {
+	return c.listMembers.CallUnary(ctx, req)
+}
+
+// UpdateMemberRole calls proto.api.v1.OrganizationService.UpdateMemberRole.
+func (c *organizationServiceClient) UpdateMemberRole(ctx context.Context, req *connect.Request[v1.UpdateMemberRoleRequest]) (*connect.Response[v1.UpdateMemberRoleResponse], error) {
+	return c.updateMemberRole.CallUnary(ctx, req)
+}
+
+// RemoveMember calls proto.api.v1.OrganizationService.RemoveMember.
+func (c *organizationServiceClient) RemoveMember(ctx context.Context, req *connect.Request[v1.RemoveMemberRequest]) (*connect.Response[v1.RemoveMemberResponse], error) {
+	return c.removeMember.CallUnary(ctx, req)
+}
+
+// CreateInvitation calls proto.api.v1.OrganizationService.CreateInvitation.
+func (c *organizationServiceClient) CreateInvitation(ctx context.Context, req *connect.Request[v1.CreateInvitationRequest]) (*connect.Response[v1.CreateInvitationResponse], error) {
+	return c.createInvitation.CallUnary(ctx, req)
+}
+
+// ListInvitations calls proto.api.v1.OrganizationService.ListInvitations.
+func (c *organizationServiceClient) ListInvitations(ctx context.Context, req *connect.Request[v1.ListInvitationsRequest]) (*connect.Response[v1.ListInvitationsResponse], error) {
+	return c.listInvitations.CallUnary(ctx, req)
+}
+
+// RevokeInvitation calls proto.api.v1.OrganizationService.RevokeInvitation.
+func (c *organizationServiceClient) RevokeInvitation(ctx context.Context, req *connect.Request[v1.RevokeInvitationRequest]) (*connect.Response[v1.RevokeInvitationResponse], error) {
+	return c.revokeInvitation.CallUnary(ctx, req)
+}
+
+// AcceptInvitation calls proto.api.v1.OrganizationService.AcceptInvitation.
+func (c *organizationServiceClient) AcceptInvitation(ctx context.Context, req *connect.Request[v1.AcceptInvitationRequest]) (*connect.Response[v1.AcceptInvitationResponse], error) {
+	return c.acceptInvitation.CallUnary(ctx, req)
+}
+
+// SetActiveOrganization calls proto.api.v1.OrganizationService.SetActiveOrganization.
+func (c *organizationServiceClient) SetActiveOrganization(ctx context.Context, req *connect.Request[v1.SetActiveOrganizationRequest]) (*connect.Response[v1.SetActiveOrganizationResponse], error) {
+	return c.setActiveOrganization.CallUnary(ctx, req)
+}
+
+// GetActiveOrganization calls proto.api.v1.OrganizationService.GetActiveOrganization.
+func (c *organizationServiceClient) GetActiveOrganization(ctx context.Context, req *connect.Request[v1.GetActiveOrganizationRequest]) (*connect.Response[v1.GetActiveOrganizationResponse], error) {
+	return c.getActiveOrganization.CallUnary(ctx, req)
+}
+
+// OrganizationServiceHandler is an implementation of the proto.api.v1.OrganizationService service.
+type OrganizationServiceHandler interface {
+	CreateOrganization(context.Context, *connect.Request[v1.CreateOrganizationRequest]) (*connect.Response[v1.CreateOrganizationResponse], error)
+	ListOrganizations(context.Context, *connect.Request[v1.ListOrganizationsRequest]) (*connect.Response[v1.ListOrganizationsResponse], error)
+	DeleteOrganization(context.Context, *connect.Request[v1.DeleteOrganizationRequest]) (*connect.Response[v1.DeleteOrganizationResponse], error)
+	ListMembers(context.Context, *connect.Request[v1.ListMembersRequest]) (*connect.Response[v1.ListMembersResponse], error)
+	UpdateMemberRole(context.Context, *connect.Request[v1.UpdateMemberRoleRequest]) (*connect.Response[v1.UpdateMemberRoleResponse], error)
+	RemoveMember(context.Context, *connect.Request[v1.RemoveMemberRequest]) (*connect.Response[v1.RemoveMemberResponse], error)
+	CreateInvitation(context.Context, *connect.Request[v1.CreateInvitationRequest]) (*connect.Response[v1.CreateInvitationResponse], error)
+	ListInvitations(context.Context, *connect.Request[v1.ListInvitationsRequest]) (*connect.Response[v1.ListInvitationsResponse], error)
+	RevokeInvitation(context.Context, *connect.Request[v1.RevokeInvitationRequest]) (*connect.Response[v1.RevokeInvitationResponse], error)
+	AcceptInvitation(context.Context, *connect.Request[v1.AcceptInvitationRequest]) (*connect.Response[v1.AcceptInvitationResponse], error)
+	SetActiveOrganization(context.Context, *connect.Request[v1.SetActiveOrganizationRequest]) (*connect.Response[v1.SetActiveOrganizationResponse], error)
+	GetActiveOrganization(context.Context, *connect.Request[v1.GetActiveOrganizationRequest]) (*connect.Response[v1.GetActiveOrganizationResponse], error)
+}
+
+// NewOrganizationServiceHandler builds an HTTP handler from the service implementation. It returns
+// the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewOrganizationServiceHandler(svc OrganizationServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	organizationServiceCreateOrganizationHandler := connect.NewUnaryHandler(
+		OrganizationServiceCreateOrganizationProcedure,
+		svc.CreateOrganization,
+		connect.WithSchema(organizationServiceCreateOrganizationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceListOrganizationsHandler := connect.NewUnaryHandler(
+		OrganizationServiceListOrganizationsProcedure,
+		svc.ListOrganizations,
+		connect.WithSchema(organizationServiceListOrganizationsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceDeleteOrganizationHandler := connect.NewUnaryHandler(
+		OrganizationServiceDeleteOrganizationProcedure,
+		svc.DeleteOrganization,
+		connect.WithSchema(organizationServiceDeleteOrganizationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceListMembersHandler := connect.NewUnaryHandler(
+		OrganizationServiceListMembersProcedure,
+		svc.ListMembers,
+		connect.WithSchema(organizationServiceListMembersMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceUpdateMemberRoleHandler := connect.NewUnaryHandler(
+		OrganizationServiceUpdateMemberRoleProcedure,
+		svc.UpdateMemberRole,
+		connect.WithSchema(organizationServiceUpdateMemberRoleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceRemoveMemberHandler := connect.NewUnaryHandler(
+		OrganizationServiceRemoveMemberProcedure,
+		svc.RemoveMember,
+		connect.WithSchema(organizationServiceRemoveMemberMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceCreateInvitationHandler := connect.NewUnaryHandler(
+		OrganizationServiceCreateInvitationProcedure,
+		svc.CreateInvitation,
+		connect.WithSchema(organizationServiceCreateInvitationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceListInvitationsHandler := connect.NewUnaryHandler(
+		OrganizationServiceListInvitationsProcedure,
+		svc.ListInvitations,
+		connect.WithSchema(organizationServiceListInvitationsMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceRevokeInvitationHandler := connect.NewUnaryHandler(
+		OrganizationServiceRevokeInvitationProcedure,
+		svc.RevokeInvitation,
+		connect.WithSchema(organizationServiceRevokeInvitationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceAcceptInvitationHandler := connect.NewUnaryHandler(
+		OrganizationServiceAcceptInvitationProcedure,
+		svc.AcceptInvitation,
+		connect.WithSchema(organizationServiceAcceptInvitationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceSetActiveOrganizationHandler := connect.NewUnaryHandler(
+		OrganizationServiceSetActiveOrganizationProcedure,
+		svc.SetActiveOrganization,
+		connect.WithSchema(organizationServiceSetActiveOrganizationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	organizationServiceGetActiveOrganizationHandler := connect.NewUnaryHandler(
+		OrganizationServiceGetActiveOrganizationProcedure,
+		svc.GetActiveOrganization,
+		connect.WithSchema(organizationServiceGetActiveOrganizationMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/proto.api.v1.OrganizationService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case OrganizationServiceCreateOrganizationProcedure:
+			organizationServiceCreateOrganizationHandler.ServeHTTP(w, r)
+		case OrganizationServiceListOrganizationsProcedure:
+			organizationServiceListOrganizationsHandler.ServeHTTP(w, r)
+		case OrganizationServiceDeleteOrganizationProcedure:
+			organizationServiceDeleteOrganizationHandler.ServeHTTP(w, r)
+		case OrganizationServiceListMembersProcedure:
+			organizationServiceListMembersHandler.ServeHTTP(w, r)
+		case OrganizationServiceUpdateMemberRoleProcedure:
+			organizationServiceUpdateMemberRoleHandler.ServeHTTP(w, r)
+		case OrganizationServiceRemoveMemberProcedure:
+			organizationServiceRemoveMemberHandler.ServeHTTP(w, r)
+		case OrganizationServiceCreateInvitationProcedure:
+			organizationServiceCreateInvitationHandler.ServeHTTP(w, r)
+		case OrganizationServiceListInvitationsProcedure:
+			organizationServiceListInvitationsHandler.ServeHTTP(w, r)
+		case OrganizationServiceRevokeInvitationProcedure:
+			organizationServiceRevokeInvitationHandler.ServeHTTP(w, r)
+		case OrganizationServiceAcceptInvitationProcedure:
+			organizationServiceAcceptInvitationHandler.ServeHTTP(w, r)
+		case OrganizationServiceSetActiveOrganizationProcedure:
+			organizationServiceSetActiveOrganizationHandler.ServeHTTP(w, r)
+		case OrganizationServiceGetActiveOrganizationProcedure:
+			organizationServiceGetActiveOrganizationHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedOrganizationServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedOrganizationServiceHandler struct{}
+
+func (UnimplementedOrganizationServiceHandler) CreateOrganization(context.Context, *connect.Request[v1.CreateOrganizationRequest]) (*connect.Response[v1.CreateOrganizationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.CreateOrganization is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) ListOrganizations(context.Context, *connect.Request[v1.ListOrganizationsRequest]) (*connect.Response[v1.ListOrganizationsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.ListOrganizations is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) DeleteOrganization(context.Context, *connect.Request[v1.DeleteOrganizationRequest]) (*connect.Response[v1.DeleteOrganizationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.DeleteOrganization is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) ListMembers(context.Context, *connect.Request[v1.ListMembersRequest]) (*connect.Response[v1.ListMembersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.ListMembers is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) UpdateMemberRole(context.Context, *connect.Request[v1.UpdateMemberRoleRequest]) (*connect.Response[v1.UpdateMemberRoleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.UpdateMemberRole is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) RemoveMember(context.Context, *connect.Request[v1.RemoveMemberRequest]) (*connect.Response[v1.RemoveMemberResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.RemoveMember is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) CreateInvitation(context.Context, *connect.Request[v1.CreateInvitationRequest]) (*connect.Response[v1.CreateInvitationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.CreateInvitation is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) ListInvitations(context.Context, *connect.Request[v1.ListInvitationsRequest]) (*connect.Response[v1.ListInvitationsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.ListInvitations is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) RevokeInvitation(context.Context, *connect.Request[v1.RevokeInvitationRequest]) (*connect.Response[v1.RevokeInvitationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.RevokeInvitation is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) AcceptInvitation(context.Context, *connect.Request[v1.AcceptInvitationRequest]) (*connect.Response[v1.AcceptInvitationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.AcceptInvitation is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) SetActiveOrganization(context.Context, *connect.Request[v1.SetActiveOrganizationRequest]) (*connect.Response[v1.SetActiveOrganizationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.SetActiveOrganization is not implemented"))
+}
+
+func (UnimplementedOrganizationServiceHandler) GetActiveOrganization(context.Context, *connect.Request[v1.GetActiveOrganizationRequest]) (*connect.Response[v1.GetActiveOrganizationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.OrganizationService.GetActiveOrganization is not implemented"))
+}
@@ -0,0 +1,311 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: proto/api/v1/passkey.proto
+
+package apiv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	v1 "simple-connect/gen/proto/api/v1"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// PasskeyServiceName is the fully-qualified name of the PasskeyService service.
+	PasskeyServiceName = "proto.api.v1.PasskeyService"
+	// ProtectedPasskeyServiceName is the fully-qualified name of the ProtectedPasskeyService service.
+	ProtectedPasskeyServiceName = "proto.api.v1.ProtectedPasskeyService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// PasskeyServiceBeginPasskeyLoginProcedure is the fully-qualified name of the PasskeyService's
+	// BeginPasskeyLogin RPC.
+	PasskeyServiceBeginPasskeyLoginProcedure = "/proto.api.v1.PasskeyService/BeginPasskeyLogin"
+	// PasskeyServiceFinishPasskeyLoginProcedure is the fully-qualified name of the PasskeyService's
+	// FinishPasskeyLogin RPC.
+	PasskeyServiceFinishPasskeyLoginProcedure = "/proto.api.v1.PasskeyService/FinishPasskeyLogin"
+	// ProtectedPasskeyServiceBeginPasskeyRegistrationProcedure is the fully-qualified name of the
+	// ProtectedPasskeyService's BeginPasskeyRegistration RPC.
+	ProtectedPasskeyServiceBeginPasskeyRegistrationProcedure = "/proto.api.v1.ProtectedPasskeyService/BeginPasskeyRegistration"
+	// ProtectedPasskeyServiceFinishPasskeyRegistrationProcedure is the fully-qualified name of the
+	// ProtectedPasskeyService's FinishPasskeyRegistration RPC.
+	ProtectedPasskeyServiceFinishPasskeyRegistrationProcedure = "/proto.api.v1.ProtectedPasskeyService/FinishPasskeyRegistration"
+	// ProtectedPasskeyServiceListPasskeysProcedure is the fully-qualified name of the
+	// ProtectedPasskeyService's ListPasskeys RPC.
+	ProtectedPasskeyServiceListPasskeysProcedure = "/proto.api.v1.ProtectedPasskeyService/ListPasskeys"
+	// ProtectedPasskeyServiceDeletePasskeyProcedure is the fully-qualified name of the
+	// ProtectedPasskeyService's DeletePasskey RPC.
+	ProtectedPasskeyServiceDeletePasskeyProcedure = "/proto.api.v1.ProtectedPasskeyService/DeletePasskey"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	passkeyServiceServiceDescriptor                                  = v1.File_proto_api_v1_passkey_proto.Services().ByName("PasskeyService")
+	passkeyServiceBeginPasskeyLoginMethodDescriptor                  = passkeyServiceServiceDescriptor.Methods().ByName("BeginPasskeyLogin")
+	passkeyServiceFinishPasskeyLoginMethodDescriptor                 = passkeyServiceServiceDescriptor.Methods().ByName("FinishPasskeyLogin")
+	protectedPasskeyServiceServiceDescriptor                         = v1.File_proto_api_v1_passkey_proto.Services().ByName("ProtectedPasskeyService")
+	protectedPasskeyServiceBeginPasskeyRegistrationMethodDescriptor  = protectedPasskeyServiceServiceDescriptor.Methods().ByName("BeginPasskeyRegistration")
+	protectedPasskeyServiceFinishPasskeyRegistrationMethodDescriptor = protectedPasskeyServiceServiceDescriptor.Methods().ByName("FinishPasskeyRegistration")
+	protectedPasskeyServiceListPasskeysMethodDescriptor              = protectedPasskeyServiceServiceDescriptor.Methods().ByName("ListPasskeys")
+	protectedPasskeyServiceDeletePasskeyMethodDescriptor             = protectedPasskeyServiceServiceDescriptor.Methods().ByName("DeletePasskey")
+)
+
+// PasskeyServiceClient is a client for the proto.api.v1.PasskeyService service.
+type PasskeyServiceClient interface {
+	BeginPasskeyLogin(context.Context, *connect.Request[v1.BeginPasskeyLoginRequest]) (*connect.Response[v1.BeginPasskeyLoginResponse], error)
+	FinishPasskeyLogin(context.Context, *connect.Request[v1.FinishPasskeyLoginRequest]) (*connect.Response[v1.FinishPasskeyLoginResponse], error)
+}
+
+// NewPasskeyServiceClient constructs a client for the proto.api.v1.PasskeyService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewPasskeyServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) PasskeyServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &passkeyServiceClient{
+		beginPasskeyLogin: connect.NewClient[v1.BeginPasskeyLoginRequest, v1.BeginPasskeyLoginResponse](
+			httpClient,
+			baseURL+PasskeyServiceBeginPasskeyLoginProcedure,
+			connect.WithSchema(passkeyServiceBeginPasskeyLoginMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		finishPasskeyLogin: connect.NewClient[v1.FinishPasskeyLoginRequest, v1.FinishPasskeyLoginResponse](
+			httpClient,
+			baseURL+PasskeyServiceFinishPasskeyLoginProcedure,
+			connect.WithSchema(passkeyServiceFinishPasskeyLoginMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// passkeyServiceClient implements PasskeyServiceClient.
+type passkeyServiceClient struct {
+	beginPasskeyLogin  *connect.Client[v1.BeginPasskeyLoginRequest, v1.BeginPasskeyLoginResponse]
+	finishPasskeyLogin *connect.Client[v1.FinishPasskeyLoginRequest, v1.FinishPasskeyLoginResponse]
+}
+
+// BeginPasskeyLogin calls proto.api.v1.PasskeyService.BeginPasskeyLogin.
+func (c *passkeyServiceClient) BeginPasskeyLogin(ctx context.Context, req *connect.Request[v1.BeginPasskeyLoginRequest]) (*connect.Response[v1.BeginPasskeyLoginResponse], error) {
+	return c.beginPasskeyLogin.CallUnary(ctx, req)
+}
+
+// FinishPasskeyLogin calls proto.api.v1.PasskeyService.FinishPasskeyLogin.
+func (c *passkeyServiceClient) FinishPasskeyLogin(ctx context.Context, req *connect.Request[v1.FinishPasskeyLoginRequest]) (*connect.Response[v1.FinishPasskeyLoginResponse], error) {
+	return c.finishPasskeyLogin.CallUnary(ctx, req)
+}
+
+// PasskeyServiceHandler is an implementation of the proto.api.v1.PasskeyService service.
+type PasskeyServiceHandler interface {
+	BeginPasskeyLogin(context.Context, *connect.Request[v1.BeginPasskeyLoginRequest]) (*connect.Response[v1.BeginPasskeyLoginResponse], error)
+	FinishPasskeyLogin(context.Context, *connect.Request[v1.FinishPasskeyLoginRequest]) (*connect.Response[v1.FinishPasskeyLoginResponse], error)
+}
+
+// NewPasskeyServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewPasskeyServiceHandler(svc PasskeyServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	passkeyServiceBeginPasskeyLoginHandler := connect.NewUnaryHandler(
+		PasskeyServiceBeginPasskeyLoginProcedure,
+		svc.BeginPasskeyLogin,
+		connect.WithSchema(passkeyServiceBeginPasskeyLoginMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	passkeyServiceFinishPasskeyLoginHandler := connect.NewUnaryHandler(
+		PasskeyServiceFinishPasskeyLoginProcedure,
+		svc.FinishPasskeyLogin,
+		connect.WithSchema(passkeyServiceFinishPasskeyLoginMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/proto.api.v1.PasskeyService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case PasskeyServiceBeginPasskeyLoginProcedure:
+			passkeyServiceBeginPasskeyLoginHandler.ServeHTTP(w, r)
+		case PasskeyServiceFinishPasskeyLoginProcedure:
+			passkeyServiceFinishPasskeyLoginHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedPasskeyServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedPasskeyServiceHandler struct{}
+
+func (UnimplementedPasskeyServiceHandler) BeginPasskeyLogin(context.Context, *connect.Request[v1.BeginPasskeyLoginRequest]) (*connect.Response[v1.BeginPasskeyLoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.PasskeyService.BeginPasskeyLogin is not implemented"))
+}
+
+func (UnimplementedPasskeyServiceHandler) FinishPasskeyLogin(context.Context, *connect.Request[v1.FinishPasskeyLoginRequest]) (*connect.Response[v1.FinishPasskeyLoginResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.PasskeyService.FinishPasskeyLogin is not implemented"))
+}
+
+// ProtectedPasskeyServiceClient is a client for the proto.api.v1.ProtectedPasskeyService service.
+type ProtectedPasskeyServiceClient interface {
+	BeginPasskeyRegistration(context.Context, *connect.Request[v1.BeginPasskeyRegistrationRequest]) (*connect.Response[v1.BeginPasskeyRegistrationResponse], error)
+	FinishPasskeyRegistration(context.Context, *connect.Request[v1.FinishPasskeyRegistrationRequest]) (*connect.Response[v1.FinishPasskeyRegistrationResponse], error)
+	ListPasskeys(context.Context, *connect.Request[v1.ListPasskeysRequest]) (*connect.Response[v1.ListPasskeysResponse], error)
+	DeletePasskey(context.Context, *connect.Request[v1.DeletePasskeyRequest]) (*connect.Response[v1.DeletePasskeyResponse], error)
+}
+
+// NewProtectedPasskeyServiceClient constructs a client for the proto.api.v1.ProtectedPasskeyService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewProtectedPasskeyServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ProtectedPasskeyServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &protectedPasskeyServiceClient{
+		beginPasskeyRegistration: connect.NewClient[v1.BeginPasskeyRegistrationRequest, v1.BeginPasskeyRegistrationResponse](
+			httpClient,
+			baseURL+ProtectedPasskeyServiceBeginPasskeyRegistrationProcedure,
+			connect.WithSchema(protectedPasskeyServiceBeginPasskeyRegistrationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		finishPasskeyRegistration: connect.NewClient[v1.FinishPasskeyRegistrationRequest, v1.FinishPasskeyRegistrationResponse](
+			httpClient,
+			baseURL+ProtectedPasskeyServiceFinishPasskeyRegistrationProcedure,
+			connect.WithSchema(protectedPasskeyServiceFinishPasskeyRegistrationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listPasskeys: connect.NewClient[v1.ListPasskeysRequest, v1.ListPasskeysResponse](
+			httpClient,
+			baseURL+ProtectedPasskeyServiceListPasskeysProcedure,
+			connect.WithSchema(protectedPasskeyServiceListPasskeysMethodDescriptor),
+			connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+			connect.WithClientOptions(opts...),
+		),
+		deletePasskey: connect.NewClient[v1.DeletePasskeyRequest, v1.DeletePasskeyResponse](
+			httpClient,
+			baseURL+ProtectedPasskeyServiceDeletePasskeyProcedure,
+			connect.WithSchema(protectedPasskeyServiceDeletePasskeyMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// protectedPasskeyServiceClient implements ProtectedPasskeyServiceClient.
+type protectedPasskeyServiceClient struct {
+	beginPasskeyRegistration  *connect.Client[v1.BeginPasskeyRegistrationRequest, v1.BeginPasskeyRegistrationResponse]
+	finishPasskeyRegistration *connect.Client[v1.FinishPasskeyRegistrationRequest, v1.FinishPasskeyRegistrationResponse]
+	listPasskeys              *connect.Client[v1.ListPasskeysRequest, v1.ListPasskeysResponse]
+	deletePasskey             *connect.Client[v1.DeletePasskeyRequest, v1.DeletePasskeyResponse]
+}
+
+// BeginPasskeyRegistration calls proto.api.v1.ProtectedPasskeyService.BeginPasskeyRegistration.
+func (c *protectedPasskeyServiceClient) BeginPasskeyRegistration(ctx context.Context, req *connect.Request[v1.BeginPasskeyRegistrationRequest]) (*connect.Response[v1.BeginPasskeyRegistrationResponse], error) {
+	return c.beginPasskeyRegistration.CallUnary(ctx, req)
+}
+
+// FinishPasskeyRegistration calls proto.api.v1.ProtectedPasskeyService.FinishPasskeyRegistration.
+func (c *protectedPasskeyServiceClient) FinishPasskeyRegistration(ctx context.Context, req *connect.Request[v1.FinishPasskeyRegistrationRequest]) (*connect.Response[v1.FinishPasskeyRegistrationResponse], error) {
+	return c.finishPasskeyRegistration.CallUnary(ctx, req)
+}
+
+// ListPasskeys calls proto.api.v1.ProtectedPasskeyService.ListPasskeys.
+func (c *protectedPasskeyServiceClient) ListPasskeys(ctx context.Context, req *connect.Request[v1.ListPasskeysRequest]) (*connect.Response[v1.ListPasskeysResponse], error) {
+	return c.listPasskeys.CallUnary(ctx, req)
+}
+
+// DeletePasskey calls proto.api.v1.ProtectedPasskeyService.DeletePasskey.
+func (c *protectedPasskeyServiceClient) DeletePasskey(ctx context.Context, req *connect.Request[v1.DeletePasskeyRequest]) (*connect.Response[v1.DeletePasskeyResponse], error) {
+	return c.deletePasskey.CallUnary(ctx, req)
+}
+
+// ProtectedPasskeyServiceHandler is an implementation of the proto.api.v1.ProtectedPasskeyService
+// service.
+type ProtectedPasskeyServiceHandler interface {
+	BeginPasskeyRegistration(context.Context, *connect.Request[v1.BeginPasskeyRegistrationRequest]) (*connect.Response[v1.BeginPasskeyRegistrationResponse], error)
+	FinishPasskeyRegistration(context.Context, *connect.Request[v1.FinishPasskeyRegistrationRequest]) (*connect.Response[v1.FinishPasskeyRegistrationResponse], error)
+	ListPasskeys(context.Context, *connect.Request[v1.ListPasskeysRequest]) (*connect.Response[v1.ListPasskeysResponse], error)
+	DeletePasskey(context.Context, *connect.Request[v1.DeletePasskeyRequest]) (*connect.Response[v1.DeletePasskeyResponse], error)
+}
+
+// NewProtectedPasskeyServiceHandler builds an HTTP handler from the service implementation. It
+// returns the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewProtectedPasskeyServiceHandler(svc ProtectedPasskeyServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	protectedPasskeyServiceBeginPasskeyRegistrationHandler := connect.NewUnaryHandler(
+		ProtectedPasskeyServiceBeginPasskeyRegistrationProcedure,
+		svc.BeginPasskeyRegistration,
+		connect.WithSchema(protectedPasskeyServiceBeginPasskeyRegistrationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedPasskeyServiceFinishPasskeyRegistrationHandler := connect.NewUnaryHandler(
+		ProtectedPasskeyServiceFinishPasskeyRegistrationProcedure,
+		svc.FinishPasskeyRegistration,
+		connect.WithSchema(protectedPasskeyServiceFinishPasskeyRegistrationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedPasskeyServiceListPasskeysHandler := connect.NewUnaryHandler(
+		ProtectedPasskeyServiceListPasskeysProcedure,
+		svc.ListPasskeys,
+		connect.WithSchema(protectedPasskeyServiceListPasskeysMethodDescriptor),
+		connect.WithIdempotency(connect.IdempotencyNoSideEffects),
+		connect.WithHandlerOptions(opts...),
+	)
+	protectedPasskeyServiceDeletePasskeyHandler := connect.NewUnaryHandler(
+		ProtectedPasskeyServiceDeletePasskeyProcedure,
+		svc.DeletePasskey,
+		connect.WithSchema(protectedPasskeyServiceDeletePasskeyMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/proto.api.v1.ProtectedPasskeyService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ProtectedPasskeyServiceBeginPasskeyRegistrationProcedure:
+			protectedPasskeyServiceBeginPasskeyRegistrationHandler.ServeHTTP(w, r)
+		case ProtectedPasskeyServiceFinishPasskeyRegistrationProcedure:
+			protectedPasskeyServiceFinishPasskeyRegistrationHandler.ServeHTTP(w, r)
+		case ProtectedPasskeyServiceListPasskeysProcedure:
+			protectedPasskeyServiceListPasskeysHandler.ServeHTTP(w, r)
+		case ProtectedPasskeyServiceDeletePasskeyProcedure:
+			protectedPasskeyServiceDeletePasskeyHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedProtectedPasskeyServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedProtectedPasskeyServiceHandler struct{}
+
+func (UnimplementedProtectedPasskeyServiceHandler) BeginPasskeyRegistration(context.Context, *connect.Request[v1.BeginPasskeyRegistrationRequest]) (*connect.Response[v1.BeginPasskeyRegistrationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedPasskeyService.BeginPasskeyRegistration is not implemented"))
+}
+
+func (UnimplementedProtectedPasskeyServiceHandler) FinishPasskeyRegistration(context.Context, *connect.Request[v1.FinishPasskeyRegistrationRequest]) (*connect.Response[v1.FinishPasskeyRegistrationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedPasskeyService.FinishPasskeyRegistration is not implemented"))
+}
+
+func (UnimplementedProtectedPasskeyServiceHandler) ListPasskeys(context.Context, *connect.Request[v1.ListPasskeysRequest]) (*connect.Response[v1.ListPasskeysResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedPasskeyService.ListPasskeys is not implemented"))
+}
+
+func (UnimplementedProtectedPasskeyServiceHandler) DeletePasskey(context.Context, *connect.Request[v1.DeletePasskeyRequest]) (*connect.Response[v1.DeletePasskeyResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("proto.api.v1.ProtectedPasskeyService.DeletePasskey is not implemented"))
+}
@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: proto/api/v1/options.proto
+
+package apiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AuthRequirement is who may call a procedure. Services set a default for
+// their methods which methods can override, procedures without either
+// require AUTH_REQUIREMENT_AUTHENTICATED.
+type AuthRequirement int32
+
+const (
+	AuthRequirement_AUTH_REQUIREMENT_UNSPECIFIED AuthRequirement = 0
+	// Anyone, credentials are still resolved when present
+	AuthRequirement_AUTH_REQUIREMENT_PUBLIC AuthRequirement = 1
+	// A signed in session or a personal access token
+	AuthRequirement_AUTH_REQUIREMENT_AUTHENTICATED AuthRequirement = 2
+	// A signed in session, for managing credentials that tokens must not touch
+	AuthRequirement_AUTH_REQUIREMENT_SESSION AuthRequirement = 3
+)
+
+// Enum value maps for AuthRequirement.
+var (
+	AuthRequirement_name = map[int32]string{
+		0: "AUTH_REQUIREMENT_UNSPECIFIED",
+		1: "AUTH_REQUIREMENT_PUBLIC",
+		2: "AUTH_REQUIREMENT_AUTHENTICATED",
+		3: "AUTH_REQUIREMENT_SESSION",
+	}
+	AuthRequirement_value = map[string]int32{
+		"AUTH_REQUIREMENT_UNSPECIFIED":   0,
+		"AUTH_REQUIREMENT_PUBLIC":        1,
+		"AUTH_REQUIREMENT_AUTHENTICATED": 2,
+		"AUTH_REQUIREMENT_SESSION":       3,
+	}
+)
+
+func (x AuthRequirement) Enum() *AuthRequirement {
+	p := new(AuthRequirement)
+	*p = x
+	return p
+}
+
+func (x AuthRequirement) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AuthRequirement) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_api_v1_options_proto_enumTypes[0].Descriptor()
+}
+
+func (AuthRequirement) Type() protoreflect.EnumType {
+	return &file_proto_api_v1_options_proto_enumTypes[0]
+}
+
+func (x AuthRequirement) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AuthRequirement.Descriptor instead.
+func (AuthRequirement) EnumDescriptor() ([]byte, []int) {
+	return file_proto_api_v1_options_proto_rawDescGZIP(), []int{0}
+}
+
+var file_proto_api_v1_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: (*AuthRequirement)(nil),
+		Field:         50000,
+		Name:          "proto.api.v1.default_auth",
+		Tag:           "varint,50000,opt,name=default_auth,enum=proto.api.v1.AuthRequirement",
+		Filename:      "proto/api/v1/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*AuthRequirement)(nil),
+		Field:         50000,
+		Name:          "proto.api.v1.auth",
+		Tag:           "varint,50000,opt,name=auth,enum=proto.api.v1.AuthRequirement",
+		Filename:      "proto/api/v1/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         50001,
+		Name:          "proto.api.v1.permission",
+		Tag:           "bytes,50001,opt,name=permission",
+		Filename:      "proto/api/v1/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.ServiceOptions.
+var (
+	// optional proto.api.v1.AuthRequirement default_auth = 50000;
+	E_DefaultAuth = &file_proto_api_v1_options_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// optional proto.api.v1.AuthRequirement auth = 50000;
+	E_Auth = &file_proto_api_v1_options_proto_extTypes[1]
+	// Permission the caller's roles must grant, e.g. "roles.manage". Implies
+	// AUTH_REQUIREMENT_AUTHENTICATED unless the method asks for a session.
+	//
+	// optional string permission = 50001;
+	E_Permission = &file_proto_api_v1_options_proto_extTypes[2]
+)
+
+var File_proto_api_v1_options_proto protoreflect.FileDescriptor
+
+var file_proto_api_v1_options_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x6f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2a, 0x92, 0x01, 0x0a,
+	0x0f, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x20, 0x0a, 0x1c, 0x41, 0x55, 0x54, 0x48, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x49, 0x52, 0x45,
+	0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x41, 0x55, 0x54, 0x48, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x49,
+	0x52, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x50, 0x55, 0x42, 0x4c, 0x49, 0x43, 0x10, 0x01, 0x12,
+	0x22, 0x0a, 0x1e, 0x41, 0x55, 0x54, 0x48, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x49, 0x52, 0x45, 0x4d,
+	0x45, 0x4e, 0x54, 0x5f, 0x41, 0x55, 0x54, 0x48, 0x45, 0x4e, 0x54, 0x49, 0x43, 0x41, 0x54, 0x45,
+	0x44, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x41, 0x55, 0x54, 0x48, 0x5f, 0x52, 0x45, 0x51, 0x55,
+	0x49, 0x52, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x10,
+	0x03, 0x3a, 0x63, 0x0a, 0x0c, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x61, 0x75, 0x74,
+	0x68, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0xd0, 0x86, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x69, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0b, 0x64, 0x65, 0x66, 0x61, 0x75,
+	0x6c, 0x74, 0x41, 0x75, 0x74, 0x68, 0x3a, 0x53, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x12, 0x1e,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xd0,
+	0x86, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x3a, 0x40, 0x0a, 0x0a, 0x70,
+	0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68,
+	0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xd1, 0x86, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x27, 0x5a,
+	0x25, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x2d, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x2f,
+	0x67, 0x65, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31,
+	0x3b, 0x61, 0x70, 0x69, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_api_v1_options_proto_rawDescOnce sync.Once
+	file_proto_api_v1_options_proto_rawDescData = file_proto_api_v1_options_proto_rawDesc
+)
+
+func file_proto_api_v1_options_proto_rawDescGZIP() []byte {
+	file_proto_api_v1_options_proto_rawDescOnce.Do(func() {
+		file_proto_api_v1_options_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_api_v1_options_proto_rawDescData)
+	})
+	return file_proto_api_v1_options_proto_rawDescData
+}
+
+var file_proto_api_v1_options_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_api_v1_options_proto_goTypes = []any{
+	(AuthRequirement)(0),                // 0: proto.api.v1.AuthRequirement
+	(*descriptorpb.ServiceOptions)(nil), // 1: google.protobuf.ServiceOptions
+	(*descriptorpb.MethodOptions)(nil),  // 2: google.protobuf.MethodOptions
+}
+var file_proto_api_v1_options_proto_depIdxs = []int32{
+	1, // 0: proto.api.v1.default_auth:extendee -> google.protobuf.ServiceOptions
+	2, // 1: proto.api.v1.auth:extendee -> google.protobuf.MethodOptions
+	2, // 2: proto.api.v1.permission:extendee -> google.protobuf.MethodOptions
+	0, // 3: proto.api.v1.default_auth:type_name -> proto.api.v1.AuthRequirement
+	0, // 4: proto.api.v1.auth:type_name -> proto.api.v1.AuthRequirement
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	3, // [3:5] is the sub-list for extension type_name
+	0, // [0:3] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_api_v1_options_proto_init() }
+func file_proto_api_v1_options_proto_init() {
+	if File_proto_api_v1_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_api_v1_options_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 3,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_api_v1_options_proto_goTypes,
+		DependencyIndexes: file_proto_api_v1_options_proto_depIdxs,
+		EnumInfos:         file_proto_api_v1_options_proto_enumTypes,
+		ExtensionInfos:    file_proto_api_v1_options_proto_extTypes,
+	}.Build()
+	File_proto_api_v1_options_proto = out.File
+	file_proto_api_v1_options_proto_rawDesc = nil
+	file_proto_api_v1_options_proto_goTypes = nil
+	file_proto_api_v1_options_proto_depIdxs = nil
+}
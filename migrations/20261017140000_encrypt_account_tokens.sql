@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- access_token and refresh_token hold ciphertext under a per row data key.
+-- token_data_key is that data key wrapped by the key ring key named in token_key_id.
+-- Rows with a NULL token_key_id predate encryption and are still plaintext until re-encrypted.
+ALTER TABLE accounts ADD COLUMN token_key_id TEXT;
+ALTER TABLE accounts ADD COLUMN token_data_key BYTEA;
+
+CREATE INDEX accounts_token_key_id_idx ON accounts (token_key_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX accounts_token_key_id_idx;
+ALTER TABLE accounts DROP COLUMN token_data_key;
+ALTER TABLE accounts DROP COLUMN token_key_id;
+-- +goose StatementEnd
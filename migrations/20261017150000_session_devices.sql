@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- id is the sha256 of the token so sessions can be referred to without exposing the token
+ALTER TABLE sessions ADD COLUMN id TEXT;
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX sessions_id_idx ON sessions (id);
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX sessions_user_id_idx;
+DROP INDEX sessions_id_idx;
+ALTER TABLE sessions DROP COLUMN ip_address;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN id;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX login_ip_failures_failed_at_idx ON login_ip_failures (failed_at);
+CREATE INDEX login_throttles_last_failed_at_idx ON login_throttles (last_failed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX login_throttles_last_failed_at_idx;
+DROP INDEX login_ip_failures_failed_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE rate_limit_buckets ADD COLUMN full_at TIMESTAMPTZ;
+
+-- Buckets kept so far were pruned a day after their last use
+UPDATE rate_limit_buckets SET full_at = updated_at + INTERVAL '24 hours';
+
+DROP INDEX rate_limit_buckets_updated_at_idx;
+CREATE INDEX rate_limit_buckets_full_at_idx ON rate_limit_buckets (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX rate_limit_buckets_full_at_idx;
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+ALTER TABLE rate_limit_buckets DROP COLUMN full_at;
+-- +goose StatementEnd
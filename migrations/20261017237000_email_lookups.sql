@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX users_lower_email_idx ON users (lower(email));
+CREATE INDEX magic_link_tokens_expires_at_idx ON magic_link_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX magic_link_tokens_expires_at_idx;
+DROP INDEX users_lower_email_idx;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Emails only differing in case are kept by one user, verified first, then the oldest. The others lose
+-- the email and keep their other ways to sign in.
+UPDATE users SET email = NULL, email_verified = NULL
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY lower(email) ORDER BY email_verified IS NULL, created_at, id) AS n
+        FROM users
+        WHERE email IS NOT NULL
+    ) ranked
+    WHERE n > 1
+);
+
+DROP INDEX users_lower_email_idx;
+CREATE UNIQUE INDEX users_lower_email_idx ON users (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_lower_email_idx;
+CREATE INDEX users_lower_email_idx ON users (lower(email));
+-- +goose StatementEnd
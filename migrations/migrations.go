@@ -0,0 +1,7 @@
+// Package migrations embeds the goose migrations so the server can tell whether they have all been applied
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS